@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/cli"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultSeedUsers is the number of users generated by seed without an argument
+const defaultSeedUsers = 10
+
+// openServices connects to the database and builds the service layer used by data commands
+// The schema must already be migrated; the returned function closes the connection
+func openServices(cfg config.Config) (*service.Service, func(), error) {
+	if !service.ValidDuplicatePolicy(cfg.DuplicatePolicy) {
+		return nil, nil, fmt.Errorf("invalid DUPLICATE_POLICY %q, expected warn or reject", cfg.DuplicatePolicy)
+	}
+
+	db, err := postgres.NewPostgresDB(cfg.DB)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize db: %w", err)
+	}
+
+	services := service.NewService(postgres.NewRepository(db), service.Config{DuplicatePolicy: cfg.DuplicatePolicy})
+	return services, func() { db.Close() }, nil
+}
+
+// runSeed generates fake users with subscriptions: seed [USERS]
+func runSeed(cfg config.Config, args []string) error {
+	users := defaultSeedUsers
+	switch len(args) {
+	case 0:
+	case 1:
+		value, err := strconv.Atoi(args[0])
+		if err != nil || value < 1 {
+			return fmt.Errorf("invalid number of users %q", args[0])
+		}
+		users = value
+	default:
+		return errors.New("usage: seed [flags] [USERS]")
+	}
+
+	services, closeDB, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	rng := rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0))
+	result, err := cli.Seed(context.Background(), services, rng, users, time.Now())
+	logrus.WithFields(logrus.Fields{"users": result.Users, "subscriptions": result.Subscriptions}).Info("seed finished")
+	return err
+}
+
+// runImport creates subscriptions from a file: import FILE (.csv, .ndjson or - for NDJSON on stdin)
+func runImport(cfg config.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: import [flags] FILE")
+	}
+	format, err := cli.FormatFromPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	var input io.Reader = os.Stdin
+	if args[0] != cli.StdioPath {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	services, closeDB, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	result, err := cli.Import(context.Background(), services, input, format)
+	for _, rowErr := range result.Errors {
+		logrus.Warn(rowErr.Error())
+	}
+	logrus.WithFields(logrus.Fields{
+		"imported":      result.Imported,
+		"failed":        len(result.Errors),
+		"created_users": result.CreatedUsers,
+	}).Info("import finished")
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%d rows were not imported", len(result.Errors))
+	}
+	return nil
+}
+
+// runExport writes subscriptions to a file: export FILE [USER_ID] (.csv, .ndjson or - for NDJSON on stdout)
+func runExport(cfg config.Config, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: export [flags] FILE [USER_ID]")
+	}
+	format, err := cli.FormatFromPath(args[0])
+	if err != nil {
+		return err
+	}
+	var userID *string
+	if len(args) == 2 {
+		userID = &args[1]
+	}
+
+	services, closeDB, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	var output io.Writer = os.Stdout
+	if args[0] != cli.StdioPath {
+		file, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		output = file
+	}
+
+	exported, err := cli.Export(context.Background(), services.SubscriptionStore, output, format, userID)
+	if err != nil {
+		return err
+	}
+	logrus.WithField("subscriptions", exported).Info("export finished")
+	return nil
+}
+
+// runReport prints the monthly spend of a user: report USER_ID [FROM [TO]] with months in MM-YYYY,
+// by default the last twelve months including the current one
+func runReport(cfg config.Config, args []string) error {
+	if len(args) < 1 || len(args) > 3 {
+		return errors.New("usage: report [flags] USER_ID [FROM [TO]]")
+	}
+
+	thisMonth := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.UTC)
+	from := thisMonth.AddDate(0, -11, 0).Format("01-2006")
+	to := thisMonth.Format("01-2006")
+	if len(args) > 1 {
+		from = args[1]
+	}
+	if len(args) > 2 {
+		to = args[2]
+	}
+
+	services, closeDB, err := openServices(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	return cli.Report(context.Background(), os.Stdout, services.AnalyticsStore, args[0], from, to)
+}
@@ -1,21 +1,40 @@
 package main
 
 import (
-	"context"
 	"os"
-	"os/signal"
-	"syscall"
+	"sort"
+	"strings"
+	_ "time/tzdata" // User timezones are validated without relying on zoneinfo of the image
 
-	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
-	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
-	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/server"
-	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/logging"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/config"
 
-	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
+// commands are the subcommands of the binary; each gets the shared configuration and
+// the arguments left after the configuration flags
+var commands = map[string]func(cfg config.Config, args []string) error{
+	"serve":   runServe,   // Start the HTTP server (default)
+	"migrate": runMigrate, // Control the database schema
+	"seed":    runSeed,    // Generate fake users and subscriptions
+	"import":  runImport,  // Create subscriptions from a CSV or NDJSON file
+	"export":  runExport,  // Write subscriptions to a CSV or NDJSON file
+	"report":  runReport,  // Print the monthly spend of a user
+	"spend":   runSpend,   // Rebuild, refresh or check the monthly spend aggregate
+}
+
+// commandNames returns the sorted names of the subcommands
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // @title Subscription Aggregator API
 // @version 1.0
 // @description API для агрегатора подписок
@@ -26,75 +45,35 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
+
+// @securityDefinitions.apikey UserIDAuth
+// @in header
+// @name X-User-ID
 func main() {
 	// Configuring the logs format in JSON for better structuring and compatibility
 	// with monitoring systems (Kibana, Elasticsearch, etc.)
 	logrus.SetFormatter(new(logrus.JSONFormatter))
-
-	// Loading environment variables from the config.env file
-	if err := godotenv.Load("config.env"); err != nil {
-		logrus.Fatalf("error loading env variables: %s", err.Error())
+	// Request and trace IDs from the context are added to lines logged with logrus.WithContext
+	logrus.AddHook(logging.ContextHook{})
+
+	// The first argument selects the command, the server is started by default
+	// Usage: app [command] [configuration flags] [command arguments]
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
 	}
 
-	// Initializing a connection to PostgreSQL using parameters from environment variables
-	db, err := postgres.NewPostgresDB(postgres.Config{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     os.Getenv("DB_PORT"),
-		Username: os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   os.Getenv("DB_NAME"),
-		SSLMode:  os.Getenv("DB_SSL_MODE"),
-	})
+	// Loading configuration from defaults, config.env, environment variables and flags
+	cfg, args, err := config.Parse(args)
 	if err != nil {
-		logrus.Fatalf("failed to initialize db: %s", err.Error())
+		logrus.Fatalf("invalid configuration: %s", err.Error())
 	}
 
-	err = postgres.RunMigrations(db)
-	if err != nil {
-		logrus.Fatal(err)
+	run, ok := commands[command]
+	if !ok {
+		logrus.Fatalf("unknown command %q, expected one of: %s", command, strings.Join(commandNames(), ", "))
 	}
-
-	// Initializing repositories for working with data
-	// repos provides access to PostgreSQL data
-	repos := postgres.NewRepository(db)
-
-	// Creating a service layer with dependency injection
-	// service encapsulates the business logic of the application
-	service := service.NewService(repos)
-
-	// Initialization of HTTP handlers with the introduction of a service layer
-	// Handlers will use business logic via service
-	handlers := handler.NewHandler(service)
-
-	// Creating a server instance
-	srv := new(server.Server)
-
-	// Launching an HTTP server in a separate goroutine
-	go func() {
-		// Launching an HTTPS server with configuration from environment variables
-		// Using HOST and HOST_PORT from config.env
-		if err := srv.Run(os.Getenv("HOST"), os.Getenv("HOST_PORT"), handlers.InitRoutes()); err != nil {
-			logrus.Fatalf("error occurred while running http server: %s", err.Error())
-		}
-	}()
-
-	logrus.Print("SubscriptionAggregatorApp Started")
-
-	// Channel for processing termination signals
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT) // Waiting for completion signals
-	<-quit                                               // Blocking until the signal is received
-
-	logrus.Print("SubscriptionAggregatorApp Shutting Down")
-
-	// Graceful server shutdown
-	if err := srv.Shutdown(context.Background()); err != nil {
-		logrus.Errorf("error occurred on server shutting down: %s", err.Error())
-	}
-
-	// Closing the DATABASE connection
-	if err := db.Close(); err != nil {
-		logrus.Errorf("error occurred on db connection close: %s", err.Error())
+	if err := run(cfg, args); err != nil {
+		logrus.Fatal(err)
 	}
-
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// migrateUsage describes the arguments of the migrate command
+const migrateUsage = "usage: migrate [flags] up | down N | version | force V"
+
+// runMigrate controls the schema independently of server startup:
+// up applies pending migrations, down N rolls back the last N, version prints the applied
+// version and force V marks V as applied to recover from a failed migration
+func runMigrate(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	// Arguments are validated before connecting so mistakes fail fast
+	var n int
+	switch args[0] {
+	case "up", "version":
+		if len(args) != 1 {
+			return errors.New(migrateUsage)
+		}
+	case "down", "force":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		value, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid %s argument %q: expected a number", args[0], args[1])
+		}
+		n = value
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", args[0], migrateUsage)
+	}
+
+	db, err := postgres.NewPostgresDB(cfg.DB)
+	if err != nil {
+		return fmt.Errorf("failed to initialize db: %w", err)
+	}
+	defer db.Close()
+
+	m, err := postgres.NewMigrator(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Down(n)
+	case "force":
+		err = m.Force(n)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if args[0] == "version" {
+		fmt.Println(version)
+	}
+	logrus.WithFields(logrus.Fields{"version": version, "dirty": dirty}).Info("schema version")
+
+	return nil
+}
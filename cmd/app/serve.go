@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/cache"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/health"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/lifecycle"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/metrics"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/outbox"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/ratelimit"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/server"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/tracing"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/worker"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// runServe starts the HTTP server with background workers and blocks until SIGTERM or SIGINT
+func runServe(cfg config.Config, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("serve takes no arguments, got %q", args)
+	}
+
+	// Tracing: spans of routes, subscription service methods and SQL statements
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+
+	// Initializing the PostgreSQL connection pool; connections are made on first use
+	db, err := postgres.Open(cfg.DB)
+	if err != nil {
+		return fmt.Errorf("failed to initialize db: %w", err)
+	}
+
+	// Startup waits for the database with backoff and applies migrations.
+	// Auto-migration can be disabled to run "migrate up" as a separate deployment step;
+	// the readiness probe then reports the server not ready until the schema is current
+	startup := func(ctx context.Context) error {
+		if err := postgres.WaitReady(ctx, db, cfg.DB); err != nil {
+			return err
+		}
+		if cfg.AutoMigrate {
+			if err := postgres.RunMigrations(db); err != nil {
+				return err
+			}
+			logrus.Print("Migrations applied successfully")
+		}
+		return nil
+	}
+
+	// By default the server only starts once the database is usable. In the background mode
+	// it starts at once and stays not ready, so orchestrators see a live process while the
+	// database is still coming up; the startup is retried until it succeeds or shutdown begins
+	startupCtx, cancelStartup := context.WithCancel(context.Background())
+	defer cancelStartup()
+	if !cfg.ConnectInBackground {
+		ctx, cancel := context.WithTimeout(startupCtx, cfg.DB.ConnectTimeout)
+		err := startup(ctx)
+		cancel()
+		if err != nil {
+			db.Close()
+			return fmt.Errorf("failed to initialize db: %w", err)
+		}
+	}
+
+	// Prometheus metrics: HTTP requests, connection pool, repository calls and business gauges
+	appMetrics := metrics.New()
+	appMetrics.RegisterDB(db.DB, "postgres")
+
+	// Initializing repositories for working with data
+	// repos provides access to PostgreSQL data, every call is timed
+	repos := appMetrics.InstrumentRepository(postgres.NewRepository(db))
+
+	// Selecting where outbox events are delivered: a webhook if configured, the log otherwise
+	var publisher outbox.Publisher = outbox.NewLogPublisher()
+	if cfg.WebhookURL != "" {
+		publisher = outbox.NewWebhookPublisher(cfg.WebhookURL, 10*time.Second)
+	}
+
+	// Creating a service layer with dependency injection
+	// service encapsulates the business logic of the application
+	// DUPLICATE_POLICY controls overlapping subscriptions: "warn" (default) or "reject"
+	if !service.ValidDuplicatePolicy(cfg.DuplicatePolicy) {
+		return fmt.Errorf("invalid DUPLICATE_POLICY %q, expected warn or reject", cfg.DuplicatePolicy)
+	}
+	service := service.NewService(repos, service.Config{DuplicatePolicy: cfg.DuplicatePolicy})
+
+	// Subscriptions and summaries read by dashboards are served from memory for up to CACHE_TTL.
+	// Writes through the subscription service drop entries at once; changes committed anywhere
+	// else (other replicas, pauses, members, organizations, lifecycle jobs) are announced by
+	// database triggers and drop entries shortly after commit, so the cache requires CACHE_NOTIFY
+	var subscriptionCache *cache.SubscriptionCache
+	if cfg.Cache.Enabled {
+		subscriptionCache = cache.NewSubscriptionCache(service.SubscriptionStore, cfg.Cache, appMetrics)
+		service.SubscriptionStore = subscriptionCache
+	}
+
+	// The lifecycle manager tracks readiness, in-flight requests, workers and resources
+	// so shutdown can stop them in order
+	app := lifecycle.NewManager()
+	app.OnClose("tracing", func() error {
+		// Pending spans are flushed after everything else has stopped
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdownTracing(ctx)
+	})
+	app.OnClose("database", db.Close)
+
+	if subscriptionCache != nil && cfg.Cache.Notify {
+		listener, err := postgres.NewChangeListener(cfg.DB)
+		if err != nil {
+			return fmt.Errorf("failed to initialize cache invalidation: %w", err)
+		}
+		app.Go("cache-invalidation", func(ctx context.Context) {
+			listener.Run(ctx, subscriptionCache.Invalidate, subscriptionCache.Reset)
+		})
+	}
+
+	// Readiness checks: traffic is accepted, the database answers, the schema is current
+	// and background workers are running
+	expectedVersion, err := postgres.LatestMigrationVersion()
+	if err != nil {
+		return fmt.Errorf("failed to determine schema version: %w", err)
+	}
+	readiness := health.NewChecker(cfg.HealthTimeout)
+	readiness.Add("lifecycle", health.Accepting(app.Ready))
+	readiness.Add("database", health.Database(repos.HealthStore))
+	readiness.Add("migrations", health.Migrations(repos.HealthStore, expectedVersion))
+	readiness.Add("workers", health.Workers(app.Workers))
+
+	// Rate limiting per client and route class; the postgres store shares buckets
+	// between replicas, the memory store limits each replica separately
+	var limiter *ratelimit.Limiter
+	var limitCleanup *worker.Periodic
+	if cfg.RateLimit.Enabled {
+		var store ratelimit.Store = ratelimit.NewMemoryStore()
+		if cfg.RateLimit.Store == ratelimit.StorePostgres {
+			store = repos.RateLimitStore
+
+			// Idle buckets are full again after a while and can be dropped
+			limitCleanup = worker.NewPeriodic("rate-limit-cleanup", 10*time.Minute, func(ctx context.Context) error {
+				_, err := repos.RateLimitStore.DeleteIdle(ctx, time.Hour)
+				return err
+			})
+		}
+		limiter = ratelimit.NewLimiter(store, cfg.RateLimit)
+	}
+
+	// Initialization of HTTP handlers with the introduction of a service layer
+	// Handlers will use business logic via service
+	handlers := handler.NewHandler(service, readiness, appMetrics, limiter)
+
+	// Periodic workers read and change data, so they start only once the database is usable
+	// and the schema is current: right away by default, after the startup in the background mode
+	startWorkers := func() {
+		if limitCleanup != nil {
+			app.Go("rate-limit-cleanup", limitCleanup.Run)
+		}
+
+		// Outbox relay publishes events committed together with data changes
+		relay := outbox.NewRelay(repos.OutboxStore, publisher, outbox.DefaultInterval, outbox.DefaultBatchSize)
+		app.Go("outbox-relay", relay.Run)
+
+		// Trial converter announces trials that turned into paid subscriptions
+		trials := worker.NewPeriodic("trial-conversion", time.Hour, func(ctx context.Context) error {
+			converted, err := service.TrialStore.ConvertEndedTrials(ctx)
+			if converted > 0 {
+				logrus.Infof("converted %d ended trials", converted)
+			}
+			return err
+		})
+		app.Go("trial-conversion", trials.Run)
+
+		// Pauses scheduled for a later month change the status once the month comes
+		pauses := worker.NewPeriodic("pause-status", time.Hour, func(ctx context.Context) error {
+			changed, err := service.PauseStore.SyncStatuses(ctx)
+			if changed > 0 {
+				logrus.Infof("updated status of %d paused or resumed subscriptions", changed)
+			}
+			return err
+		})
+		app.Go("pause-status", pauses.Run)
+
+		// Budget evaluation catches up with evaluations that failed after a change
+		// and alerts budgets that are exceeded again in a new month
+		budgetChecks := worker.NewPeriodic("budget-evaluation", time.Hour, func(ctx context.Context) error {
+			_, err := service.BudgetStore.EvaluateAll(ctx)
+			return err
+		})
+		app.Go("budget-evaluation", budgetChecks.Run)
+
+		// Expiry job closes out subscriptions past their finish date
+		// An advisory lock lets only one replica run it at a time
+		expiry := worker.NewPeriodic("subscription-expiry", time.Hour, func(ctx context.Context) error {
+			expired, err := service.LifecycleStore.ExpireEnded(ctx)
+			if expired > 0 {
+				logrus.Infof("expired %d ended subscriptions", expired)
+			}
+			return err
+		})
+		app.Go("subscription-expiry", expiry.Run)
+
+		// Monthly spend aggregate: changes recorded by database triggers are applied and the
+		// covered months follow the horizon; summaries fall back to raw data while changes are pending
+		// An advisory lock lets only one replica refresh at a time, the first run builds a missing aggregate
+		if cfg.Spend.RefreshInterval > 0 {
+			spend := worker.NewPeriodic("monthly-spend", cfg.Spend.RefreshInterval, func(ctx context.Context) error {
+				refresh, err := repos.SpendStore.Refresh(ctx, cfg.Spend.Until(time.Now()))
+				if refresh.Rebuilt || refresh.Months > 0 {
+					logrus.WithFields(logrus.Fields{"rebuilt": refresh.Rebuilt, "months": refresh.Months}).Info("monthly spend aggregate extended")
+				}
+				return err
+			})
+			app.Go("monthly-spend", spend.Run)
+		}
+
+		// Business gauges are refreshed periodically instead of querying the database on every scrape
+		stats := worker.NewPeriodic("business-metrics", time.Minute, func(ctx context.Context) error {
+			current, err := service.StatsStore.GetStats(ctx)
+			if err != nil {
+				return err
+			}
+			appMetrics.SetStats(current)
+			return nil
+		})
+		app.Go("business-metrics", stats.Run)
+	}
+
+	// Creating a server instance with the configured address, timeouts and certificate
+	srv, err := server.NewServer(cfg.HTTP, app.Track(handlers.InitRoutes()))
+	if err != nil {
+		return fmt.Errorf("error occurred while creating http server: %w", err)
+	}
+
+	// Launching an HTTP server in a separate goroutine
+	go func() {
+		if err := srv.Run(); err != nil {
+			logrus.Fatalf("error occurred while running http server: %s", err.Error())
+		}
+	}()
+
+	// startMu keeps workers from being started while shutdown is waiting for them
+	var startMu sync.Mutex
+	if cfg.ConnectInBackground {
+		go func() {
+			if err := startup(startupCtx); err != nil {
+				logrus.Errorf("database startup failed, the server stays not ready: %s", err.Error())
+				return
+			}
+			startMu.Lock()
+			defer startMu.Unlock()
+			if startupCtx.Err() == nil {
+				startWorkers()
+				app.SetReady(true)
+				logrus.Print("Database ready, accepting traffic")
+			}
+		}()
+	} else {
+		startWorkers()
+		app.SetReady(true)
+	}
+
+	logrus.Print("SubscriptionAggregatorApp Started")
+
+	// Channel for processing termination signals
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT) // Waiting for completion signals
+	<-quit                                               // Blocking until the signal is received
+
+	logrus.Print("SubscriptionAggregatorApp Shutting Down")
+
+	// A database startup still running in the background must not start workers or mark
+	// the server ready again
+	startMu.Lock()
+	cancelStartup()
+	startMu.Unlock()
+
+	// Graceful shutdown, bounded by the configured timeout: readiness fails first,
+	// then in-flight requests and background workers finish and the DB pool is closed
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	if err := app.Shutdown(ctx, cfg.HTTP.DrainDelay, srv.Shutdown); err != nil {
+		return fmt.Errorf("error occurred on shutting down: %w", err)
+	}
+
+	return nil
+}
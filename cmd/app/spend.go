@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/cli"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// spendUsage describes the arguments of the spend command
+const spendUsage = "usage: spend [flags] rebuild | refresh | check [FROM [TO]]"
+
+// runSpend maintains the monthly spend aggregate read by summaries:
+// rebuild recalculates it from scratch, refresh applies pending changes like the server job does
+// and check compares it with raw data between FROM and TO (MM-YYYY), by default from twelve
+// months ago up to the horizon, failing when any month differs
+func runSpend(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(spendUsage)
+	}
+
+	now := time.Now()
+	until := cfg.Spend.Until(now)
+	from := until.AddDate(0, -cfg.Spend.HorizonMonths-11, 0).Format("01-2006")
+	to := until.Format("01-2006")
+
+	// Arguments are validated before connecting so mistakes fail fast
+	switch args[0] {
+	case "rebuild", "refresh":
+		if len(args) != 1 {
+			return errors.New(spendUsage)
+		}
+	case "check":
+		if len(args) > 3 {
+			return errors.New(spendUsage)
+		}
+		if len(args) > 1 {
+			from = args[1]
+		}
+		if len(args) > 2 {
+			to = args[2]
+		}
+	default:
+		return fmt.Errorf("unknown spend command %q, %s", args[0], spendUsage)
+	}
+
+	db, err := postgres.NewPostgresDB(cfg.DB)
+	if err != nil {
+		return fmt.Errorf("failed to initialize db: %w", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	spend := postgres.NewSpendRepository(db)
+
+	switch args[0] {
+	case "rebuild":
+		rows, err := spend.Rebuild(ctx, until)
+		if err != nil {
+			return err
+		}
+		logrus.WithFields(logrus.Fields{"rows": rows, "until": to}).Info("monthly spend rebuilt")
+	case "refresh":
+		refresh, err := spend.Refresh(ctx, until)
+		if err != nil {
+			return err
+		}
+		logrus.WithFields(logrus.Fields{
+			"rebuilt":   refresh.Rebuilt,
+			"months":    refresh.Months,
+			"refreshed": refresh.Refreshed,
+		}).Info("monthly spend refreshed")
+	case "check":
+		differences, err := cli.CheckSpend(ctx, os.Stdout, spend, from, to)
+		if err != nil {
+			return err
+		}
+		if differences > 0 {
+			return fmt.Errorf("%d rows of the monthly spend aggregate differ from subscriptions, run spend rebuild to repair", differences)
+		}
+	}
+
+	return nil
+}
@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/subscriptions": {
+        "/analytics/forecast": {
             "get": {
-                "description": "Get all subscriptions",
+                "description": "Project month-by-month charges of active personal subscriptions starting with the current month",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,14 +25,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "analytics"
+                ],
+                "summary": "Get spend forecast",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of months to forecast (default 12)",
+                        "name": "months",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Limit the forecast to a single user",
+                        "name": "user_id",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all subscriptions",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handler.getAllSubResponse"
+                            "$ref": "#/definitions/models.Forecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
                         }
                     },
                     "500": {
@@ -42,9 +62,85 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/analytics/settlement": {
+            "get": {
+                "description": "Net amounts members of shared personal subscriptions owe the owners, per month (current month by default)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get settlements",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "First month in MM-YYYY format",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last month in MM-YYYY format (defaults to from)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Limit to debts of or to a single user",
+                        "name": "user_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.getSettlementsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Returns 200 while the process is running; dependencies are not checked",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations": {
             "post": {
-                "description": "Create a new subscription with the input payload",
+                "security": [
+                    {
+                        "UserIDAuth": []
+                    }
+                ],
+                "description": "Create an organization owned by the calling user",
                 "consumes": [
                     "application/json"
                 ],
@@ -52,23 +148,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "organizations"
                 ],
-                "summary": "Create a new subscription",
+                "summary": "Create an organization",
                 "parameters": [
                     {
-                        "description": "Subscription input",
+                        "description": "Organization input",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Subscription"
+                            "$ref": "#/definitions/models.Organization"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "subId",
+                        "description": "organizationId",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -80,6 +176,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.errorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -89,9 +191,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions/total-cost": {
+        "/organizations/{org_id}": {
             "get": {
-                "description": "Get subscription summary with filters",
+                "security": [
+                    {
+                        "UserIDAuth": []
+                    }
+                ],
+                "description": "Get an organization (viewer role required)",
                 "consumes": [
                     "application/json"
                 ],
@@ -99,17 +206,77 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "organizations"
                 ],
-                "summary": "Get subscription summary",
+                "summary": "Get organization",
                 "parameters": [
                     {
-                        "description": "Filter criteria",
+                        "type": "integer",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Organization"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "UserIDAuth": []
+                    }
+                ],
+                "description": "Rename an organization (admin role required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Update organization",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Organization input",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.SubscriptionFilter"
+                            "$ref": "#/definitions/models.Organization"
                         }
                     }
                 ],
@@ -117,7 +284,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.SubscriptionFilter"
+                            "$ref": "#/definitions/handler.statusResponse"
                         }
                     },
                     "400": {
@@ -126,6 +293,71 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.errorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "UserIDAuth": []
+                    }
+                ],
+                "description": "Delete an organization; its subscriptions stay with their owners (owner role required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Delete organization",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -135,9 +367,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/subscriptions/{subscription_id}": {
+        "/organizations/{org_id}/members": {
             "get": {
-                "description": "Get subscription by ID",
+                "security": [
+                    {
+                        "UserIDAuth": []
+                    }
+                ],
+                "description": "Get members of an organization with their roles (viewer role required)",
                 "consumes": [
                     "application/json"
                 ],
@@ -145,14 +382,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "organizations"
                 ],
-                "summary": "Get subscription by ID",
+                "summary": "Get organization members",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Subscription ID",
-                        "name": "subscription_id",
+                        "description": "Organization ID",
+                        "name": "org_id",
                         "in": "path",
                         "required": true
                     }
@@ -161,11 +398,17 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Subscription"
+                            "$ref": "#/definitions/handler.getOrganizationMembersResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/handler.errorResponse"
                         }
@@ -177,9 +420,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/organizations/{org_id}/members/{user_id}": {
             "put": {
-                "description": "Update subscription",
+                "security": [
+                    {
+                        "UserIDAuth": []
+                    }
+                ],
+                "description": "Add a user to an organization or change their role (admin role required, owner role to manage owners)",
                 "consumes": [
                     "application/json"
                 ],
@@ -187,24 +437,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "organizations"
                 ],
-                "summary": "Update subscription",
+                "summary": "Set organization member",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Subscription ID",
-                        "name": "subscription_id",
+                        "description": "Organization ID",
+                        "name": "org_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Update input",
+                        "type": "string",
+                        "description": "Member user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member role",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateSubscription"
+                            "$ref": "#/definitions/models.OrganizationMember"
                         }
                     }
                 ],
@@ -221,6 +478,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.errorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -230,7 +505,12 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Delete subscription",
+                "security": [
+                    {
+                        "UserIDAuth": []
+                    }
+                ],
+                "description": "Remove a user from an organization (admin role required, owner role to remove owners)",
                 "consumes": [
                     "application/json"
                 ],
@@ -238,14 +518,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "subscriptions"
+                    "organizations"
                 ],
-                "summary": "Delete subscription",
+                "summary": "Remove organization member",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Subscription ID",
-                        "name": "subscription_id",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Member user ID",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     }
@@ -257,37 +544,1744 @@ const docTemplate = `{
                             "$ref": "#/definitions/handler.statusResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/handler.errorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/handler.errorResponse"
                         }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "handler.errorResponse": {
-            "description": "Error response",
-            "type": "object",
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{org_id}/subscriptions": {
+            "get": {
+                "security": [
+                    {
+                        "UserIDAuth": []
+                    }
+                ],
+                "description": "Get subscriptions paid by an organization (viewer role required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Get organization subscriptions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Lifecycle status filter (trial, active, paused, cancelled, expired)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.getOrganizationSubscriptionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{org_id}/subscriptions/{subscription_id}": {
+            "put": {
+                "security": [
+                    {
+                        "UserIDAuth": []
+                    }
+                ],
+                "description": "Make an organization pay for a subscription (member role required for own subscriptions, admin for any)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Attach subscription to organization",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "subscription_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "UserIDAuth": []
+                    }
+                ],
+                "description": "Return a subscription of an organization to its owner (admin role required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Detach subscription from organization",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "subscription_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{org_id}/total-cost": {
+            "get": {
+                "security": [
+                    {
+                        "UserIDAuth": []
+                    }
+                ],
+                "description": "Calculate the total cost of subscriptions paid by an organization for a period (viewer role required)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "organizations"
+                ],
+                "summary": "Get organization total cost",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Organization ID",
+                        "name": "org_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Period and optional filters",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionFilter"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionFilter"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Checks the database, schema version and background workers; returns 503 when any check fails\nor while the application is starting or shutting down, so load balancers stop routing to it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthReport"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthReport"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "description": "Get all personal subscriptions; subscriptions paid by an organization are listed by GET /organizations/{org_id}/subscriptions",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get all subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Lifecycle status filter (trial, active, paused, cancelled, expired)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.getAllSubResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new subscription with the input payload",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Create a new subscription",
+                "parameters": [
+                    {
+                        "description": "Subscription input",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "subId, plus warning and duplicates when the subscription overlaps existing ones",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/duplicates": {
+            "get": {
+                "description": "Report personal subscriptions of the same user and service with overlapping periods",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get duplicate subscriptions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.getDuplicatesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/total-cost": {
+            "get": {
+                "description": "Get the total cost of personal subscriptions with filters",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get subscription summary",
+                "parameters": [
+                    {
+                        "description": "Filter criteria",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionFilter"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SubscriptionFilter"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/trials/ending": {
+            "get": {
+                "description": "Get personal free trials whose last free month is within the given number of months, starting with the current month",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trials"
+                ],
+                "summary": "Get ending trials",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of months to look ahead (default 1)",
+                        "name": "months",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.getEndingTrialsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{subscription_id}": {
+            "get": {
+                "description": "Get subscription by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Get subscription by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "subscription_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update subscription",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Update subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "subscription_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update input",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateSubscription"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status, plus warning and duplicates when the subscription overlaps existing ones",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete subscription",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Delete subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "subscription_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{subscription_id}/cancel": {
+            "post": {
+                "description": "Cancel a subscription; the current month is still billed and the subscription ends with it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Cancel subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "subscription_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cancellation reason",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.CancelInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{subscription_id}/members": {
+            "get": {
+                "description": "Get users sharing the cost of a subscription",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "members"
+                ],
+                "summary": "Get subscription members",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "subscription_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.getMembersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Share the cost of a subscription with a user by weight or fixed amount; the owner pays the rest",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "members"
+                ],
+                "summary": "Add subscription member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "subscription_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Member input",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Member"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{subscription_id}/members/{user_id}": {
+            "delete": {
+                "description": "Stop sharing a subscription with a user; their share returns to the owner",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "members"
+                ],
+                "summary": "Remove subscription member",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "subscription_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Member user ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{subscription_id}/pause": {
+            "post": {
+                "description": "Stop billing of a subscription starting with the given month (current month by default)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Pause subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "subscription_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pause start month",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.PauseInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{subscription_id}/resume": {
+            "post": {
+                "description": "Continue billing of a paused subscription from the given month (current month by default)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "subscriptions"
+                ],
+                "summary": "Resume subscription",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Subscription ID",
+                        "name": "subscription_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Resume month",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/models.PauseInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "post": {
+                "description": "Create a user; the ID is generated unless provided",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a user",
+                "parameters": [
+                    {
+                        "description": "User input",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "userId",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "description": "Get user profile and preferences",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update display name, timezone or currency preference of a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User update data",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a user with their budgets and memberships; owned subscriptions block the deletion unless cascade is set, subscriptions paid by an organization always do",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also delete personal subscriptions owned by the user",
+                        "name": "cascade",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/budgets": {
+            "get": {
+                "description": "Get all budgets of the user with used, remaining and breached status for the current month",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "budgets"
+                ],
+                "summary": "Get user budgets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.getAllBudgetsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a monthly spending limit for the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "budgets"
+                ],
+                "summary": "Create a budget",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Budget input",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Budget"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "budgetId",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/budgets/{budget_id}": {
+            "get": {
+                "description": "Get used, remaining and breached status of a budget for the current month",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "budgets"
+                ],
+                "summary": "Get budget status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Budget ID",
+                        "name": "budget_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BudgetStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a budget of the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "budgets"
+                ],
+                "summary": "Delete budget",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Budget ID",
+                        "name": "budget_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.statusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/subscriptions": {
+            "get": {
+                "description": "Get personal subscriptions the user owns or shares as a member",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Lifecycle status filter (trial, active, paused, cancelled, expired)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handler.getUserSubscriptionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.errorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handler.errorResponse": {
+            "description": "Error response",
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "ID to find the request in the logs",
+                    "type": "string"
+                }
+            }
+        },
+        "handler.getAllBudgetsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Budgets with their current month usage",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BudgetStatus"
+                    }
+                }
+            }
+        },
+        "handler.getAllSubResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Array of subscription objects",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Subscription"
+                    }
+                }
+            }
+        },
+        "handler.getDuplicatesResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Groups of overlapping subscriptions",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DuplicateGroup"
+                    }
+                }
+            }
+        },
+        "handler.getEndingTrialsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Subscriptions whose trial ends soon",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Subscription"
+                    }
+                }
+            }
+        },
+        "handler.getMembersResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Members sharing the subscription",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Member"
+                    }
+                }
+            }
+        },
+        "handler.getOrganizationMembersResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Members with their roles",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.OrganizationMember"
+                    }
+                }
+            }
+        },
+        "handler.getOrganizationSubscriptionsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Subscriptions paid by the organization",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Subscription"
+                    }
+                }
+            }
+        },
+        "handler.getSettlementsResponse": {
+            "type": "object",
             "properties": {
-                "message": {
-                    "type": "string"
+                "data": {
+                    "description": "Net debts between users per month",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Settlement"
+                    }
                 }
             }
         },
-        "handler.getAllSubResponse": {
+        "handler.getUserSubscriptionsResponse": {
             "type": "object",
             "properties": {
                 "data": {
-                    "description": "Array of subscription objects",
+                    "description": "Subscriptions the user owns or shares",
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/models.Subscription"
@@ -304,19 +2298,297 @@ const docTemplate = `{
                 }
             }
         },
+        "models.Budget": {
+            "description": "Monthly spending limit",
+            "type": "object",
+            "required": [
+                "amount"
+            ],
+            "properties": {
+                "amount": {
+                    "description": "Monthly limit (required)",
+                    "type": "integer"
+                },
+                "category": {
+                    "description": "Optional subscription category the limit applies to",
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "Currency code, defaults to RUB",
+                    "type": "string"
+                }
+            }
+        },
+        "models.BudgetStatus": {
+            "description": "Budget usage for the current month",
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Monthly limit",
+                    "type": "integer"
+                },
+                "breached": {
+                    "description": "True when the projected spend exceeds the limit",
+                    "type": "boolean"
+                },
+                "category": {
+                    "description": "Optional category filter",
+                    "type": "string"
+                },
+                "currency": {
+                    "description": "Currency code",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Budget identifier",
+                    "type": "integer"
+                },
+                "month": {
+                    "description": "Evaluated month in MM-YYYY format",
+                    "type": "string"
+                },
+                "remaining": {
+                    "description": "Amount left before the limit is reached",
+                    "type": "integer"
+                },
+                "used": {
+                    "description": "Projected spend for the month",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "description": "Owner of the budget",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CancelInput": {
+            "description": "Subscription cancellation data",
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "description": "Optional free-form cancellation reason",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Charge": {
+            "description": "Expected charge of a subscription",
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Charged amount",
+                    "type": "integer"
+                },
+                "month": {
+                    "description": "Billed month in MM-YYYY format",
+                    "type": "string"
+                },
+                "service_name": {
+                    "description": "Name of the service",
+                    "type": "string"
+                },
+                "subscription_id": {
+                    "description": "Charged subscription",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "description": "Owner of the subscription",
+                    "type": "string"
+                }
+            }
+        },
+        "models.DuplicateGroup": {
+            "description": "Overlapping subscriptions of one user for the same service",
+            "type": "object",
+            "properties": {
+                "service_name": {
+                    "description": "Service name shared by the subscriptions",
+                    "type": "string"
+                },
+                "subscriptions": {
+                    "description": "Subscriptions with overlapping periods",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Subscription"
+                    }
+                },
+                "user_id": {
+                    "description": "Owner of the subscriptions",
+                    "type": "string"
+                }
+            }
+        },
         "models.Filters": {
             "type": "object",
             "properties": {
+                "category": {
+                    "description": "Optional filter by category",
+                    "type": "string"
+                },
                 "service_name": {
                     "description": "Optional filter by service name",
                     "type": "string"
                 },
+                "status": {
+                    "description": "Optional filter by lifecycle status",
+                    "type": "string"
+                },
                 "user_id": {
                     "description": "Optional filter by user ID",
                     "type": "string"
                 }
             }
         },
+        "models.Forecast": {
+            "description": "Spend forecast for upcoming months",
+            "type": "object",
+            "properties": {
+                "charges": {
+                    "description": "Expected charges ordered by month",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Charge"
+                    }
+                },
+                "currency": {
+                    "description": "Currency code of all amounts",
+                    "type": "string"
+                },
+                "from": {
+                    "description": "First forecast month in MM-YYYY format",
+                    "type": "string"
+                },
+                "months": {
+                    "description": "Per-month totals, including months without charges",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MonthTotal"
+                    }
+                },
+                "to": {
+                    "description": "Last forecast month in MM-YYYY format",
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Sum of all expected charges",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.HealthCheck": {
+            "description": "Result of a readiness check",
+            "type": "object",
+            "properties": {
+                "duration_ms": {
+                    "description": "Time the check took",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Failure reason",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "ok or fail",
+                    "type": "string"
+                }
+            }
+        },
+        "models.HealthReport": {
+            "description": "Readiness status with a breakdown per dependency check",
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "Result of each check by name",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.HealthCheck"
+                    }
+                },
+                "status": {
+                    "description": "ready when every check passed, not ready otherwise",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Member": {
+            "description": "Member of a shared subscription",
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "fixed_amount": {
+                    "description": "Fixed amount the member pays per charge",
+                    "type": "integer"
+                },
+                "share_weight": {
+                    "description": "Weight of the member's part of the cost left after fixed amounts",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "description": "Member user identifier (required)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.MonthTotal": {
+            "description": "Total charges of a month",
+            "type": "object",
+            "properties": {
+                "month": {
+                    "description": "Month in MM-YYYY format",
+                    "type": "string"
+                },
+                "total": {
+                    "description": "Sum of all charges in the month",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Organization": {
+            "description": "Organization information",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "id": {
+                    "description": "Unique identifier (output only)",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Organization name (required)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.OrganizationMember": {
+            "description": "Organization member with role",
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "description": "Role: owner, admin, member or viewer",
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "Member user identifier (output only, taken from the path on writes)",
+                    "type": "string"
+                }
+            }
+        },
+        "models.PauseInput": {
+            "description": "Month the pause or resume takes effect",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "description": "Month in MM-YYYY format, defaults to the current month",
+                    "type": "string"
+                }
+            }
+        },
         "models.Period": {
             "type": "object",
             "required": [
@@ -334,6 +2606,32 @@ const docTemplate = `{
                 }
             }
         },
+        "models.Settlement": {
+            "description": "Net debt between two users for a month",
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Net amount owed",
+                    "type": "integer"
+                },
+                "currency": {
+                    "description": "Currency code of the amount",
+                    "type": "string"
+                },
+                "from_user_id": {
+                    "description": "User who owes the amount",
+                    "type": "string"
+                },
+                "month": {
+                    "description": "Month in MM-YYYY format",
+                    "type": "string"
+                },
+                "to_user_id": {
+                    "description": "User who paid and is owed the amount",
+                    "type": "string"
+                }
+            }
+        },
         "models.Subscription": {
             "description": "Subscription information",
             "type": "object",
@@ -344,18 +2642,50 @@ const docTemplate = `{
                 "user_id"
             ],
             "properties": {
+                "billing_cycle": {
+                    "description": "Billing cadence: monthly (default), quarterly or yearly",
+                    "type": "string"
+                },
+                "cancellation_reason": {
+                    "description": "Reason given on cancellation (output only)",
+                    "type": "string"
+                },
+                "cancelled_at": {
+                    "description": "Cancellation date in DD-MM-YYYY format (output only)",
+                    "type": "string"
+                },
+                "category": {
+                    "description": "Optional spending category used by budgets",
+                    "type": "string"
+                },
                 "finish_date": {
-                    "description": "End date as timestamp",
+                    "description": "Optional end date in MM-YYYY format (exclusive, empty for open-ended)",
                     "type": "string"
                 },
                 "id": {
                     "description": "Unique identifier",
                     "type": "integer"
                 },
+                "organization_id": {
+                    "description": "Organization paying for the subscription (output only)",
+                    "type": "integer"
+                },
+                "paused": {
+                    "description": "Whether billing is paused in the current month (output only)",
+                    "type": "boolean"
+                },
+                "paused_from": {
+                    "description": "Start month of the current or scheduled pause (output only)",
+                    "type": "string"
+                },
                 "price": {
                     "description": "Subscription price (required)",
                     "type": "integer"
                 },
+                "price_after_trial": {
+                    "description": "Optional price charged once the trial ends (defaults to price)",
+                    "type": "integer"
+                },
                 "service_name": {
                     "description": "Name of the service (required)",
                     "type": "string"
@@ -364,6 +2694,14 @@ const docTemplate = `{
                     "description": "Start date in string format (required)",
                     "type": "string"
                 },
+                "status": {
+                    "description": "Lifecycle status: trial, active, paused, cancelled or expired (output only)",
+                    "type": "string"
+                },
+                "trial_end": {
+                    "description": "Optional last free month in MM-YYYY format",
+                    "type": "string"
+                },
                 "user_id": {
                     "description": "User identifier as string (required)",
                     "type": "string"
@@ -404,13 +2742,73 @@ const docTemplate = `{
             "description": "Subscription update data",
             "type": "object",
             "properties": {
+                "billing_cycle": {
+                    "description": "Optional new billing cadence",
+                    "type": "string"
+                },
+                "category": {
+                    "description": "Optional new category, empty string clears it",
+                    "type": "string"
+                },
+                "finish_date": {
+                    "description": "Optional new end date in \"MM-YYYY\" format, empty string makes it open-ended",
+                    "type": "string"
+                },
                 "price": {
                     "description": "Optional new price value (pointer allows nil for no update)",
                     "type": "integer"
                 },
+                "price_after_trial": {
+                    "description": "Optional new price charged after the trial",
+                    "type": "integer"
+                },
                 "start_date": {
                     "description": "Optional new start date in \"MM-YYYY\" format",
                     "type": "string"
+                },
+                "trial_end": {
+                    "description": "Optional new last free month in \"MM-YYYY\" format, empty string removes the trial",
+                    "type": "string"
+                }
+            }
+        },
+        "models.UpdateUser": {
+            "description": "User update data",
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "description": "Optional new preferred currency code",
+                    "type": "string"
+                },
+                "display_name": {
+                    "description": "Optional new display name",
+                    "type": "string"
+                },
+                "timezone": {
+                    "description": "Optional new IANA timezone",
+                    "type": "string"
+                }
+            }
+        },
+        "models.User": {
+            "description": "User information",
+            "type": "object",
+            "properties": {
+                "currency": {
+                    "description": "Preferred currency code, defaults to RUB",
+                    "type": "string"
+                },
+                "display_name": {
+                    "description": "Name shown to other members of shared subscriptions",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "User identifier, generated when omitted on creation",
+                    "type": "string"
+                },
+                "timezone": {
+                    "description": "IANA timezone, defaults to UTC",
+                    "type": "string"
                 }
             }
         }
@@ -420,6 +2818,11 @@ const docTemplate = `{
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
+        },
+        "UserIDAuth": {
+            "type": "apiKey",
+            "name": "X-User-ID",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,95 @@
+// Package cache keeps frequently read subscription data in process memory
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+// entry is a cached value together with its key and expiry time
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// LRU is a size-bounded cache whose entries also expire after a fixed TTL
+// When full, adding evicts the least recently used entry. LRU is not safe
+// for concurrent use; callers guard it with their own lock
+type LRU[K comparable, V any] struct {
+	size  int
+	ttl   time.Duration
+	order *list.List // Most recently used first
+	items map[K]*list.Element
+	now   func() time.Time
+}
+
+// NewLRU creates an empty cache holding at most size entries for ttl each
+func NewLRU[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		size:  size,
+		ttl:   ttl,
+		order: list.New(),
+		items: make(map[K]*list.Element, size),
+		now:   time.Now,
+	}
+}
+
+// Get returns the value cached for key, if present and not expired
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	element, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	e := element.Value.(*entry[K, V])
+	if !c.now().Before(e.expires) {
+		c.removeElement(element)
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+// Add caches value for key, replacing a previous value and restarting its TTL
+func (c *LRU[K, V]) Add(key K, value V) {
+	expires := c.now().Add(c.ttl)
+	if element, ok := c.items[key]; ok {
+		e := element.Value.(*entry[K, V])
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	for c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Remove drops the entry of key if it is cached
+func (c *LRU[K, V]) Remove(key K) {
+	if element, ok := c.items[key]; ok {
+		c.removeElement(element)
+	}
+}
+
+// Purge drops all entries
+func (c *LRU[K, V]) Purge() {
+	c.order.Init()
+	clear(c.items)
+}
+
+// Len returns the number of cached entries, including expired ones not dropped yet
+func (c *LRU[K, V]) Len() int {
+	return c.order.Len()
+}
+
+// removeElement unlinks an entry from both the list and the index
+func (c *LRU[K, V]) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+)
+
+// Names of the caches reported to the Recorder
+const (
+	CacheSubscription = "subscription" // Subscriptions by ID
+	CacheSummary      = "summary"      // Subscription summaries by filter
+)
+
+// Sources of invalidations reported to the Recorder
+const (
+	InvalidationWrite  = "write"  // Create, Update or Delete on this replica
+	InvalidationNotify = "notify" // Change announced by the database
+	InvalidationReset  = "reset"  // Everything dropped after changes may have been missed
+)
+
+// Config holds cache settings
+type Config struct {
+	Enabled bool          // Cache subscriptions and summaries
+	Size    int           // Maximum number of entries of each cache
+	TTL     time.Duration // How long an entry is served before it is read again
+	Notify  bool          // Drop entries changed on other replicas via PostgreSQL LISTEN/NOTIFY
+}
+
+// Recorder receives cache lookups and invalidations, e.g. to export them as metrics
+type Recorder interface {
+	CacheLookup(cache string, hit bool)
+	CacheInvalidation(source string)
+}
+
+// SubscriptionCache decorates a service.SubscriptionStore with a cache of GetById and
+// GetSubscriptionSummary results; other methods are passed through
+// A summary depends on every subscription it sums, including shared ones, so any
+// change drops all cached summaries and the cached entry of the changed subscription
+type SubscriptionCache struct {
+	service.SubscriptionStore
+	recorder Recorder
+
+	mu            sync.Mutex
+	generation    uint64 // Incremented by every invalidation
+	subscriptions *LRU[int, models.Subscription]
+	summaries     *LRU[string, int]
+}
+
+// NewSubscriptionCache wraps next with caches of cfg.Size entries living for cfg.TTL
+// recorder may be nil
+func NewSubscriptionCache(next service.SubscriptionStore, cfg Config, recorder Recorder) *SubscriptionCache {
+	return &SubscriptionCache{
+		SubscriptionStore: next,
+		recorder:          recorder,
+		subscriptions:     NewLRU[int, models.Subscription](cfg.Size, cfg.TTL),
+		summaries:         NewLRU[string, int](cfg.Size, cfg.TTL),
+	}
+}
+
+// GetById returns the cached subscription or reads and caches it
+func (c *SubscriptionCache) GetById(ctx context.Context, subID int) (models.Subscription, error) {
+	c.mu.Lock()
+	sub, ok := c.subscriptions.Get(subID)
+	generation := c.generation
+	c.mu.Unlock()
+	c.lookup(CacheSubscription, ok)
+	if ok {
+		return sub, nil
+	}
+
+	sub, err := c.SubscriptionStore.GetById(ctx, subID)
+	if err != nil {
+		return sub, err
+	}
+
+	c.store(generation, func() { c.subscriptions.Add(subID, sub) })
+	return sub, nil
+}
+
+// GetSubscriptionSummary returns the cached total or calculates and caches it
+func (c *SubscriptionCache) GetSubscriptionSummary(ctx context.Context, filter models.SubscriptionFilter) (int, error) {
+	key, err := summaryKey(filter)
+	if err != nil {
+		return c.SubscriptionStore.GetSubscriptionSummary(ctx, filter)
+	}
+
+	c.mu.Lock()
+	total, ok := c.summaries.Get(key)
+	generation := c.generation
+	c.mu.Unlock()
+	c.lookup(CacheSummary, ok)
+	if ok {
+		return total, nil
+	}
+
+	total, err = c.SubscriptionStore.GetSubscriptionSummary(ctx, filter)
+	if err != nil {
+		return total, err
+	}
+
+	c.store(generation, func() { c.summaries.Add(key, total) })
+	return total, nil
+}
+
+// Create adds the subscription and drops cached summaries
+func (c *SubscriptionCache) Create(ctx context.Context, sub models.Subscription) (int, error) {
+	subID, err := c.SubscriptionStore.Create(ctx, sub)
+	c.invalidate(subID, InvalidationWrite)
+	return subID, err
+}
+
+// Update changes the subscription and drops its cached entry and all summaries
+func (c *SubscriptionCache) Update(ctx context.Context, subID int, input models.UpdateSubscription) error {
+	err := c.SubscriptionStore.Update(ctx, subID, input)
+	c.invalidate(subID, InvalidationWrite)
+	return err
+}
+
+// Delete removes the subscription and drops its cached entry and all summaries
+func (c *SubscriptionCache) Delete(ctx context.Context, subID int) error {
+	err := c.SubscriptionStore.Delete(ctx, subID)
+	c.invalidate(subID, InvalidationWrite)
+	return err
+}
+
+// Invalidate drops the cached subscription and all summaries after a change made elsewhere
+func (c *SubscriptionCache) Invalidate(subID int) {
+	c.invalidate(subID, InvalidationNotify)
+}
+
+// Reset drops every cached entry
+func (c *SubscriptionCache) Reset() {
+	c.mu.Lock()
+	c.generation++
+	c.subscriptions.Purge()
+	c.summaries.Purge()
+	c.mu.Unlock()
+
+	if c.recorder != nil {
+		c.recorder.CacheInvalidation(InvalidationReset)
+	}
+}
+
+// invalidate drops the entry of subID and all summaries
+// Failed writes invalidate too, as a write may fail after it was committed
+func (c *SubscriptionCache) invalidate(subID int, source string) {
+	c.mu.Lock()
+	c.generation++
+	c.subscriptions.Remove(subID)
+	c.summaries.Purge()
+	c.mu.Unlock()
+
+	if c.recorder != nil {
+		c.recorder.CacheInvalidation(source)
+	}
+}
+
+// store caches a value read from the store unless an invalidation happened meanwhile,
+// in which case the value may predate the change and is dropped
+func (c *SubscriptionCache) store(generation uint64, add func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		add()
+	}
+}
+
+// lookup reports a cache hit or miss
+func (c *SubscriptionCache) lookup(cache string, hit bool) {
+	if c.recorder != nil {
+		c.recorder.CacheLookup(cache, hit)
+	}
+}
+
+// summaryKey identifies a summary by its period and filters; output fields are left out
+// Organization summaries are read from the repository directly and never reach the cache
+func summaryKey(filter models.SubscriptionFilter) (string, error) {
+	key, err := json.Marshal(struct {
+		Period  models.Period
+		Filters models.Filters
+	}{filter.Period, filter.Filters})
+	return string(key), err
+}
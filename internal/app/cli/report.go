@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+)
+
+// Report prints the monthly spend of a user between from and to (MM-YYYY) as a table:
+// a row per month with the charge of every service and the month total, then the grand total
+func Report(ctx context.Context, w io.Writer, analytics service.AnalyticsStore, userID, from, to string) error {
+	spend, err := analytics.MonthlySpend(ctx, userID, from, to)
+	if err != nil {
+		return err
+	}
+
+	// Columns are the charged services in alphabetical order
+	perMonth := make(map[string]map[string]int, len(spend.Months))
+	perService := make(map[string]int)
+	for _, charge := range spend.Charges {
+		if perMonth[charge.Month] == nil {
+			perMonth[charge.Month] = make(map[string]int)
+		}
+		perMonth[charge.Month][charge.ServiceName] += charge.Amount
+		perService[charge.ServiceName] += charge.Amount
+	}
+	services := make([]string, 0, len(perService))
+	for name := range perService {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+
+	fmt.Fprintf(w, "Spend of user %s from %s to %s, %s\n\n", userID, spend.From, spend.To, spend.Currency)
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(table, "MONTH\t")
+	for _, name := range services {
+		fmt.Fprint(table, name+"\t")
+	}
+	fmt.Fprintln(table, "TOTAL\t")
+
+	for _, month := range spend.Months {
+		fmt.Fprint(table, month.Month+"\t")
+		for _, name := range services {
+			fmt.Fprint(table, amount(perMonth[month.Month][name])+"\t")
+		}
+		fmt.Fprintln(table, strconv.Itoa(month.Total)+"\t")
+	}
+
+	fmt.Fprint(table, "TOTAL\t")
+	for _, name := range services {
+		fmt.Fprint(table, strconv.Itoa(perService[name])+"\t")
+	}
+	fmt.Fprintln(table, strconv.Itoa(spend.Total)+"\t")
+
+	return table.Flush()
+}
+
+// amount formats a charge, months without a charge show a dash
+func amount(value int) string {
+	if value == 0 {
+		return "-"
+	}
+	return strconv.Itoa(value)
+}
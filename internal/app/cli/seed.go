@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+)
+
+// catalogEntry is a service offered to generated users with its usual price and cadence
+type catalogEntry struct {
+	name     string
+	price    int
+	category string
+	cycle    string
+	trial    bool // Whether the service usually starts with a free month
+}
+
+// catalog lists popular services used for generated subscriptions
+var catalog = []catalogEntry{
+	{"Netflix", 799, "entertainment", models.BillingMonthly, true},
+	{"Kinopoisk", 299, "entertainment", models.BillingMonthly, true},
+	{"YouTube Premium", 399, "entertainment", models.BillingMonthly, true},
+	{"Yandex Plus", 399, "entertainment", models.BillingMonthly, true},
+	{"Spotify", 299, "music", models.BillingMonthly, true},
+	{"Apple Music", 169, "music", models.BillingMonthly, true},
+	{"iCloud+", 149, "cloud", models.BillingMonthly, false},
+	{"Google One", 1390, "cloud", models.BillingYearly, false},
+	{"Dropbox Plus", 3600, "cloud", models.BillingQuarterly, false},
+	{"Microsoft 365", 6990, "productivity", models.BillingYearly, true},
+	{"Notion Plus", 1000, "productivity", models.BillingMonthly, false},
+	{"ChatGPT Plus", 2000, "productivity", models.BillingMonthly, false},
+	{"GitHub Copilot", 1000, "development", models.BillingMonthly, true},
+	{"JetBrains All Products", 7800, "development", models.BillingQuarterly, false},
+	{"Duolingo Super", 2990, "education", models.BillingYearly, true},
+	{"Coursera Plus", 4990, "education", models.BillingQuarterly, true},
+}
+
+var (
+	firstNames = []string{"Anna", "Ivan", "Maria", "Dmitry", "Elena", "Sergey", "Olga", "Alexey", "Natalia", "Pavel", "Irina", "Nikolay"}
+	lastNames  = []string{"Ivanov", "Smirnov", "Kuznetsov", "Popov", "Sokolov", "Lebedev", "Kozlov", "Novikov", "Morozov", "Volkov"}
+	timezones  = []string{"Europe/Moscow", "Europe/Moscow", "Europe/Kaliningrad", "Asia/Yekaterinburg", "Asia/Novosibirsk", "Asia/Vladivostok", "UTC"}
+)
+
+// maxSeedSubscriptions is the largest number of subscriptions of a generated user
+const maxSeedSubscriptions = 5
+
+// SeedResult counts the generated records
+type SeedResult struct {
+	Users         int
+	Subscriptions int
+}
+
+// Seed creates users with realistic subscriptions through the service layer: each user gets
+// up to five different services that started within the last three years, some of them
+// already finished or started with a free month. The same rng seed produces the same data
+func Seed(ctx context.Context, services *service.Service, rng *rand.Rand, users int, now time.Time) (SeedResult, error) {
+	var result SeedResult
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < users; i++ {
+		userID, err := services.UserStore.Create(ctx, models.User{
+			DisplayName: firstNames[rng.IntN(len(firstNames))] + " " + lastNames[rng.IntN(len(lastNames))],
+			Timezone:    timezones[rng.IntN(len(timezones))],
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to create user: %w", err)
+		}
+		result.Users++
+
+		// Different services per user keep the duplicate check from rejecting subscriptions
+		for _, index := range rng.Perm(len(catalog))[:rng.IntN(maxSeedSubscriptions)+1] {
+			sub := seedSubscription(rng, catalog[index], userID, thisMonth)
+			if _, err := services.SubscriptionStore.Create(ctx, sub); err != nil {
+				return result, fmt.Errorf("failed to create %s subscription: %w", sub.ServiceName, err)
+			}
+			result.Subscriptions++
+		}
+	}
+
+	return result, nil
+}
+
+// seedSubscription generates a subscription to the service starting up to three years ago
+func seedSubscription(rng *rand.Rand, entry catalogEntry, userID string, thisMonth time.Time) models.Subscription {
+	start := thisMonth.AddDate(0, -rng.IntN(36), 0)
+	sub := models.Subscription{
+		ServiceName:  entry.name,
+		Price:        entry.price,
+		UserID:       userID,
+		StartDate:    start.Format("01-2006"),
+		Category:     entry.category,
+		BillingCycle: entry.cycle,
+	}
+
+	// A quarter of subscriptions have a finish date, some of them still in the future
+	if rng.IntN(4) == 0 {
+		finish := start.AddDate(0, models.BillingCycleMonths(entry.cycle)*(rng.IntN(4)+1), 0)
+		sub.FinishDate = finish.Format("01-2006")
+	}
+
+	// Some services start with a free month; the finish date is at least a cycle later
+	if entry.trial && rng.IntN(3) == 0 {
+		sub.TrialEnd = sub.StartDate
+	}
+
+	return sub
+}
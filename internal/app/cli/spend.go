@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+)
+
+// CheckSpend compares the monthly spend aggregate with raw data between from and to (MM-YYYY)
+// and prints a row for every user, service and month that differs
+// Returns the number of differences; months beyond the aggregate horizon are not checked
+func CheckSpend(ctx context.Context, w io.Writer, spend postgres.SpendStore, from, to string) (int, error) {
+	fromMonth, err := time.Parse("01-2006", from)
+	if err != nil {
+		return 0, fmt.Errorf("invalid from format, expected MM-YYYY: %w", err)
+	}
+	toMonth, err := time.Parse("01-2006", to)
+	if err != nil {
+		return 0, fmt.Errorf("invalid to format, expected MM-YYYY: %w", err)
+	}
+	if toMonth.Before(fromMonth) {
+		return 0, fmt.Errorf("from %s is after to %s", from, to)
+	}
+
+	mismatches, err := spend.Check(ctx, fromMonth, toMonth)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(mismatches) == 0 {
+		fmt.Fprintf(w, "Monthly spend from %s to %s matches subscriptions\n", from, to)
+		return 0, nil
+	}
+
+	fmt.Fprintf(w, "Monthly spend from %s to %s differs from subscriptions in %d rows\n\n", from, to, len(mismatches))
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "MONTH\tUSER\tSERVICE\tAGGREGATED\tACTUAL\t")
+	for _, m := range mismatches {
+		fmt.Fprintln(table, m.Month.Format("01-2006")+"\t"+m.UserID+"\t"+m.ServiceName+"\t"+
+			strconv.Itoa(m.Aggregated)+"\t"+strconv.Itoa(m.Actual)+"\t")
+	}
+
+	return len(mismatches), table.Flush()
+}
@@ -0,0 +1,282 @@
+// Package cli implements the data commands of the binary on top of the service layer:
+// generating fake data, importing and exporting subscriptions, printing spend reports and
+// checking the monthly spend aggregate
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+)
+
+// Format is the file format of imported and exported subscriptions
+type Format string
+
+const (
+	FormatCSV    Format = "csv"    // Comma-separated values with a header row
+	FormatNDJSON Format = "ndjson" // One JSON subscription per line
+)
+
+// StdioPath stands for standard input or output instead of a file; it uses NDJSON
+const StdioPath = "-"
+
+// FormatFromPath chooses the format by the file extension
+func FormatFromPath(path string) (Format, error) {
+	if path == StdioPath {
+		return FormatNDJSON, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON, nil
+	}
+	return "", fmt.Errorf("unsupported file %q, expected .csv, .ndjson or .jsonl", path)
+}
+
+// csvColumns are written by Export; Import reads columns by name and ignores output-only ones
+var csvColumns = []string{
+	"id", "user_id", "service_name", "price", "start_date", "finish_date",
+	"category", "billing_cycle", "trial_end", "price_after_trial", "status",
+}
+
+// requiredColumns must be present in an imported CSV file
+var requiredColumns = []string{"user_id", "service_name", "price", "start_date"}
+
+// Export writes the subscriptions, optionally limited to a user, and returns how many were written
+func Export(ctx context.Context, subs service.SubscriptionStore, w io.Writer, format Format, userID *string) (int, error) {
+	list, err := subs.GetAll(ctx, models.SubscriptionListFilter{UserID: userID})
+	if err != nil {
+		return 0, err
+	}
+
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvColumns); err != nil {
+			return 0, err
+		}
+		for _, sub := range list {
+			if err := writer.Write(csvRecord(sub)); err != nil {
+				return 0, err
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return 0, err
+		}
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		for _, sub := range list {
+			if err := encoder.Encode(sub); err != nil {
+				return 0, err
+			}
+		}
+	default:
+		return 0, fmt.Errorf("unsupported format %q", format)
+	}
+
+	return len(list), nil
+}
+
+// csvRecord converts a subscription to a row in the order of csvColumns
+func csvRecord(sub *models.Subscription) []string {
+	priceAfterTrial := ""
+	if sub.PriceAfterTrial != nil {
+		priceAfterTrial = strconv.Itoa(*sub.PriceAfterTrial)
+	}
+
+	return []string{
+		strconv.Itoa(sub.Id), sub.UserID, sub.ServiceName, strconv.Itoa(sub.Price), sub.StartDate, sub.FinishDate,
+		sub.Category, sub.BillingCycle, sub.TrialEnd, priceAfterTrial, sub.Status,
+	}
+}
+
+// ImportResult summarizes an import; rows that failed do not stop the import
+type ImportResult struct {
+	Imported     int     // Subscriptions created
+	CreatedUsers int     // Users created because imported subscriptions referenced them
+	Errors       []error // Failed rows, each prefixed with its line number
+}
+
+// Import creates a subscription for every row through the service layer, so rows are validated
+// and duplicate checks apply as for API requests. Users referenced by the rows are created
+// when they do not exist. Only unreadable input stops the import
+func Import(ctx context.Context, services *service.Service, r io.Reader, format Format) (ImportResult, error) {
+	var next func() (models.Subscription, int, error)
+	switch format {
+	case FormatCSV:
+		reader, err := newCSVReader(r)
+		if err != nil {
+			return ImportResult{}, err
+		}
+		next = reader
+	case FormatNDJSON:
+		next = newNDJSONReader(r)
+	default:
+		return ImportResult{}, fmt.Errorf("unsupported format %q", format)
+	}
+
+	var result ImportResult
+	knownUsers := make(map[string]bool)
+
+	for {
+		sub, line, err := next()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		var rowErr *rowError
+		if errors.As(err, &rowErr) {
+			result.Errors = append(result.Errors, fmt.Errorf("line %d: %w", line, rowErr.err))
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+
+		if !knownUsers[sub.UserID] {
+			created, err := ensureUser(ctx, services.UserStore, sub.UserID)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("line %d: %w", line, err))
+				continue
+			}
+			knownUsers[sub.UserID] = true
+			if created {
+				result.CreatedUsers++
+			}
+		}
+
+		if _, err := services.SubscriptionStore.Create(ctx, sub); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		result.Imported++
+	}
+}
+
+// ensureUser creates the user when it does not exist and reports whether it was created
+func ensureUser(ctx context.Context, users service.UserStore, userID string) (bool, error) {
+	_, err := users.GetById(ctx, userID)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, service.ErrUserNotFound) {
+		return false, err
+	}
+
+	if _, err := users.Create(ctx, models.User{Id: userID}); err != nil {
+		return false, fmt.Errorf("failed to create user: %w", err)
+	}
+	return true, nil
+}
+
+// rowError is a problem with a single row; the remaining rows can still be imported
+type rowError struct {
+	err error
+}
+
+func (e *rowError) Error() string { return e.err.Error() }
+
+// newCSVReader reads the header and returns a function producing one subscription per row
+func newCSVReader(r io.Reader) (func() (models.Subscription, int, error), error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header has no %s column", name)
+		}
+	}
+
+	return func() (models.Subscription, int, error) {
+		record, err := reader.Read()
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return models.Subscription{}, parseErr.Line, &rowError{err: parseErr.Err}
+		}
+		if err != nil {
+			return models.Subscription{}, 0, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		sub := models.Subscription{
+			UserID:       field("user_id"),
+			ServiceName:  field("service_name"),
+			StartDate:    field("start_date"),
+			FinishDate:   field("finish_date"),
+			Category:     field("category"),
+			BillingCycle: field("billing_cycle"),
+			TrialEnd:     field("trial_end"),
+		}
+
+		price, err := strconv.Atoi(field("price"))
+		if err != nil {
+			return models.Subscription{}, line, &rowError{err: fmt.Errorf("invalid price %q", field("price"))}
+		}
+		sub.Price = price
+
+		if value := field("price_after_trial"); value != "" {
+			priceAfterTrial, err := strconv.Atoi(value)
+			if err != nil {
+				return models.Subscription{}, line, &rowError{err: fmt.Errorf("invalid price_after_trial %q", value)}
+			}
+			sub.PriceAfterTrial = &priceAfterTrial
+		}
+
+		return sub, line, nil
+	}, nil
+}
+
+// newNDJSONReader returns a function producing one subscription per non-empty line
+func newNDJSONReader(r io.Reader) func() (models.Subscription, int, error) {
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	return func() (models.Subscription, int, error) {
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+
+			var sub models.Subscription
+			if err := json.Unmarshal([]byte(text), &sub); err != nil {
+				return models.Subscription{}, line, &rowError{err: fmt.Errorf("invalid JSON: %w", err)}
+			}
+			return sub, line, nil
+		}
+
+		if err := scanner.Err(); err != nil {
+			return models.Subscription{}, line, err
+		}
+		return models.Subscription{}, line, io.EOF
+	}
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultForecastMonths is used when the months query parameter is omitted
+const defaultForecastMonths = 12
+
+// @Summary Get spend forecast
+// @Description Project month-by-month charges of active personal subscriptions starting with the current month
+// @Tags analytics
+// @Accept  json
+// @Produce  json
+// @Param months query int false "Number of months to forecast (default 12)"
+// @Param user_id query string false "Limit the forecast to a single user"
+// @Success 200 {object} models.Forecast
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /analytics/forecast [get]
+func (h *Handler) getForecast(c *gin.Context) {
+	months := defaultForecastMonths
+	if raw := c.Query("months"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid months param")
+			return
+		}
+		months = value
+	}
+
+	var userID *string
+	if raw := c.Query("user_id"); raw != "" {
+		userID = &raw
+	}
+
+	forecast, err := h.services.AnalyticsStore.Forecast(c.Request.Context(), userID, months)
+	if err != nil {
+		// Return 400 Bad Request for an out-of-range number of months or a malformed user ID
+		if errors.Is(err, service.ErrInvalidForecast) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, forecast)
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/gin-gonic/gin"
+)
+
+// getAllBudgetsResponse defines the response structure for listing user budgets
+type getAllBudgetsResponse struct {
+	Data []models.BudgetStatus `json:"data"` // Budgets with their current month usage
+}
+
+// @Summary Create a budget
+// @Description Create a monthly spending limit for the user
+// @Tags budgets
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param input body models.Budget true "Budget input"
+// @Success 200 {object} map[string]interface{} "budgetId"
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /users/{id}/budgets [post]
+func (h *Handler) createBudget(c *gin.Context) {
+	var input models.Budget
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	budgetID, err := h.services.BudgetStore.Create(c.Request.Context(), c.Param("id"), input)
+	if err != nil {
+		budgetErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"budgetId": budgetID,
+	})
+}
+
+// @Summary Get user budgets
+// @Description Get all budgets of the user with used, remaining and breached status for the current month
+// @Tags budgets
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Success 200 {object} getAllBudgetsResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /users/{id}/budgets [get]
+func (h *Handler) getAllBudgets(c *gin.Context) {
+	budgets, err := h.services.BudgetStore.GetAll(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		budgetErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, getAllBudgetsResponse{
+		Data: budgets,
+	})
+}
+
+// @Summary Get budget status
+// @Description Get used, remaining and breached status of a budget for the current month
+// @Tags budgets
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param budget_id path int true "Budget ID"
+// @Success 200 {object} models.BudgetStatus
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /users/{id}/budgets/{budget_id} [get]
+func (h *Handler) getBudgetById(c *gin.Context) {
+	budgetID, err := strconv.Atoi(c.Param("budget_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid budget_id param")
+		return
+	}
+
+	budget, err := h.services.BudgetStore.GetById(c.Request.Context(), c.Param("id"), budgetID)
+	if err != nil {
+		budgetErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// @Summary Delete budget
+// @Description Delete a budget of the user
+// @Tags budgets
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param budget_id path int true "Budget ID"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /users/{id}/budgets/{budget_id} [delete]
+func (h *Handler) deleteBudget(c *gin.Context) {
+	budgetID, err := strconv.Atoi(c.Param("budget_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid budget_id param")
+		return
+	}
+
+	if err := h.services.BudgetStore.Delete(c.Request.Context(), c.Param("id"), budgetID); err != nil {
+		budgetErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
+
+// budgetErrorResponse maps budget service errors to HTTP statuses
+func budgetErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidBudget):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrBudgetNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	default:
+		userErrorResponse(c, err)
+	}
+}
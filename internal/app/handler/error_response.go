@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/logging"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -9,7 +10,8 @@ import (
 // Used to maintain consistent error formatting across all API endpoints
 // @Description Error response
 type errorResponse struct {
-	Message string `json:"message"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"` // ID to find the request in the logs
 }
 
 // statusResponse represents a standardized success response structure
@@ -22,6 +24,7 @@ type statusResponse struct {
 // newErrorResponse logs an error and returns a standardized error response
 // This ensures consistent error handling and logging across all handlers
 func newErrorResponse(c *gin.Context, statusCode int, message string) {
-	logrus.Error(message)
-	c.AbortWithStatusJSON(statusCode, errorResponse{Message: message})
+	ctx := c.Request.Context()
+	logrus.WithContext(ctx).Error(message)
+	c.AbortWithStatusJSON(statusCode, errorResponse{Message: message, RequestID: logging.RequestID(ctx)})
 }
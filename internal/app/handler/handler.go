@@ -2,23 +2,36 @@ package handler
 
 import (
 	_ "github.com/evgeney-fullstack/subscription-aggregator-app/docs"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/metrics"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/ratelimit"
 	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/tracing"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 // Handler handles HTTP requests and manages routing.
 // Contains dependencies required for request handlers (future fields).
 type Handler struct {
-	services *service.Service
+	services  *service.Service
+	readiness Readiness
+	metrics   *metrics.Metrics
+	limiter   *ratelimit.Limiter
 }
 
 // NewHandler creates and returns a new Handler instance.
 // Constructor function for initializing a handler with possible dependencies.
-func NewHandler(services *service.Service) *Handler {
+// A nil readiness reports the application as always ready, nil metrics disable /metrics,
+// a nil limiter disables rate limiting.
+func NewHandler(services *service.Service, readiness Readiness, metrics *metrics.Metrics, limiter *ratelimit.Limiter) *Handler {
 	return &Handler{
-		services: services,
+		services:  services,
+		readiness: readiness,
+		metrics:   metrics,
+		limiter:   limiter,
 	}
 
 }
@@ -29,17 +42,94 @@ func (h *Handler) InitRoutes() *gin.Engine {
 
 	router := gin.New()
 
+	// The request ID comes first so that every later log line can carry it
+	router.Use(requestID)
+
+	// Every route gets a server span, continuing the trace of an incoming traceparent header
+	router.Use(otelgin.Middleware(tracing.DefaultServiceName))
+
+	// Request durations are recorded for every route, including probes and unmatched paths
+	if h.metrics != nil {
+		router.Use(h.metrics.Middleware())
+		router.GET("/metrics", gin.WrapH(h.metrics.Handler())) //Prometheus scrape endpoint
+	}
+
+	// Panics are recovered inside the access log so the resulting 500 is logged too
+	router.Use(accessLog, recovery)
+
+	// Rejected requests still pass through the access log and metrics above
+	if h.limiter != nil {
+		router.Use(h.rateLimit)
+	}
+
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	router.GET("/healthz", h.healthz) //Liveness probe: the process is up
+	router.GET("/readyz", h.readyz)   //Readiness probe: dependencies are usable
+
+	// Subscriptions paid by an organization are visible to its viewers and changed by its members
+	orgRead := h.requireSubscriptionOrgRole(models.RoleViewer)
+	orgWrite := h.requireSubscriptionOrgRole(models.RoleMember)
+
 	// Create a route group for subscription-related endpoints
 	subscriptions := router.Group("/subscriptions")
 	{
-		subscriptions.POST("/", h.createSubscription)                   //Create a new subscription
-		subscriptions.GET("/", h.getAllSubscriptions)                   //Retrieve all subscriptions
-		subscriptions.GET("/:subscription_id", h.getSubscriptionById)   //Get a specific subscription by ID
-		subscriptions.PUT("/:subscription_id", h.updateSubscription)    //Update an existing subscription
-		subscriptions.DELETE("/:subscription_id", h.deleteSubscription) //Delete a subscription
+		subscriptions.POST("/", h.createSubscription)                             //Create a new subscription
+		subscriptions.GET("/", h.getAllSubscriptions)                             //Retrieve all subscriptions
+		subscriptions.GET("/:subscription_id", orgRead, h.getSubscriptionById)    //Get a specific subscription by ID
+		subscriptions.PUT("/:subscription_id", orgWrite, h.updateSubscription)    //Update an existing subscription
+		subscriptions.DELETE("/:subscription_id", orgWrite, h.deleteSubscription) //Delete a subscription
 		subscriptions.GET("/total-cost", h.getSubscriptionSummary)
+		subscriptions.GET("/duplicates", h.getDuplicateSubscriptions)                        //Report overlapping subscriptions
+		subscriptions.GET("/trials/ending", h.getEndingTrials)                               //List trials that convert to paid soon
+		subscriptions.POST("/:subscription_id/pause", orgWrite, h.pauseSubscription)         //Stop billing from a month
+		subscriptions.POST("/:subscription_id/resume", orgWrite, h.resumeSubscription)       //Continue billing from a month
+		subscriptions.POST("/:subscription_id/cancel", orgWrite, h.cancelSubscription)       //Stop billing after the current month
+		subscriptions.POST("/:subscription_id/members", orgWrite, h.addMember)               //Share the cost with a user
+		subscriptions.GET("/:subscription_id/members", orgRead, h.getMembers)                //List users sharing the cost
+		subscriptions.DELETE("/:subscription_id/members/:user_id", orgWrite, h.removeMember) //Stop sharing with a user
+	}
+
+	// Create a route group for user-related endpoints
+	users := router.Group("/users")
+	{
+		users.POST("/", h.createUser)                           //Create a user
+		users.GET("/:id", h.getUserById)                        //Get a user profile
+		users.PUT("/:id", h.updateUser)                         //Update display name and preferences
+		users.DELETE("/:id", h.deleteUser)                      //Delete a user
+		users.GET("/:id/subscriptions", h.getUserSubscriptions) //List subscriptions owned or shared by the user
+
+		budgets := users.Group("/:id/budgets")
+		{
+			budgets.POST("/", h.createBudget)             //Create a monthly spending limit
+			budgets.GET("/", h.getAllBudgets)             //List budgets with their current status
+			budgets.GET("/:budget_id", h.getBudgetById)   //Get status of a specific budget
+			budgets.DELETE("/:budget_id", h.deleteBudget) //Delete a budget
+		}
+	}
+
+	// Create a route group for organization endpoints; every request must identify its caller
+	// and the role required in the organization is checked before the handler runs
+	organizations := router.Group("/organizations", h.identifyUser)
+	{
+		organizations.POST("/", h.createOrganization)                                                                                         //Create an organization owned by the caller
+		organizations.GET("/:org_id", h.requireOrgRole(models.RoleViewer), h.getOrganization)                                                 //Get an organization
+		organizations.PUT("/:org_id", h.requireOrgRole(models.RoleAdmin), h.updateOrganization)                                               //Rename an organization
+		organizations.DELETE("/:org_id", h.requireOrgRole(models.RoleOwner), h.deleteOrganization)                                            //Delete an organization
+		organizations.GET("/:org_id/members", h.requireOrgRole(models.RoleViewer), h.getOrganizationMembers)                                  //List members and roles
+		organizations.PUT("/:org_id/members/:user_id", h.requireOrgRole(models.RoleAdmin), h.setOrganizationMember)                           //Add a member or change a role
+		organizations.DELETE("/:org_id/members/:user_id", h.requireOrgRole(models.RoleAdmin), h.removeOrganizationMember)                     //Remove a member
+		organizations.GET("/:org_id/subscriptions", h.requireOrgRole(models.RoleViewer), h.getOrganizationSubscriptions)                      //List subscriptions paid by the organization
+		organizations.PUT("/:org_id/subscriptions/:subscription_id", h.requireOrgRole(models.RoleMember), h.attachOrganizationSubscription)   //Attach a subscription
+		organizations.DELETE("/:org_id/subscriptions/:subscription_id", h.requireOrgRole(models.RoleAdmin), h.detachOrganizationSubscription) //Detach a subscription
+		organizations.GET("/:org_id/total-cost", h.requireOrgRole(models.RoleViewer), h.getOrganizationSummary)                               //Total cost of organization subscriptions
+	}
+
+	// Create a route group for spend analytics endpoints
+	analytics := router.Group("/analytics")
+	{
+		analytics.GET("/forecast", h.getForecast)      //Project upcoming charges
+		analytics.GET("/settlement", h.getSettlements) //Who owes whom for shared subscriptions
 	}
 
 	return router
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Readiness runs the dependency checks of the readiness probe
+type Readiness interface {
+	Check(ctx context.Context) models.HealthReport
+}
+
+// healthz handles the liveness probe
+// @Summary Liveness probe
+// @Description Returns 200 while the process is running; dependencies are not checked
+// @Tags health
+// @Produce json
+// @Success 200 {object} statusResponse
+// @Router /healthz [get]
+func (h *Handler) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, statusResponse{Status: models.HealthOK})
+}
+
+// readyz handles the readiness probe
+// @Summary Readiness probe
+// @Description Checks the database, schema version and background workers; returns 503 when any check fails
+// @Description or while the application is starting or shutting down, so load balancers stop routing to it
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.HealthReport
+// @Failure 503 {object} models.HealthReport
+// @Router /readyz [get]
+func (h *Handler) readyz(c *gin.Context) {
+	if h.readiness == nil {
+		c.JSON(http.StatusOK, models.HealthReport{Status: models.HealthReady, Checks: map[string]models.HealthCheck{}})
+		return
+	}
+
+	report := h.readiness.Check(c.Request.Context())
+	if report.Status != models.HealthReady {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
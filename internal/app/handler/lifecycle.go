@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Cancel subscription
+// @Description Cancel a subscription; the current month is still billed and the subscription ends with it
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param subscription_id path int true "Subscription ID"
+// @Param input body models.CancelInput false "Cancellation reason"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /subscriptions/{subscription_id}/cancel [post]
+func (h *Handler) cancelSubscription(c *gin.Context) {
+	subID, err := strconv.Atoi(c.Param("subscription_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid subscription_id param")
+		return
+	}
+
+	// The request body is optional: the reason may be omitted
+	var input models.CancelInput
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&input); err != nil {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := h.services.LifecycleStore.Cancel(c.Request.Context(), subID, input); err != nil {
+		// Return 409 Conflict if the subscription is already cancelled or expired
+		if errors.Is(err, service.ErrInvalidTransition) {
+			newErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/gin-gonic/gin"
+)
+
+// getMembersResponse defines the response structure for listing members of a subscription
+type getMembersResponse struct {
+	Data []models.Member `json:"data"` // Members sharing the subscription
+}
+
+// getSettlementsResponse defines the response structure for the settlement view
+type getSettlementsResponse struct {
+	Data []models.Settlement `json:"data"` // Net debts between users per month
+}
+
+// @Summary Add subscription member
+// @Description Share the cost of a subscription with a user by weight or fixed amount; the owner pays the rest
+// @Tags members
+// @Accept  json
+// @Produce  json
+// @Param subscription_id path int true "Subscription ID"
+// @Param input body models.Member true "Member input"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /subscriptions/{subscription_id}/members [post]
+func (h *Handler) addMember(c *gin.Context) {
+	subID, err := strconv.Atoi(c.Param("subscription_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid subscription_id param")
+		return
+	}
+
+	var input models.Member
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.MemberStore.AddMember(c.Request.Context(), subID, input); err != nil {
+		// Return 409 Conflict if the user is already a member
+		if errors.Is(err, service.ErrMemberConflict) {
+			newErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		// Return 400 Bad Request if the member is not a registered user
+		if errors.Is(err, service.ErrUserNotFound) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
+
+// @Summary Get subscription members
+// @Description Get users sharing the cost of a subscription
+// @Tags members
+// @Accept  json
+// @Produce  json
+// @Param subscription_id path int true "Subscription ID"
+// @Success 200 {object} getMembersResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /subscriptions/{subscription_id}/members [get]
+func (h *Handler) getMembers(c *gin.Context) {
+	subID, err := strconv.Atoi(c.Param("subscription_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid subscription_id param")
+		return
+	}
+
+	members, err := h.services.MemberStore.GetMembers(c.Request.Context(), subID)
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, getMembersResponse{
+		Data: members,
+	})
+}
+
+// @Summary Remove subscription member
+// @Description Stop sharing a subscription with a user; their share returns to the owner
+// @Tags members
+// @Accept  json
+// @Produce  json
+// @Param subscription_id path int true "Subscription ID"
+// @Param user_id path string true "Member user ID"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /subscriptions/{subscription_id}/members/{user_id} [delete]
+func (h *Handler) removeMember(c *gin.Context) {
+	subID, err := strconv.Atoi(c.Param("subscription_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid subscription_id param")
+		return
+	}
+
+	if err := h.services.MemberStore.RemoveMember(c.Request.Context(), subID, c.Param("user_id")); err != nil {
+		// Return 404 Not Found if the user is not a member
+		if errors.Is(err, service.ErrMemberNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
+
+// @Summary Get settlements
+// @Description Net amounts members of shared personal subscriptions owe the owners, per month (current month by default)
+// @Tags analytics
+// @Accept  json
+// @Produce  json
+// @Param from query string false "First month in MM-YYYY format"
+// @Param to query string false "Last month in MM-YYYY format (defaults to from)"
+// @Param user_id query string false "Limit to debts of or to a single user"
+// @Success 200 {object} getSettlementsResponse
+// @Failure 500 {object} errorResponse
+// @Router /analytics/settlement [get]
+func (h *Handler) getSettlements(c *gin.Context) {
+	var userID *string
+	if raw := c.Query("user_id"); raw != "" {
+		userID = &raw
+	}
+
+	settlements, err := h.services.MemberStore.GetSettlements(c.Request.Context(), userID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, getSettlementsResponse{
+		Data: settlements,
+	})
+}
@@ -0,0 +1,368 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/gin-gonic/gin"
+)
+
+// getOrganizationMembersResponse defines the response structure for listing organization members
+type getOrganizationMembersResponse struct {
+	Data []models.OrganizationMember `json:"data"` // Members with their roles
+}
+
+// getOrganizationSubscriptionsResponse defines the response structure for listing organization subscriptions
+type getOrganizationSubscriptionsResponse struct {
+	Data []models.Subscription `json:"data"` // Subscriptions paid by the organization
+}
+
+// @Summary Create an organization
+// @Description Create an organization owned by the calling user
+// @Tags organizations
+// @Security UserIDAuth
+// @Accept  json
+// @Produce  json
+// @Param input body models.Organization true "Organization input"
+// @Success 200 {object} map[string]interface{} "organizationId"
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /organizations [post]
+func (h *Handler) createOrganization(c *gin.Context) {
+	var input models.Organization
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	orgID, err := h.services.OrganizationStore.Create(c.Request.Context(), c.GetString(userCtx), input)
+	if err != nil {
+		organizationErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"organizationId": orgID,
+	})
+}
+
+// @Summary Get organization
+// @Description Get an organization (viewer role required)
+// @Tags organizations
+// @Security UserIDAuth
+// @Accept  json
+// @Produce  json
+// @Param org_id path int true "Organization ID"
+// @Success 200 {object} models.Organization
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /organizations/{org_id} [get]
+func (h *Handler) getOrganization(c *gin.Context) {
+	org, err := h.services.OrganizationStore.GetById(c.Request.Context(), orgID(c))
+	if err != nil {
+		organizationErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+// @Summary Update organization
+// @Description Rename an organization (admin role required)
+// @Tags organizations
+// @Security UserIDAuth
+// @Accept  json
+// @Produce  json
+// @Param org_id path int true "Organization ID"
+// @Param input body models.Organization true "Organization input"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /organizations/{org_id} [put]
+func (h *Handler) updateOrganization(c *gin.Context) {
+	var input models.Organization
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.OrganizationStore.Update(c.Request.Context(), orgID(c), input); err != nil {
+		organizationErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
+
+// @Summary Delete organization
+// @Description Delete an organization; its subscriptions stay with their owners (owner role required)
+// @Tags organizations
+// @Security UserIDAuth
+// @Accept  json
+// @Produce  json
+// @Param org_id path int true "Organization ID"
+// @Success 200 {object} statusResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /organizations/{org_id} [delete]
+func (h *Handler) deleteOrganization(c *gin.Context) {
+	if err := h.services.OrganizationStore.Delete(c.Request.Context(), orgID(c)); err != nil {
+		organizationErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
+
+// @Summary Get organization members
+// @Description Get members of an organization with their roles (viewer role required)
+// @Tags organizations
+// @Security UserIDAuth
+// @Accept  json
+// @Produce  json
+// @Param org_id path int true "Organization ID"
+// @Success 200 {object} getOrganizationMembersResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /organizations/{org_id}/members [get]
+func (h *Handler) getOrganizationMembers(c *gin.Context) {
+	members, err := h.services.OrganizationStore.GetMembers(c.Request.Context(), orgID(c))
+	if err != nil {
+		organizationErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, getOrganizationMembersResponse{
+		Data: members,
+	})
+}
+
+// @Summary Set organization member
+// @Description Add a user to an organization or change their role (admin role required, owner role to manage owners)
+// @Tags organizations
+// @Security UserIDAuth
+// @Accept  json
+// @Produce  json
+// @Param org_id path int true "Organization ID"
+// @Param user_id path string true "Member user ID"
+// @Param input body models.OrganizationMember true "Member role"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /organizations/{org_id}/members/{user_id} [put]
+func (h *Handler) setOrganizationMember(c *gin.Context) {
+	var input models.OrganizationMember
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.OrganizationStore.SetMember(c.Request.Context(), c.GetString(orgRoleCtx), orgID(c), c.Param("user_id"), input); err != nil {
+		// Return 400 Bad Request if the new member is not a registered user
+		if errors.Is(err, service.ErrUserNotFound) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		organizationErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
+
+// @Summary Remove organization member
+// @Description Remove a user from an organization (admin role required, owner role to remove owners)
+// @Tags organizations
+// @Security UserIDAuth
+// @Accept  json
+// @Produce  json
+// @Param org_id path int true "Organization ID"
+// @Param user_id path string true "Member user ID"
+// @Success 200 {object} statusResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /organizations/{org_id}/members/{user_id} [delete]
+func (h *Handler) removeOrganizationMember(c *gin.Context) {
+	if err := h.services.OrganizationStore.RemoveMember(c.Request.Context(), c.GetString(orgRoleCtx), orgID(c), c.Param("user_id")); err != nil {
+		organizationErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
+
+// @Summary Get organization subscriptions
+// @Description Get subscriptions paid by an organization (viewer role required)
+// @Tags organizations
+// @Security UserIDAuth
+// @Accept  json
+// @Produce  json
+// @Param org_id path int true "Organization ID"
+// @Param status query string false "Lifecycle status filter (trial, active, paused, cancelled, expired)"
+// @Success 200 {object} getOrganizationSubscriptionsResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /organizations/{org_id}/subscriptions [get]
+func (h *Handler) getOrganizationSubscriptions(c *gin.Context) {
+	var status *string
+	if raw := c.Query("status"); raw != "" {
+		status = &raw
+	}
+
+	subs, err := h.services.OrganizationStore.GetSubscriptions(c.Request.Context(), orgID(c), status)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStatus) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		organizationErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, getOrganizationSubscriptionsResponse{
+		Data: subs,
+	})
+}
+
+// @Summary Attach subscription to organization
+// @Description Make an organization pay for a subscription (member role required for own subscriptions, admin for any)
+// @Tags organizations
+// @Security UserIDAuth
+// @Accept  json
+// @Produce  json
+// @Param org_id path int true "Organization ID"
+// @Param subscription_id path int true "Subscription ID"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /organizations/{org_id}/subscriptions/{subscription_id} [put]
+func (h *Handler) attachOrganizationSubscription(c *gin.Context) {
+	subID, err := strconv.Atoi(c.Param("subscription_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid subscription_id param")
+		return
+	}
+
+	if err := h.services.OrganizationStore.AttachSubscription(c.Request.Context(), c.GetString(userCtx), c.GetString(orgRoleCtx), orgID(c), subID); err != nil {
+		organizationErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
+
+// @Summary Detach subscription from organization
+// @Description Return a subscription of an organization to its owner (admin role required)
+// @Tags organizations
+// @Security UserIDAuth
+// @Accept  json
+// @Produce  json
+// @Param org_id path int true "Organization ID"
+// @Param subscription_id path int true "Subscription ID"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /organizations/{org_id}/subscriptions/{subscription_id} [delete]
+func (h *Handler) detachOrganizationSubscription(c *gin.Context) {
+	subID, err := strconv.Atoi(c.Param("subscription_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid subscription_id param")
+		return
+	}
+
+	if err := h.services.OrganizationStore.DetachSubscription(c.Request.Context(), orgID(c), subID); err != nil {
+		organizationErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
+
+// @Summary Get organization total cost
+// @Description Calculate the total cost of subscriptions paid by an organization for a period (viewer role required)
+// @Tags organizations
+// @Security UserIDAuth
+// @Accept  json
+// @Produce  json
+// @Param org_id path int true "Organization ID"
+// @Param input body models.SubscriptionFilter true "Period and optional filters"
+// @Success 200 {object} models.SubscriptionFilter
+// @Failure 400 {object} errorResponse
+// @Failure 401 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /organizations/{org_id}/total-cost [get]
+func (h *Handler) getOrganizationSummary(c *gin.Context) {
+	var filter models.SubscriptionFilter
+	if err := c.BindJSON(&filter); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id := orgID(c)
+	totalCost, err := h.services.OrganizationStore.GetSummary(c.Request.Context(), id, filter)
+	if err != nil {
+		organizationErrorResponse(c, err)
+		return
+	}
+
+	filter.TotalCost = totalCost
+	filter.Currency = models.DefaultCurrency
+
+	c.JSON(http.StatusOK, filter)
+}
+
+// orgID returns the organization ID already validated by requireOrgRole
+func orgID(c *gin.Context) int {
+	id, _ := strconv.Atoi(c.Param("org_id"))
+	return id
+}
+
+// organizationErrorResponse maps organization service errors to HTTP statuses
+func organizationErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrForbidden):
+		newErrorResponse(c, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrOrganizationNotFound), errors.Is(err, service.ErrNotOrganizationMember):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrOrganizationConflict):
+		newErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		userErrorResponse(c, err)
+	}
+}
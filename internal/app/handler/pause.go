@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Pause subscription
+// @Description Stop billing of a subscription starting with the given month (current month by default)
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param subscription_id path int true "Subscription ID"
+// @Param input body models.PauseInput false "Pause start month"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /subscriptions/{subscription_id}/pause [post]
+func (h *Handler) pauseSubscription(c *gin.Context) {
+	h.changePauseState(c, h.services.PauseStore.Pause)
+}
+
+// @Summary Resume subscription
+// @Description Continue billing of a paused subscription from the given month (current month by default)
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Param subscription_id path int true "Subscription ID"
+// @Param input body models.PauseInput false "Resume month"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /subscriptions/{subscription_id}/resume [post]
+func (h *Handler) resumeSubscription(c *gin.Context) {
+	h.changePauseState(c, h.services.PauseStore.Resume)
+}
+
+// changePauseState parses a pause or resume request and applies it with the given service operation
+func (h *Handler) changePauseState(c *gin.Context, apply func(ctx context.Context, subID int, input models.PauseInput) error) {
+	subID, err := strconv.Atoi(c.Param("subscription_id"))
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, "invalid subscription_id param")
+		return
+	}
+
+	// The request body is optional: without it the change applies to the current month
+	var input models.PauseInput
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&input); err != nil {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if err := apply(c.Request.Context(), subID, input); err != nil {
+		// Return 409 Conflict if the subscription is already in the requested state
+		// or its status does not allow the change
+		if errors.Is(err, service.ErrPauseConflict) || errors.Is(err, service.ErrInvalidTransition) {
+			newErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		// Return 400 Bad Request if the month is malformed or outside of the billed period
+		if errors.Is(err, service.ErrInvalidPause) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		// Return 404 Not Found if the subscription does not exist
+		if errors.Is(err, service.ErrSubscriptionNotFound) {
+			newErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
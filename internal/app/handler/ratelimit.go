@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/logging"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// unlimitedRoutes are never rate limited so that probes and scrapes keep working under load
+var unlimitedRoutes = map[string]bool{
+	"/healthz":      true,
+	"/readyz":       true,
+	"/metrics":      true,
+	"/swagger/*any": true,
+}
+
+// summaryRoutes aggregate many rows per request and get the stricter summary limit
+var summaryRoutes = map[string]bool{
+	"/subscriptions/total-cost":         true,
+	"/subscriptions/duplicates":         true,
+	"/organizations/:org_id/total-cost": true,
+	"/analytics/forecast":               true,
+	"/analytics/settlement":             true,
+}
+
+// routeClass returns the rate limit class of the matched route
+func routeClass(c *gin.Context) ratelimit.Class {
+	switch {
+	case summaryRoutes[c.FullPath()]:
+		return ratelimit.ClassSummary
+	case c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead:
+		return ratelimit.ClassRead
+	default:
+		return ratelimit.ClassWrite
+	}
+}
+
+// rateLimitKey identifies the client: the calling user when the identity header names an
+// existing user, otherwise the client IP. Unknown or malformed IDs fall back to the IP so a
+// client cannot get a fresh bucket by sending a new header value with every request
+func (h *Handler) rateLimitKey(c *gin.Context) string {
+	if userID := c.GetHeader(userIdentityHeader); userID != "" {
+		if _, err := uuid.Parse(userID); err == nil {
+			if user, err := h.services.UserStore.GetById(c.Request.Context(), userID); err == nil {
+				return "user:" + user.Id
+			}
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// rateLimit takes a token from the client's bucket for the route class, reports the bucket
+// in RateLimit-* headers and rejects the request with 429 when the bucket is empty.
+// Store failures let the request through: the limiter must not take the API down
+func (h *Handler) rateLimit(c *gin.Context) {
+	if unlimitedRoutes[c.FullPath()] {
+		c.Next()
+		return
+	}
+
+	class := routeClass(c)
+	limit := h.limiter.Limit(class)
+
+	result, err := h.limiter.Allow(c.Request.Context(), class, h.rateLimitKey(c))
+	if err != nil {
+		logrus.WithContext(c.Request.Context()).WithError(err).Warn("rate limit check failed, request allowed")
+		c.Next()
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	header.Set("RateLimit-Policy", strconv.Itoa(limit.Burst)+";w="+strconv.Itoa(ceilSeconds(limit.Window())))
+
+	if !result.Allowed {
+		// Rejections are expected under load; the access log records them as warnings
+		header.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(result.RetryAfter))))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, errorResponse{
+			Message:   "rate limit exceeded for " + string(class) + " requests",
+			RequestID: logging.RequestID(c.Request.Context()),
+		})
+		return
+	}
+
+	c.Next()
+}
+
+// ceilSeconds rounds a duration up to whole seconds as required by the headers
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// requestIDHeader carries the request ID from the client or proxy and back in the response
+	requestIDHeader = "X-Request-ID"
+
+	// maxRequestIDLength limits client supplied request IDs written to logs
+	maxRequestIDLength = 128
+)
+
+// requestID takes the request ID from the header or generates one, returns it in the response
+// and stores it in the request context so every log line of the request can include it
+func requestID(c *gin.Context) {
+	id := c.GetHeader(requestIDHeader)
+	if !validRequestID(id) {
+		id = uuid.NewString()
+	}
+
+	c.Header(requestIDHeader, id)
+	c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+	c.Next()
+}
+
+// validRequestID accepts non-empty IDs of printable ASCII characters up to maxRequestIDLength
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// accessLog writes one structured log line per request after it has been handled
+// Server errors are logged at error level, client errors at warning level
+func accessLog(c *gin.Context) {
+	start := time.Now()
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	user := c.GetString(userCtx)
+	if user == "" {
+		user = c.GetHeader(userIdentityHeader)
+	}
+
+	entry := logrus.WithContext(c.Request.Context()).WithFields(logrus.Fields{
+		"method":     c.Request.Method,
+		"route":      route,
+		"path":       c.Request.URL.Path,
+		"status":     c.Writer.Status(),
+		"latency_ms": time.Since(start).Milliseconds(),
+		"bytes":      c.Writer.Size(),
+		"client_ip":  c.ClientIP(),
+		"user":       user,
+	})
+
+	switch status := c.Writer.Status(); {
+	case status >= http.StatusInternalServerError:
+		entry.Error("request completed")
+	case status >= http.StatusBadRequest:
+		entry.Warn("request completed")
+	default:
+		entry.Info("request completed")
+	}
+}
+
+// recovery turns a panic in a handler into a 500 response and logs it with the stack trace
+func recovery(c *gin.Context) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		// The client went away; there is nobody to answer
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+
+		logrus.WithContext(c.Request.Context()).
+			WithField("stack", string(debug.Stack())).
+			Errorf("panic recovered: %v", recovered)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse{
+			Message:   "internal server error",
+			RequestID: logging.RequestID(c.Request.Context()),
+		})
+	}()
+
+	c.Next()
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
 	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
 )
 
 // createSubscription handles HTTP POST request for creating a new subscription
@@ -16,8 +19,9 @@ import (
 // @Accept  json
 // @Produce  json
 // @Param input body models.Subscription true "Subscription input"
-// @Success 200 {object} map[string]interface{} "subId"
+// @Success 200 {object} map[string]interface{} "subId, plus warning and duplicates when the subscription overlaps existing ones"
 // @Failure 400 {object} errorResponse
+// @Failure 409 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Router /subscriptions [post]
 func (h *Handler) createSubscription(c *gin.Context) {
@@ -32,8 +36,18 @@ func (h *Handler) createSubscription(c *gin.Context) {
 
 	// Call service layer to handle business logic and persistence
 	// Service will validate business rules and create the subscription
-	subId, err := h.services.SubscriptionStore.Create(sub)
+	subId, err := h.services.SubscriptionStore.Create(c.Request.Context(), sub)
 	if err != nil {
+		// Return 409 Conflict if the duplicate policy rejected an overlapping subscription
+		if errors.Is(err, service.ErrDuplicateSubscription) {
+			newErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
+		// Return 400 Bad Request if the owner is not a registered user
+		if errors.Is(err, service.ErrUserNotFound) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		// Return 500 Internal Server Error if service operation fails
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -41,9 +55,28 @@ func (h *Handler) createSubscription(c *gin.Context) {
 
 	// Return 200 OK with the ID of the newly created subscription
 	// The ID can be used by clients for subsequent operations
-	c.JSON(http.StatusOK, map[string]interface{}{
+	response := map[string]interface{}{
 		"subId": subId,
-	})
+	}
+	h.addDuplicateWarning(c, subId, response)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// addDuplicateWarning attaches overlapping subscriptions to a write response
+// Used under the warn policy, when overlaps are stored but reported to the client
+func (h *Handler) addDuplicateWarning(c *gin.Context, subID int, response map[string]interface{}) {
+	duplicates, err := h.services.SubscriptionStore.FindDuplicates(c.Request.Context(), subID)
+	if err != nil {
+		// The write already succeeded, so a failed check must not turn it into an error
+		logrus.WithContext(c.Request.Context()).Warnf("failed to check subscription %d for duplicates: %s", subID, err.Error())
+		return
+	}
+
+	if len(duplicates) > 0 {
+		response["warning"] = "subscription overlaps existing subscriptions of the same service"
+		response["duplicates"] = duplicates
+	}
 }
 
 // getAllSubResponse defines the response structure for listing all subscriptions
@@ -53,19 +86,33 @@ type getAllSubResponse struct {
 }
 
 // getAllSubscriptions handles HTTP GET request to retrieve all subscriptions
-// This endpoint returns a list of all subscriptions in the system
+// This endpoint returns a list of all personal subscriptions in the system
 // @Summary Get all subscriptions
-// @Description Get all subscriptions
+// @Description Get all personal subscriptions; subscriptions paid by an organization are listed by GET /organizations/{org_id}/subscriptions
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
+// @Param status query string false "Lifecycle status filter (trial, active, paused, cancelled, expired)"
 // @Success 200 {object} getAllSubResponse
+// @Failure 400 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Router /subscriptions [get]
 func (h *Handler) getAllSubscriptions(c *gin.Context) {
+	// Optional status filter from the query string
+	// Subscriptions paid by an organization are visible to its members only
+	filter := models.SubscriptionListFilter{Personal: true}
+	if status := c.Query("status"); status != "" {
+		filter.Status = &status
+	}
+
 	// Retrieve all subscriptions from the service layer
-	subs, err := h.services.SubscriptionStore.GetAll()
+	subs, err := h.services.SubscriptionStore.GetAll(c.Request.Context(), filter)
 	if err != nil {
+		// Return 400 Bad Request for unknown statuses
+		if errors.Is(err, service.ErrInvalidStatus) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		// Return 500 Internal Server Error if data retrieval fails
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -87,6 +134,8 @@ func (h *Handler) getAllSubscriptions(c *gin.Context) {
 // @Param subscription_id path int true "Subscription ID"
 // @Success 200 {object} models.Subscription
 // @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Router /subscriptions/{subscription_id} [get]
 func (h *Handler) getSubscriptionById(c *gin.Context) {
@@ -100,7 +149,7 @@ func (h *Handler) getSubscriptionById(c *gin.Context) {
 	}
 
 	// Retrieve the subscription from the service layer using the extracted ID
-	sub, err := h.services.SubscriptionStore.GetById(subID)
+	sub, err := h.services.SubscriptionStore.GetById(c.Request.Context(), subID)
 	if err != nil {
 		// Return 500 Internal Server Error if data retrieval fails
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
@@ -118,8 +167,11 @@ func (h *Handler) getSubscriptionById(c *gin.Context) {
 // @Produce  json
 // @Param subscription_id path int true "Subscription ID"
 // @Param input body models.UpdateSubscription true "Update input"
-// @Success 200 {object} statusResponse
+// @Success 200 {object} map[string]interface{} "status, plus warning and duplicates when the subscription overlaps existing ones"
 // @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Router /subscriptions/{subscription_id} [put]
 func (h *Handler) updateSubscription(c *gin.Context) {
@@ -139,15 +191,24 @@ func (h *Handler) updateSubscription(c *gin.Context) {
 		return
 	}
 
-	err = h.services.SubscriptionStore.Update(subID, input)
+	err = h.services.SubscriptionStore.Update(c.Request.Context(), subID, input)
 	if err != nil {
+		// Return 409 Conflict if the new period overlaps, the new price is below the fixed amounts of members
+		// or the dates of a cancelled or expired subscription are changed
+		if errors.Is(err, service.ErrDuplicateSubscription) || errors.Is(err, service.ErrMemberConflict) || errors.Is(err, service.ErrInvalidTransition) {
+			newErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, statusResponse{
-		Status: "Operation completed successfully",
-	})
+	response := map[string]interface{}{
+		"status": "Operation completed successfully",
+	}
+	h.addDuplicateWarning(c, subID, response)
+
+	c.JSON(http.StatusOK, response)
 }
 
 // @Summary Delete subscription
@@ -158,6 +219,8 @@ func (h *Handler) updateSubscription(c *gin.Context) {
 // @Param subscription_id path int true "Subscription ID"
 // @Success 200 {object} statusResponse
 // @Failure 400 {object} errorResponse
+// @Failure 403 {object} errorResponse
+// @Failure 404 {object} errorResponse
 // @Failure 500 {object} errorResponse
 // @Router /subscriptions/{subscription_id} [delete]
 func (h *Handler) deleteSubscription(c *gin.Context) {
@@ -171,7 +234,7 @@ func (h *Handler) deleteSubscription(c *gin.Context) {
 	}
 
 	// Service will delete the subscription
-	err = h.services.SubscriptionStore.Delete(subID)
+	err = h.services.SubscriptionStore.Delete(c.Request.Context(), subID)
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
@@ -187,7 +250,7 @@ func (h *Handler) deleteSubscription(c *gin.Context) {
 }
 
 // @Summary Get subscription summary
-// @Description Get subscription summary with filters
+// @Description Get the total cost of personal subscriptions with filters
 // @Tags subscriptions
 // @Accept  json
 // @Produce  json
@@ -204,14 +267,39 @@ func (h *Handler) getSubscriptionSummary(c *gin.Context) {
 		return
 	}
 
-	totalCost, err := h.services.SubscriptionStore.GetSubscriptionSummary(filter)
+	totalCost, err := h.services.SubscriptionStore.GetSubscriptionSummary(c.Request.Context(), filter)
 	if err != nil {
 		newErrorResponse(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	filter.TotalCost = totalCost
-	filter.Currency = "RUB"
+	filter.Currency = models.DefaultCurrency
 
 	c.JSON(http.StatusOK, filter)
 }
+
+// getDuplicatesResponse defines the response structure for the duplicates report
+type getDuplicatesResponse struct {
+	Data []models.DuplicateGroup `json:"data"` // Groups of overlapping subscriptions
+}
+
+// @Summary Get duplicate subscriptions
+// @Description Report personal subscriptions of the same user and service with overlapping periods
+// @Tags subscriptions
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} getDuplicatesResponse
+// @Failure 500 {object} errorResponse
+// @Router /subscriptions/duplicates [get]
+func (h *Handler) getDuplicateSubscriptions(c *gin.Context) {
+	groups, err := h.services.SubscriptionStore.GetDuplicates(c.Request.Context())
+	if err != nil {
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, getDuplicatesResponse{
+		Data: groups,
+	})
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultTrialLookaheadMonths is used when the months query parameter is omitted
+const defaultTrialLookaheadMonths = 1
+
+// getEndingTrialsResponse defines the response structure for the ending trials view
+type getEndingTrialsResponse struct {
+	Data []models.Subscription `json:"data"` // Subscriptions whose trial ends soon
+}
+
+// @Summary Get ending trials
+// @Description Get personal free trials whose last free month is within the given number of months, starting with the current month
+// @Tags trials
+// @Accept  json
+// @Produce  json
+// @Param months query int false "Number of months to look ahead (default 1)"
+// @Success 200 {object} getEndingTrialsResponse
+// @Failure 400 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /subscriptions/trials/ending [get]
+func (h *Handler) getEndingTrials(c *gin.Context) {
+	months := defaultTrialLookaheadMonths
+	if raw := c.Query("months"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid months param")
+			return
+		}
+		months = value
+	}
+
+	subs, err := h.services.TrialStore.GetEndingTrials(c.Request.Context(), months)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTrialLookahead) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, getEndingTrialsResponse{
+		Data: subs,
+	})
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/gin-gonic/gin"
+)
+
+// getUserSubscriptionsResponse defines the response structure for listing subscriptions of a user
+type getUserSubscriptionsResponse struct {
+	Data []models.Subscription `json:"data"` // Subscriptions the user owns or shares
+}
+
+// @Summary Create a user
+// @Description Create a user; the ID is generated unless provided
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param input body models.User true "User input"
+// @Success 200 {object} map[string]interface{} "userId"
+// @Failure 400 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /users [post]
+func (h *Handler) createUser(c *gin.Context) {
+	var input models.User
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, err := h.services.UserStore.Create(c.Request.Context(), input)
+	if err != nil {
+		userErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{
+		"userId": userID,
+	})
+}
+
+// @Summary Get user by ID
+// @Description Get user profile and preferences
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Success 200 {object} models.User
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /users/{id} [get]
+func (h *Handler) getUserById(c *gin.Context) {
+	user, err := h.services.UserStore.GetById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		userErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Update user
+// @Description Update display name, timezone or currency preference of a user
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param input body models.UpdateUser true "User update data"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /users/{id} [put]
+func (h *Handler) updateUser(c *gin.Context) {
+	var input models.UpdateUser
+	if err := c.BindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.services.UserStore.Update(c.Request.Context(), c.Param("id"), input); err != nil {
+		userErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
+
+// @Summary Delete user
+// @Description Delete a user with their budgets and memberships; owned subscriptions block the deletion unless cascade is set, subscriptions paid by an organization always do
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param cascade query bool false "Also delete personal subscriptions owned by the user"
+// @Success 200 {object} statusResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 409 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /users/{id} [delete]
+func (h *Handler) deleteUser(c *gin.Context) {
+	cascade := false
+	if raw := c.Query("cascade"); raw != "" {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, "invalid cascade param")
+			return
+		}
+		cascade = value
+	}
+
+	if err := h.services.UserStore.Delete(c.Request.Context(), c.Param("id"), cascade); err != nil {
+		userErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, statusResponse{
+		Status: "Operation completed successfully",
+	})
+}
+
+// @Summary Get user subscriptions
+// @Description Get personal subscriptions the user owns or shares as a member
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param id path string true "User ID"
+// @Param status query string false "Lifecycle status filter (trial, active, paused, cancelled, expired)"
+// @Success 200 {object} getUserSubscriptionsResponse
+// @Failure 400 {object} errorResponse
+// @Failure 404 {object} errorResponse
+// @Failure 500 {object} errorResponse
+// @Router /users/{id}/subscriptions [get]
+func (h *Handler) getUserSubscriptions(c *gin.Context) {
+	var status *string
+	if raw := c.Query("status"); raw != "" {
+		status = &raw
+	}
+
+	subs, err := h.services.UserStore.GetSubscriptions(c.Request.Context(), c.Param("id"), status)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStatus) {
+			newErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		userErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, getUserSubscriptionsResponse{
+		Data: subs,
+	})
+}
+
+// userErrorResponse maps user service errors to HTTP statuses
+func userErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidUser):
+		newErrorResponse(c, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrUserNotFound):
+		newErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrUserConflict):
+		newErrorResponse(c, http.StatusConflict, err.Error())
+	default:
+		newErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+)
+
+// DefaultTimeout bounds a single check when no other timeout is configured
+const DefaultTimeout = 2 * time.Second
+
+// Check verifies one dependency, returning an error when it is not usable
+type Check func(ctx context.Context) error
+
+// Checker runs named readiness checks concurrently, each within its own timeout
+type Checker struct {
+	timeout time.Duration
+	names   []string
+	checks  map[string]Check
+}
+
+// NewChecker creates a checker without checks
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout, checks: make(map[string]Check)}
+}
+
+// Add registers a check under the name shown in the report
+func (c *Checker) Add(name string, check Check) {
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Check runs all checks and reports ready only when every check passed
+// A check that does not finish within the timeout fails with the context error
+func (c *Checker) Check(ctx context.Context) models.HealthReport {
+	report := models.HealthReport{
+		Status: models.HealthReady,
+		Checks: make(map[string]models.HealthCheck, len(c.names)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range c.names {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status != models.HealthOK {
+				report.Status = models.HealthNotReady
+			}
+		}(name, c.checks[name])
+	}
+	wg.Wait()
+
+	return report
+}
+
+// run executes a single check within the timeout
+func (c *Checker) run(ctx context.Context, check Check) models.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := models.HealthCheck{Status: models.HealthOK, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = models.HealthFail
+		result.Error = err.Error()
+	}
+	return result
+}
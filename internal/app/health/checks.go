@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+)
+
+// Database fails when PostgreSQL does not answer a ping
+func Database(store postgres.HealthStore) Check {
+	return store.Ping
+}
+
+// Migrations fails unless the schema is at the expected version and not dirty
+func Migrations(store postgres.HealthStore, expected uint) Check {
+	return func(ctx context.Context) error {
+		version, dirty, err := store.MigrationVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d is dirty", version)
+		}
+		if version != expected {
+			return fmt.Errorf("schema version %d, expected %d", version, expected)
+		}
+		return nil
+	}
+}
+
+// Workers fails when a background worker has stopped; workers reports the running state by name
+func Workers(workers func() map[string]bool) Check {
+	return func(ctx context.Context) error {
+		var stopped []string
+		for name, running := range workers() {
+			if !running {
+				stopped = append(stopped, name)
+			}
+		}
+		if len(stopped) > 0 {
+			sort.Strings(stopped)
+			return fmt.Errorf("workers not running: %s", strings.Join(stopped, ", "))
+		}
+		return nil
+	}
+}
+
+// Accepting fails while the application is starting or shutting down
+func Accepting(ready func() bool) Check {
+	return func(ctx context.Context) error {
+		if !ready() {
+			return errors.New("not accepting traffic")
+		}
+		return nil
+	}
+}
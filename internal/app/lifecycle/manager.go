@@ -0,0 +1,157 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// closer releases a resource once requests and workers have stopped
+type closer struct {
+	name  string
+	close func() error
+}
+
+// Manager coordinates readiness, in-flight requests, background workers and resources
+// Shutdown runs in a fixed order: readiness fails, the HTTP server drains,
+// workers stop, and resources are closed in reverse registration order
+type Manager struct {
+	ctx     context.Context    // Cancelled when workers must stop
+	cancel  context.CancelFunc // Stops the workers
+	workers sync.WaitGroup
+
+	ready    atomic.Bool
+	inFlight atomic.Int64
+
+	mu      sync.Mutex
+	closers []closer
+	running map[string]bool // Workers by name, false once a worker has returned
+}
+
+// NewManager creates a new lifecycle manager; the application starts not ready
+func NewManager() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{ctx: ctx, cancel: cancel, running: make(map[string]bool)}
+}
+
+// Go runs a background worker until shutdown; the worker must return once ctx is cancelled
+func (m *Manager) Go(name string, run func(ctx context.Context)) {
+	m.setRunning(name, true)
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		run(m.ctx)
+		m.setRunning(name, false)
+		logrus.Infof("worker %s stopped", name)
+	}()
+}
+
+// setRunning records the state of a worker
+func (m *Manager) setRunning(name string, running bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.running[name] = running
+}
+
+// Workers returns whether each started worker is still running
+func (m *Manager) Workers() map[string]bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workers := make(map[string]bool, len(m.running))
+	for name, running := range m.running {
+		workers[name] = running
+	}
+	return workers
+}
+
+// OnClose registers a resource to close after workers have stopped
+func (m *Manager) OnClose(name string, close func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closers = append(m.closers, closer{name: name, close: close})
+}
+
+// SetReady marks whether the application accepts traffic
+func (m *Manager) SetReady(ready bool) {
+	m.ready.Store(ready)
+}
+
+// Ready reports whether load balancers should route traffic to this instance
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// InFlight returns the number of requests currently being served
+func (m *Manager) InFlight() int64 {
+	return m.inFlight.Load()
+}
+
+// Track wraps the handler to count in-flight requests
+func (m *Manager) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Shutdown stops the application within the deadline of ctx
+// Readiness fails first and requests keep being served for drainDelay so load balancers
+// notice it, then stopServer waits for in-flight requests. Requests still running at the
+// deadline are cut off and counted in the log
+func (m *Manager) Shutdown(ctx context.Context, drainDelay time.Duration, stopServer func(context.Context) error) error {
+	m.SetReady(false)
+
+	var errs []error
+
+	select {
+	case <-time.After(drainDelay):
+	case <-ctx.Done():
+	}
+
+	if err := stopServer(ctx); err != nil {
+		if cut := m.InFlight(); cut > 0 {
+			logrus.Warnf("shutdown deadline reached, %d in-flight requests cut off", cut)
+		}
+		errs = append(errs, fmt.Errorf("failed to stop http server: %w", err))
+	}
+
+	m.cancel()
+	if err := m.waitWorkers(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	m.mu.Lock()
+	closers := m.closers
+	m.mu.Unlock()
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s: %w", closers[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// waitWorkers waits for background workers to return or for the deadline
+func (m *Manager) waitWorkers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.New("shutdown deadline reached before background workers stopped")
+	}
+}
@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by the context, or an empty string
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// ContextHook adds the request ID and trace ID of the entry context to log lines
+// Lower layers get them by logging with logrus.WithContext(ctx)
+type ContextHook struct{}
+
+// Levels applies the hook to every level
+func (ContextHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire copies identifiers from the entry context into the entry fields
+func (ContextHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	if requestID := RequestID(entry.Context); requestID != "" {
+		entry.Data["request_id"] = requestID
+	}
+	if span := trace.SpanContextFromContext(entry.Context); span.IsValid() {
+		entry.Data["trace_id"] = span.TraceID().String()
+	}
+
+	return nil
+}
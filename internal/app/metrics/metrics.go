@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the business metrics of the application
+const namespace = "subscription_aggregator"
+
+// Metrics owns the Prometheus registry and the collectors of the application
+// A dedicated registry keeps instances independent, e.g. between tests
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestDuration *prometheus.HistogramVec
+	queryDuration   *prometheus.HistogramVec
+	subscriptions   *prometheus.GaugeVec
+	monthlySpend    prometheus.Gauge
+	cacheRequests   *prometheus.CounterVec
+	invalidations   *prometheus.CounterVec
+}
+
+// New creates the collectors and registers them together with Go runtime and process metrics
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of HTTP requests by method, route and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of repository calls by store, method and outcome.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"store", "method", "outcome"}),
+		subscriptions: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "subscriptions",
+			Help:      "Number of subscriptions by lifecycle status.",
+		}, []string{"status"}),
+		monthlySpend: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "monthly_spend",
+			Help:      "Total charged for all subscriptions in the current month, in " + models.DefaultCurrency + ".",
+		}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Cache lookups by cache and result (hit or miss).",
+		}, []string{"cache", "result"}),
+		invalidations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_invalidations_total",
+			Help: "Cache invalidations by source: local writes, database notifications or resets.",
+		}, []string{"source"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestDuration,
+		m.queryDuration,
+		m.subscriptions,
+		m.monthlySpend,
+		m.cacheRequests,
+		m.invalidations,
+	)
+
+	return m
+}
+
+// Handler serves the registered metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Middleware records the duration of every request
+// The route template is used instead of the path to keep the number of series bounded
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.requestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterDB exports connection pool statistics of the database
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// SetStats updates the business gauges
+func (m *Metrics) SetStats(stats models.SubscriptionStats) {
+	for status, count := range stats.ByStatus {
+		m.subscriptions.WithLabelValues(status).Set(float64(count))
+	}
+	m.monthlySpend.Set(float64(stats.MonthlySpend))
+}
+
+// CacheLookup counts a cache hit or miss
+func (m *Metrics) CacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// CacheInvalidation counts an invalidation of cached entries
+func (m *Metrics) CacheInvalidation(source string) {
+	m.invalidations.WithLabelValues(source).Inc()
+}
+
+// observe records the duration of a repository call started at start
+func (m *Metrics) observe(store, method string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.queryDuration.WithLabelValues(store, method, outcome).Observe(time.Since(start).Seconds())
+}
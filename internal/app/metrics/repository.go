@@ -0,0 +1,324 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+)
+
+// InstrumentRepository wraps every store of the repository to record call durations
+// The health store is left as is so readiness probes do not skew query metrics
+func (m *Metrics) InstrumentRepository(repos *postgres.Repository) *postgres.Repository {
+	return &postgres.Repository{
+		SubscriptionStore: subscriptionStore{next: repos.SubscriptionStore, m: m},
+		OutboxStore:       outboxStore{next: repos.OutboxStore, m: m},
+		BudgetStore:       budgetStore{next: repos.BudgetStore, m: m},
+		PauseStore:        pauseStore{next: repos.PauseStore, m: m},
+		MemberStore:       memberStore{next: repos.MemberStore, m: m},
+		UserStore:         userStore{next: repos.UserStore, m: m},
+		OrganizationStore: organizationStore{next: repos.OrganizationStore, m: m},
+		HealthStore:       repos.HealthStore,
+		RateLimitStore:    rateLimitStore{next: repos.RateLimitStore, m: m},
+		SpendStore:        spendStore{next: repos.SpendStore, m: m},
+	}
+}
+
+// track records the duration of a repository call; err is read when the call returns
+func (m *Metrics) track(store, method string, start time.Time, err *error) {
+	m.observe(store, method, start, *err)
+}
+
+// subscriptionStore records call durations of postgres.SubscriptionStore
+type subscriptionStore struct {
+	next postgres.SubscriptionStore
+	m    *Metrics
+}
+
+func (s subscriptionStore) Create(ctx context.Context, sub models.Subscription) (result int, err error) {
+	defer s.m.track("subscription", "Create", time.Now(), &err)
+	return s.next.Create(ctx, sub)
+}
+
+func (s subscriptionStore) GetAll(ctx context.Context, filter models.SubscriptionListFilter) (result []models.SubscriptionDB, err error) {
+	defer s.m.track("subscription", "GetAll", time.Now(), &err)
+	return s.next.GetAll(ctx, filter)
+}
+
+func (s subscriptionStore) GetById(ctx context.Context, subID int) (result models.SubscriptionDB, err error) {
+	defer s.m.track("subscription", "GetById", time.Now(), &err)
+	return s.next.GetById(ctx, subID)
+}
+
+func (s subscriptionStore) Delete(ctx context.Context, subID int) (err error) {
+	defer s.m.track("subscription", "Delete", time.Now(), &err)
+	return s.next.Delete(ctx, subID)
+}
+
+func (s subscriptionStore) Update(ctx context.Context, subID int, input models.UpdateSubscription) (err error) {
+	defer s.m.track("subscription", "Update", time.Now(), &err)
+	return s.next.Update(ctx, subID, input)
+}
+
+func (s subscriptionStore) GetSubscriptionSummary(ctx context.Context, filter models.SubscriptionFilter) (result int, err error) {
+	defer s.m.track("subscription", "GetSubscriptionSummary", time.Now(), &err)
+	return s.next.GetSubscriptionSummary(ctx, filter)
+}
+
+func (s subscriptionStore) GetCharges(ctx context.Context, filter models.ChargeFilter) (result []models.ChargeDB, err error) {
+	defer s.m.track("subscription", "GetCharges", time.Now(), &err)
+	return s.next.GetCharges(ctx, filter)
+}
+
+func (s subscriptionStore) FindOverlaps(ctx context.Context, filter models.OverlapFilter) (result []models.SubscriptionDB, err error) {
+	defer s.m.track("subscription", "FindOverlaps", time.Now(), &err)
+	return s.next.FindOverlaps(ctx, filter)
+}
+
+func (s subscriptionStore) GetDuplicates(ctx context.Context) (result []models.SubscriptionDB, err error) {
+	defer s.m.track("subscription", "GetDuplicates", time.Now(), &err)
+	return s.next.GetDuplicates(ctx)
+}
+
+func (s subscriptionStore) GetEndingTrials(ctx context.Context, from time.Time, to time.Time) (result []models.SubscriptionDB, err error) {
+	defer s.m.track("subscription", "GetEndingTrials", time.Now(), &err)
+	return s.next.GetEndingTrials(ctx, from, to)
+}
+
+func (s subscriptionStore) ConvertEndedTrials(ctx context.Context, month time.Time) (result []models.SubscriptionDB, err error) {
+	defer s.m.track("subscription", "ConvertEndedTrials", time.Now(), &err)
+	return s.next.ConvertEndedTrials(ctx, month)
+}
+
+func (s subscriptionStore) ChangeStatus(ctx context.Context, subID int, change models.StatusChange) (result models.SubscriptionDB, err error) {
+	defer s.m.track("subscription", "ChangeStatus", time.Now(), &err)
+	return s.next.ChangeStatus(ctx, subID, change)
+}
+
+func (s subscriptionStore) ExpireEnded(ctx context.Context, month time.Time) (result []models.SubscriptionDB, err error) {
+	defer s.m.track("subscription", "ExpireEnded", time.Now(), &err)
+	return s.next.ExpireEnded(ctx, month)
+}
+
+func (s subscriptionStore) CountByStatus(ctx context.Context) (result []models.StatusCountDB, err error) {
+	defer s.m.track("subscription", "CountByStatus", time.Now(), &err)
+	return s.next.CountByStatus(ctx)
+}
+
+// outboxStore records call durations of postgres.OutboxStore
+type outboxStore struct {
+	next postgres.OutboxStore
+	m    *Metrics
+}
+
+func (s outboxStore) Claim(ctx context.Context, limit int, lease time.Duration) (result []models.OutboxEvent, err error) {
+	defer s.m.track("outbox", "Claim", time.Now(), &err)
+	return s.next.Claim(ctx, limit, lease)
+}
+
+func (s outboxStore) MarkDelivered(ctx context.Context, event models.OutboxEvent) (err error) {
+	defer s.m.track("outbox", "MarkDelivered", time.Now(), &err)
+	return s.next.MarkDelivered(ctx, event)
+}
+
+func (s outboxStore) MarkFailed(ctx context.Context, event models.OutboxEvent, cause string, retryAt *time.Time) (err error) {
+	defer s.m.track("outbox", "MarkFailed", time.Now(), &err)
+	return s.next.MarkFailed(ctx, event, cause, retryAt)
+}
+
+// budgetStore records call durations of postgres.BudgetStore
+type budgetStore struct {
+	next postgres.BudgetStore
+	m    *Metrics
+}
+
+func (s budgetStore) Create(ctx context.Context, budget models.BudgetDB) (result int, err error) {
+	defer s.m.track("budget", "Create", time.Now(), &err)
+	return s.next.Create(ctx, budget)
+}
+
+func (s budgetStore) GetByUser(ctx context.Context, userID string) (result []models.BudgetDB, err error) {
+	defer s.m.track("budget", "GetByUser", time.Now(), &err)
+	return s.next.GetByUser(ctx, userID)
+}
+
+func (s budgetStore) GetById(ctx context.Context, userID string, budgetID int) (result models.BudgetDB, err error) {
+	defer s.m.track("budget", "GetById", time.Now(), &err)
+	return s.next.GetById(ctx, userID, budgetID)
+}
+
+func (s budgetStore) Delete(ctx context.Context, userID string, budgetID int) (err error) {
+	defer s.m.track("budget", "Delete", time.Now(), &err)
+	return s.next.Delete(ctx, userID, budgetID)
+}
+
+func (s budgetStore) GetUserIDs(ctx context.Context) (result []string, err error) {
+	defer s.m.track("budget", "GetUserIDs", time.Now(), &err)
+	return s.next.GetUserIDs(ctx)
+}
+
+func (s budgetStore) Evaluate(ctx context.Context, userID string, month time.Time) (err error) {
+	defer s.m.track("budget", "Evaluate", time.Now(), &err)
+	return s.next.Evaluate(ctx, userID, month)
+}
+
+// pauseStore records call durations of postgres.PauseStore
+type pauseStore struct {
+	next postgres.PauseStore
+	m    *Metrics
+}
+
+func (s pauseStore) Pause(ctx context.Context, subID int, from time.Time, expectedStatus string, newStatus string) (result models.PauseDB, err error) {
+	defer s.m.track("pause", "Pause", time.Now(), &err)
+	return s.next.Pause(ctx, subID, from, expectedStatus, newStatus)
+}
+
+func (s pauseStore) Resume(ctx context.Context, subID int, from time.Time, expectedStatus string, newStatus string) (result models.PauseDB, err error) {
+	defer s.m.track("pause", "Resume", time.Now(), &err)
+	return s.next.Resume(ctx, subID, from, expectedStatus, newStatus)
+}
+
+func (s pauseStore) SyncStatuses(ctx context.Context, month time.Time) (result int, err error) {
+	defer s.m.track("pause", "SyncStatuses", time.Now(), &err)
+	return s.next.SyncStatuses(ctx, month)
+}
+
+// memberStore records call durations of postgres.MemberStore
+type memberStore struct {
+	next postgres.MemberStore
+	m    *Metrics
+}
+
+func (s memberStore) Add(ctx context.Context, member models.MemberDB) (result models.MemberDB, err error) {
+	defer s.m.track("member", "Add", time.Now(), &err)
+	return s.next.Add(ctx, member)
+}
+
+func (s memberStore) GetBySubscription(ctx context.Context, subID int) (result []models.MemberDB, err error) {
+	defer s.m.track("member", "GetBySubscription", time.Now(), &err)
+	return s.next.GetBySubscription(ctx, subID)
+}
+
+func (s memberStore) Remove(ctx context.Context, subID int, userID string) (err error) {
+	defer s.m.track("member", "Remove", time.Now(), &err)
+	return s.next.Remove(ctx, subID, userID)
+}
+
+func (s memberStore) GetDebts(ctx context.Context, filter models.SettlementFilter) (result []models.DebtDB, err error) {
+	defer s.m.track("member", "GetDebts", time.Now(), &err)
+	return s.next.GetDebts(ctx, filter)
+}
+
+// userStore records call durations of postgres.UserStore
+type userStore struct {
+	next postgres.UserStore
+	m    *Metrics
+}
+
+func (s userStore) Create(ctx context.Context, user models.UserDB) (result models.UserDB, err error) {
+	defer s.m.track("user", "Create", time.Now(), &err)
+	return s.next.Create(ctx, user)
+}
+
+func (s userStore) GetById(ctx context.Context, userID string) (result models.UserDB, err error) {
+	defer s.m.track("user", "GetById", time.Now(), &err)
+	return s.next.GetById(ctx, userID)
+}
+
+func (s userStore) Update(ctx context.Context, userID string, input models.UpdateUser) (err error) {
+	defer s.m.track("user", "Update", time.Now(), &err)
+	return s.next.Update(ctx, userID, input)
+}
+
+func (s userStore) Delete(ctx context.Context, userID string, cascade bool) (err error) {
+	defer s.m.track("user", "Delete", time.Now(), &err)
+	return s.next.Delete(ctx, userID, cascade)
+}
+
+// organizationStore records call durations of postgres.OrganizationStore
+type organizationStore struct {
+	next postgres.OrganizationStore
+	m    *Metrics
+}
+
+func (s organizationStore) Create(ctx context.Context, name string, ownerID string) (result models.OrganizationDB, err error) {
+	defer s.m.track("organization", "Create", time.Now(), &err)
+	return s.next.Create(ctx, name, ownerID)
+}
+
+func (s organizationStore) GetById(ctx context.Context, orgID int) (result models.OrganizationDB, err error) {
+	defer s.m.track("organization", "GetById", time.Now(), &err)
+	return s.next.GetById(ctx, orgID)
+}
+
+func (s organizationStore) Update(ctx context.Context, orgID int, name string) (err error) {
+	defer s.m.track("organization", "Update", time.Now(), &err)
+	return s.next.Update(ctx, orgID, name)
+}
+
+func (s organizationStore) Delete(ctx context.Context, orgID int) (err error) {
+	defer s.m.track("organization", "Delete", time.Now(), &err)
+	return s.next.Delete(ctx, orgID)
+}
+
+func (s organizationStore) GetRole(ctx context.Context, orgID int, userID string) (result string, err error) {
+	defer s.m.track("organization", "GetRole", time.Now(), &err)
+	return s.next.GetRole(ctx, orgID, userID)
+}
+
+func (s organizationStore) GetMembers(ctx context.Context, orgID int) (result []models.OrganizationMemberDB, err error) {
+	defer s.m.track("organization", "GetMembers", time.Now(), &err)
+	return s.next.GetMembers(ctx, orgID)
+}
+
+func (s organizationStore) SetMember(ctx context.Context, orgID int, userID string, role string) (err error) {
+	defer s.m.track("organization", "SetMember", time.Now(), &err)
+	return s.next.SetMember(ctx, orgID, userID, role)
+}
+
+func (s organizationStore) RemoveMember(ctx context.Context, orgID int, userID string) (err error) {
+	defer s.m.track("organization", "RemoveMember", time.Now(), &err)
+	return s.next.RemoveMember(ctx, orgID, userID)
+}
+
+func (s organizationStore) SetSubscriptionOrganization(ctx context.Context, subID int, orgID *int) (err error) {
+	defer s.m.track("organization", "SetSubscriptionOrganization", time.Now(), &err)
+	return s.next.SetSubscriptionOrganization(ctx, subID, orgID)
+}
+
+type rateLimitStore struct {
+	next postgres.RateLimitStore
+	m    *Metrics
+}
+
+func (s rateLimitStore) Take(ctx context.Context, key string, rate float64, burst int) (tokens float64, allowed bool, err error) {
+	defer s.m.track("rate_limit", "Take", time.Now(), &err)
+	return s.next.Take(ctx, key, rate, burst)
+}
+
+func (s rateLimitStore) DeleteIdle(ctx context.Context, idleFor time.Duration) (result int64, err error) {
+	defer s.m.track("rate_limit", "DeleteIdle", time.Now(), &err)
+	return s.next.DeleteIdle(ctx, idleFor)
+}
+
+// spendStore records call durations of postgres.SpendStore
+type spendStore struct {
+	next postgres.SpendStore
+	m    *Metrics
+}
+
+func (s spendStore) Refresh(ctx context.Context, until time.Time) (result models.SpendRefresh, err error) {
+	defer s.m.track("spend", "Refresh", time.Now(), &err)
+	return s.next.Refresh(ctx, until)
+}
+
+func (s spendStore) Rebuild(ctx context.Context, until time.Time) (result int64, err error) {
+	defer s.m.track("spend", "Rebuild", time.Now(), &err)
+	return s.next.Rebuild(ctx, until)
+}
+
+func (s spendStore) Check(ctx context.Context, from, to time.Time) (result []models.SpendMismatchDB, err error) {
+	defer s.m.track("spend", "Check", time.Now(), &err)
+	return s.next.Check(ctx, from, to)
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// ChargeDB represents a single billed month of a subscription
+// Produced by expanding subscriptions over a period according to their billing cycle
+type ChargeDB struct {
+	SubscriptionID int       `db:"subscription_id"` // Charged subscription
+	UserID         string    `db:"user_id"`         // Owner of the subscription
+	ServiceName    string    `db:"service_name"`    // Name of the service
+	Month          time.Time `db:"month"`           // First day of the billed month
+	Amount         int       `db:"amount"`          // Charged amount
+}
+
+// ChargeFilter narrows down the charges returned for a period
+type ChargeFilter struct {
+	UserID *string   // Optional filter by user ID
+	From   time.Time // First month of the period (inclusive)
+	To     time.Time // Last month of the period (inclusive)
+}
+
+// Charge represents an expected charge in API responses
+// @Description Expected charge of a subscription
+type Charge struct {
+	SubscriptionID int    `json:"subscription_id"` // Charged subscription
+	UserID         string `json:"user_id"`         // Owner of the subscription
+	ServiceName    string `json:"service_name"`    // Name of the service
+	Month          string `json:"month"`           // Billed month in MM-YYYY format
+	Amount         int    `json:"amount"`          // Charged amount
+}
+
+// MonthTotal holds the total of all charges in a month
+// @Description Total charges of a month
+type MonthTotal struct {
+	Month string `json:"month"` // Month in MM-YYYY format
+	Total int    `json:"total"` // Sum of all charges in the month
+}
+
+// Forecast is the projected spend for upcoming months
+// @Description Spend forecast for upcoming months
+type Forecast struct {
+	From     string       `json:"from"`     // First forecast month in MM-YYYY format
+	To       string       `json:"to"`       // Last forecast month in MM-YYYY format
+	Currency string       `json:"currency"` // Currency code of all amounts
+	Total    int          `json:"total"`    // Sum of all expected charges
+	Months   []MonthTotal `json:"months"`   // Per-month totals, including months without charges
+	Charges  []Charge     `json:"charges"`  // Expected charges ordered by month
+}
+
+// SpendRefresh describes the changes applied to the monthly spend aggregate by a refresh
+type SpendRefresh struct {
+	Rebuilt   bool // The aggregate had not been built and was calculated from scratch
+	Months    int  // Months appended to keep up with the horizon
+	Refreshed int  // Users and services recalculated after changes
+}
+
+// SpendMismatchDB is a month whose aggregated spend differs from the spend calculated from raw data
+type SpendMismatchDB struct {
+	UserID      string    `db:"user_id"`      // User paying the shares
+	ServiceName string    `db:"service_name"` // Name of the service
+	Month       time.Time `db:"month"`        // First day of the month
+	Aggregated  int       `db:"aggregated"`   // Amount stored in the aggregate
+	Actual      int       `db:"actual"`       // Amount calculated from subscriptions
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Event types published for budgets
+const (
+	EventBudgetBreached = "budget.breached"
+)
+
+// Aggregate type used for budget events in the outbox
+const (
+	AggregateBudget = "budget"
+)
+
+// Budget represents a monthly spending limit for API requests
+// @Description Monthly spending limit
+type Budget struct {
+	Amount   int     `json:"amount" binding:"required"` // Monthly limit (required)
+	Currency string  `json:"currency"`                  // Currency code, defaults to RUB
+	Category *string `json:"category"`                  // Optional subscription category the limit applies to
+}
+
+// Validate checks the budget limit and normalizes the currency
+// Prices are stored in a single currency, so budgets in other currencies cannot be evaluated
+func (b *Budget) Validate() error {
+	if b.Amount <= 0 {
+		return errors.New("budget amount must be positive")
+	}
+
+	if b.Currency == "" {
+		b.Currency = DefaultCurrency
+	}
+	if b.Currency != DefaultCurrency {
+		return errors.New("unsupported budget currency, only " + DefaultCurrency + " is supported")
+	}
+
+	return nil
+}
+
+// BudgetDB represents the budget model for database operations
+type BudgetDB struct {
+	Id            int        `json:"id" db:"id"`                         // Unique identifier
+	UserID        string     `json:"user_id" db:"user_id"`               // Owner of the budget
+	Amount        int        `json:"amount" db:"amount"`                 // Monthly limit
+	Currency      string     `json:"currency" db:"currency"`             // Currency code
+	Category      *string    `json:"category" db:"category"`             // Optional category filter
+	BreachedMonth *time.Time `json:"breached_month" db:"breached_month"` // Month the limit was last exceeded and alerted (nil when within the limit)
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`         // Creation time
+}
+
+// BudgetStatus describes how much of a budget is used in the current month
+// @Description Budget usage for the current month
+type BudgetStatus struct {
+	Id        int     `json:"id"`        // Budget identifier
+	UserID    string  `json:"user_id"`   // Owner of the budget
+	Amount    int     `json:"amount"`    // Monthly limit
+	Currency  string  `json:"currency"`  // Currency code
+	Category  *string `json:"category"`  // Optional category filter
+	Month     string  `json:"month"`     // Evaluated month in MM-YYYY format
+	Used      int     `json:"used"`      // Projected spend for the month
+	Remaining int     `json:"remaining"` // Amount left before the limit is reached
+	Breached  bool    `json:"breached"`  // True when the projected spend exceeds the limit
+}
+
+// BudgetAlert is the payload of the "budget.breached" event
+type BudgetAlert struct {
+	BudgetID int     `json:"budget_id"`
+	UserID   string  `json:"user_id"`
+	Amount   int     `json:"amount"`
+	Currency string  `json:"currency"`
+	Category *string `json:"category"`
+	Month    string  `json:"month"`
+	Used     int     `json:"used"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// OverlapFilter describes a subscription period checked against existing subscriptions
+// of the same user and service
+type OverlapFilter struct {
+	UserID      string     // Owner of the subscription
+	ServiceName string     // Service name, compared case-insensitively
+	StartDate   time.Time  // First billed month
+	FinishDate  *time.Time // Exclusive end month (nil for open-ended)
+	ExcludeID   int        // Subscription to ignore (the one being updated), 0 for none
+}
+
+// DuplicateGroup lists overlapping subscriptions of one user for the same service
+// @Description Overlapping subscriptions of one user for the same service
+type DuplicateGroup struct {
+	UserID        string         `json:"user_id"`       // Owner of the subscriptions
+	ServiceName   string         `json:"service_name"`  // Service name shared by the subscriptions
+	Subscriptions []Subscription `json:"subscriptions"` // Subscriptions with overlapping periods
+}
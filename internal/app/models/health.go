@@ -0,0 +1,24 @@
+package models
+
+// Health statuses reported by the liveness and readiness probes
+const (
+	HealthOK       = "ok"
+	HealthFail     = "fail"
+	HealthReady    = "ready"
+	HealthNotReady = "not ready"
+)
+
+// HealthReport is the result of the readiness probe
+// @Description Readiness status with a breakdown per dependency check
+type HealthReport struct {
+	Status string                 `json:"status"` // ready when every check passed, not ready otherwise
+	Checks map[string]HealthCheck `json:"checks"` // Result of each check by name
+}
+
+// HealthCheck is the result of a single readiness check
+// @Description Result of a readiness check
+type HealthCheck struct {
+	Status     string `json:"status"`          // ok or fail
+	Error      string `json:"error,omitempty"` // Failure reason
+	DurationMs int64  `json:"duration_ms"`     // Time the check took
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types published when the members of a shared subscription change
+const (
+	EventMemberAdded   = "subscription.member_added"
+	EventMemberRemoved = "subscription.member_removed"
+)
+
+// Member represents a user sharing the cost of a subscription in API requests/responses
+// @Description Member of a shared subscription
+type Member struct {
+	UserID      string `json:"user_id" binding:"required"` // Member user identifier (required)
+	ShareWeight *int   `json:"share_weight"`               // Weight of the member's part of the cost left after fixed amounts
+	FixedAmount *int   `json:"fixed_amount"`               // Fixed amount the member pays per charge
+}
+
+// Validate ensures the member pays either a positive weight or a positive fixed amount
+func (m Member) Validate() error {
+	if (m.ShareWeight == nil) == (m.FixedAmount == nil) {
+		return errors.New("exactly one of share weight and fixed amount must be set")
+	}
+	if m.ShareWeight != nil && *m.ShareWeight <= 0 {
+		return errors.New("share weight must be positive")
+	}
+	if m.FixedAmount != nil && *m.FixedAmount <= 0 {
+		return errors.New("fixed amount must be positive")
+	}
+	return nil
+}
+
+// MemberDB represents a member of a shared subscription in the database
+// JSON tags define the payload of member events written to the outbox
+type MemberDB struct {
+	Id             int       `json:"id" db:"id"`                           // Unique identifier
+	SubscriptionID int       `json:"subscription_id" db:"subscription_id"` // Shared subscription
+	UserID         uuid.UUID `json:"user_id" db:"user_id"`                 // Member user identifier
+	ShareWeight    *int      `json:"share_weight" db:"share_weight"`       // Weight of the member's part
+	FixedAmount    *int      `json:"fixed_amount" db:"fixed_amount"`       // Fixed amount per charge
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`           // Creation time
+}
+
+// SettlementFilter narrows down the debts returned for a period
+type SettlementFilter struct {
+	UserID *string   // Optional filter by debtor or creditor
+	From   time.Time // First month of the period (inclusive)
+	To     time.Time // Last month of the period (inclusive)
+}
+
+// DebtDB is the total a member owes the owner of shared subscriptions in a month
+type DebtDB struct {
+	Month    time.Time `db:"month"`    // First day of the month
+	Debtor   string    `db:"debtor"`   // Member paying a share
+	Creditor string    `db:"creditor"` // Owner who paid the subscription
+	Amount   int       `db:"amount"`   // Sum of the member's shares
+}
+
+// Settlement is the net amount one user owes another for a month
+// @Description Net debt between two users for a month
+type Settlement struct {
+	Month      string `json:"month"`        // Month in MM-YYYY format
+	FromUserID string `json:"from_user_id"` // User who owes the amount
+	ToUserID   string `json:"to_user_id"`   // User who paid and is owed the amount
+	Amount     int    `json:"amount"`       // Net amount owed
+	Currency   string `json:"currency"`     // Currency code of the amount
+}
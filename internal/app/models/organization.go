@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles of organization members, from the most to the least privileged
+const (
+	RoleOwner  = "owner"  // Manages everything, including other owners and the organization itself
+	RoleAdmin  = "admin"  // Manages members and organization subscriptions
+	RoleMember = "member" // Attaches and changes organization subscriptions
+	RoleViewer = "viewer" // Reads organization data only
+)
+
+// RoleRank orders roles by privilege, higher ranks include the permissions of lower ones
+// Returns 0 for unknown roles
+func RoleRank(role string) int {
+	switch role {
+	case RoleOwner:
+		return 4
+	case RoleAdmin:
+		return 3
+	case RoleMember:
+		return 2
+	case RoleViewer:
+		return 1
+	}
+	return 0
+}
+
+// Event types published for organizations
+const (
+	EventOrganizationCreated       = "organization.created"
+	EventOrganizationUpdated       = "organization.updated"
+	EventOrganizationDeleted       = "organization.deleted"
+	EventOrganizationMemberChanged = "organization.member_changed"
+	EventOrganizationMemberRemoved = "organization.member_removed"
+)
+
+// Aggregate type used for organization events in the outbox
+const (
+	AggregateOrganization = "organization"
+)
+
+// Organization represents an organization for API requests/responses
+// @Description Organization information
+type Organization struct {
+	Id   int    `json:"id"`                      // Unique identifier (output only)
+	Name string `json:"name" binding:"required"` // Organization name (required)
+}
+
+// OrganizationDB represents the organization model for database operations
+// JSON tags define the payload of organization events written to the outbox
+type OrganizationDB struct {
+	Id        int       `json:"id" db:"id"`                 // Unique identifier
+	Name      string    `json:"name" db:"name"`             // Organization name
+	CreatedAt time.Time `json:"created_at" db:"created_at"` // Creation time
+}
+
+// OrganizationMember represents a member of an organization in API requests/responses
+// @Description Organization member with role
+type OrganizationMember struct {
+	UserID string `json:"user_id"`                 // Member user identifier (output only, taken from the path on writes)
+	Role   string `json:"role" binding:"required"` // Role: owner, admin, member or viewer
+}
+
+// Validate ensures the role is one of the supported roles
+func (m OrganizationMember) Validate() error {
+	if RoleRank(m.Role) == 0 {
+		return errors.New("invalid role, expected owner, admin, member or viewer")
+	}
+	return nil
+}
+
+// OrganizationMemberDB represents a member of an organization in the database
+type OrganizationMemberDB struct {
+	OrganizationID int       `json:"organization_id" db:"organization_id"` // Organization
+	UserID         uuid.UUID `json:"user_id" db:"user_id"`                 // Member user identifier
+	Role           string    `json:"role" db:"role"`                       // Member role
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`           // Creation time
+}
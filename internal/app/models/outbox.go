@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Event types published through the transactional outbox
+const (
+	EventSubscriptionCreated = "subscription.created"
+	EventSubscriptionUpdated = "subscription.updated"
+	EventSubscriptionDeleted = "subscription.deleted"
+	EventTrialConverted      = "subscription.trial_converted"
+)
+
+// Aggregate types used to group outbox events by the entity they describe
+const (
+	AggregateSubscription = "subscription"
+)
+
+// OutboxEvent represents an event stored in the outbox table
+// Events are written in the same transaction as the data change they describe
+type OutboxEvent struct {
+	Id            int64     `db:"id"`             // Unique identifier (defines delivery order)
+	AggregateType string    `db:"aggregate_type"` // Type of the entity the event belongs to
+	AggregateID   string    `db:"aggregate_id"`   // Identifier of the entity the event belongs to
+	EventType     string    `db:"event_type"`     // Event name, e.g. "subscription.created"
+	Payload       []byte    `db:"payload"`        // JSON-encoded event body
+	CreatedAt     time.Time `db:"created_at"`     // Time the event was recorded
+	Attempts      int       `db:"attempts"`       // Number of failed delivery attempts
+	ClaimedUntil  time.Time `db:"claimed_until"`  // End of the relay's lease, identifies the claim
+}
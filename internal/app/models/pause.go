@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Event types published when a subscription is paused or resumed
+const (
+	EventSubscriptionPaused  = "subscription.paused"
+	EventSubscriptionResumed = "subscription.resumed"
+)
+
+// PauseInput is the optional request body of the pause and resume endpoints
+// @Description Month the pause or resume takes effect
+type PauseInput struct {
+	From string `json:"from"` // Month in MM-YYYY format, defaults to the current month
+}
+
+// PauseDB represents a pause interval of a subscription in the database
+// JSON tags define the payload of pause events written to the outbox
+type PauseDB struct {
+	Id             int        `json:"id" db:"id"`                           // Unique identifier
+	SubscriptionID int        `json:"subscription_id" db:"subscription_id"` // Paused subscription
+	PausedFrom     time.Time  `json:"paused_from" db:"paused_from"`         // First month without charges
+	ResumedFrom    *time.Time `json:"resumed_from" db:"resumed_from"`       // First charged month after the pause (nil while paused)
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`           // Creation time
+}
@@ -0,0 +1,13 @@
+package models
+
+// StatusCountDB is the number of subscriptions in a lifecycle status
+type StatusCountDB struct {
+	Status string `db:"status"`
+	Count  int    `db:"count"`
+}
+
+// SubscriptionStats summarizes subscriptions for business metrics
+type SubscriptionStats struct {
+	ByStatus     map[string]int // Number of subscriptions per lifecycle status
+	MonthlySpend int            // Total charged for all subscriptions in the current month
+}
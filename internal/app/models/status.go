@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Lifecycle statuses of a subscription
+const (
+	StatusTrial     = "trial"     // Free trial, nothing is charged yet
+	StatusActive    = "active"    // Regularly billed
+	StatusPaused    = "paused"    // Has an open pause
+	StatusCancelled = "cancelled" // Cancelled by the user, billed until the end of the cancellation month
+	StatusExpired   = "expired"   // Reached its finish date
+)
+
+// Event types published for lifecycle transitions
+const (
+	EventSubscriptionCancelled = "subscription.cancelled"
+	EventSubscriptionExpired   = "subscription.expired"
+)
+
+// CancelInput is the request body of the cancel endpoint
+// @Description Subscription cancellation data
+type CancelInput struct {
+	Reason string `json:"reason"` // Optional free-form cancellation reason
+}
+
+// StatusChange describes a lifecycle transition applied by the repository
+type StatusChange struct {
+	From        string     // Status the subscription is expected to have
+	To          string     // New status
+	CancelledAt *time.Time // Cancellation date (cancel only)
+	Reason      *string    // Cancellation reason (cancel only)
+	FinishDate  *time.Time // New exclusive finish date (cancel only)
+	EventType   string     // Outbox event written together with the change
+}
+
+// SubscriptionListFilter narrows down the subscriptions list
+type SubscriptionListFilter struct {
+	Status         *string // Optional filter by lifecycle status
+	UserID         *string // Optional filter by owner or member
+	OrganizationID *int    // Optional filter by organization
+	Personal       bool    // Leave out subscriptions paid by an organization
+}
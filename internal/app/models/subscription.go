@@ -7,27 +7,73 @@ import (
 	"github.com/google/uuid"
 )
 
+// DefaultCurrency is the currency all subscription prices are stored in
+const DefaultCurrency = "RUB"
+
+// Supported billing cycles of a subscription
+const (
+	BillingMonthly   = "monthly"
+	BillingQuarterly = "quarterly"
+	BillingYearly    = "yearly"
+)
+
+// BillingCycleMonths returns the number of months between two charges of the cycle
+// Returns 0 for unknown cycles
+func BillingCycleMonths(cycle string) int {
+	switch cycle {
+	case BillingMonthly:
+		return 1
+	case BillingQuarterly:
+		return 3
+	case BillingYearly:
+		return 12
+	}
+	return 0
+}
+
 // Subscription represents the subscription model for API requests/responses
 // Used for JSON marshaling/unmarshaling with string-based date fields
 // @Description Subscription information
 type Subscription struct {
-	Id          int    `json:"id" db:"id"`                      // Unique identifier
-	ServiceName string `json:"service_name" binding:"required"` // Name of the service (required)
-	Price       int    `json:"price" binding:"required"`        // Subscription price (required)
-	UserID      string `json:"user_id" binding:"required"`      // User identifier as string (required)
-	StartDate   string `json:"start_date" binding:"required"`   // Start date in string format (required)
-	FinishDate  string `json:"finish_date"`                     // End date as timestamp
+	Id                 int    `json:"id" db:"id"`                      // Unique identifier
+	ServiceName        string `json:"service_name" binding:"required"` // Name of the service (required)
+	Price              int    `json:"price" binding:"required"`        // Subscription price (required)
+	UserID             string `json:"user_id" binding:"required"`      // User identifier as string (required)
+	StartDate          string `json:"start_date" binding:"required"`   // Start date in string format (required)
+	FinishDate         string `json:"finish_date"`                     // Optional end date in MM-YYYY format (exclusive, empty for open-ended)
+	Category           string `json:"category"`                        // Optional spending category used by budgets
+	BillingCycle       string `json:"billing_cycle"`                   // Billing cadence: monthly (default), quarterly or yearly
+	TrialEnd           string `json:"trial_end"`                       // Optional last free month in MM-YYYY format
+	PriceAfterTrial    *int   `json:"price_after_trial"`               // Optional price charged once the trial ends (defaults to price)
+	Paused             bool   `json:"paused"`                          // Whether billing is paused in the current month (output only)
+	PausedFrom         string `json:"paused_from"`                     // Start month of the current or scheduled pause (output only)
+	Status             string `json:"status"`                          // Lifecycle status: trial, active, paused, cancelled or expired (output only)
+	CancelledAt        string `json:"cancelled_at"`                    // Cancellation date in DD-MM-YYYY format (output only)
+	CancellationReason string `json:"cancellation_reason"`             // Reason given on cancellation (output only)
+	OrganizationID     *int   `json:"organization_id"`                 // Organization paying for the subscription (output only)
+	RejectOverlaps     bool   `json:"-"`                               // Refuse to store a period overlapping the same service (set by the service layer)
 }
 
 // SubscriptionDB represents the subscription model for database operations
 // Uses proper data types for database storage (UUID, time.Time)
+// JSON tags define the payload of subscription events written to the outbox
 type SubscriptionDB struct {
-	Id          int       `db:"id"`           // Unique identifier
-	ServiceName string    `db:"service_name"` // Name of the service
-	Price       int       `db:"price"`        // Subscription price
-	UserID      uuid.UUID `db:"user_id"`      // User identifier as UUID
-	StartDate   time.Time `db:"start_date"`   // Start date as timestamp
-	FinishDate  time.Time `db:"finish_date"`  // End date as timestamp
+	Id                 int        `json:"id" db:"id"`                                   // Unique identifier
+	ServiceName        string     `json:"service_name" db:"service_name"`               // Name of the service
+	Price              int        `json:"price" db:"price"`                             // Subscription price
+	UserID             uuid.UUID  `json:"user_id" db:"user_id"`                         // User identifier as UUID
+	StartDate          time.Time  `json:"start_date" db:"start_date"`                   // Start date as timestamp
+	FinishDate         *time.Time `json:"finish_date" db:"finish_date"`                 // End date as timestamp (nil for open-ended)
+	Category           *string    `json:"category" db:"category"`                       // Optional spending category
+	BillingCycle       string     `json:"billing_cycle" db:"billing_cycle"`             // Billing cadence
+	TrialEnd           *time.Time `json:"trial_end" db:"trial_end"`                     // Last free month (nil without trial)
+	PriceAfterTrial    *int       `json:"price_after_trial" db:"price_after_trial"`     // Price charged after the trial
+	TrialConvertedAt   *time.Time `json:"trial_converted_at" db:"trial_converted_at"`   // When the trial was converted to paid
+	PausedFrom         *time.Time `json:"paused_from" db:"paused_from"`                 // Start of the open pause (only filled by queries joining pauses)
+	Status             string     `json:"status" db:"status"`                           // Lifecycle status
+	CancelledAt        *time.Time `json:"cancelled_at" db:"cancelled_at"`               // Cancellation date
+	CancellationReason *string    `json:"cancellation_reason" db:"cancellation_reason"` // Reason given on cancellation
+	OrganizationID     *int       `json:"organization_id" db:"organization_id"`         // Organization paying for the subscription
 }
 
 // UpdateSubscription defines the structure for subscription update requests
@@ -35,15 +81,22 @@ type SubscriptionDB struct {
 // This allows for partial updates (PATCH semantics) where only provided fields are updated
 // @Description Subscription update data
 type UpdateSubscription struct {
-	Price     *int    `json:"price" `     // Optional new price value (pointer allows nil for no update)
-	StartDate *string `json:"start_date"` // Optional new start date in "MM-YYYY" format
+	Price           *int    `json:"price" `            // Optional new price value (pointer allows nil for no update)
+	StartDate       *string `json:"start_date"`        // Optional new start date in "MM-YYYY" format
+	Category        *string `json:"category"`          // Optional new category, empty string clears it
+	FinishDate      *string `json:"finish_date"`       // Optional new end date in "MM-YYYY" format, empty string makes it open-ended
+	BillingCycle    *string `json:"billing_cycle"`     // Optional new billing cadence
+	TrialEnd        *string `json:"trial_end"`         // Optional new last free month in "MM-YYYY" format, empty string removes the trial
+	PriceAfterTrial *int    `json:"price_after_trial"` // Optional new price charged after the trial
+	RejectOverlaps  bool    `json:"-"`                 // Refuse to store a period overlapping the same service (set by the service layer)
 }
 
 // Validate ensures the update request contains at least one field to update
 // Prevents empty update operations that would make no changes to the resource
 func (i UpdateSubscription) Validate() error {
 	// Check that at least one field is provided for update
-	if i.Price == nil && i.StartDate == nil {
+	if i.Price == nil && i.StartDate == nil && i.Category == nil && i.FinishDate == nil && i.BillingCycle == nil &&
+		i.TrialEnd == nil && i.PriceAfterTrial == nil {
 		return errors.New("update structure has no values")
 	}
 
@@ -68,8 +121,11 @@ type Period struct {
 
 // Filters contains optional criteria to narrow down subscription summary
 type Filters struct {
-	UserID      *string `json:"user_id"`      // Optional filter by user ID
-	ServiceName *string `json:"service_name"` // Optional filter by service name
+	UserID         *string `json:"user_id"`      // Optional filter by user ID
+	ServiceName    *string `json:"service_name"` // Optional filter by service name
+	Category       *string `json:"category"`     // Optional filter by category
+	Status         *string `json:"status"`       // Optional filter by lifecycle status
+	OrganizationID *int    `json:"-"`            // Organization filter, set only by the organization summary
 }
 
 // SubscriptionFilterDB is the database representation of subscription filters
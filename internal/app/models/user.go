@@ -0,0 +1,91 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types published for users
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+)
+
+// Aggregate type used for user events in the outbox
+const (
+	AggregateUser = "user"
+)
+
+// DefaultTimezone is used for users that did not choose a timezone
+const DefaultTimezone = "UTC"
+
+// User represents a user for API requests/responses
+// @Description User information
+type User struct {
+	Id          string `json:"id"`           // User identifier, generated when omitted on creation
+	DisplayName string `json:"display_name"` // Name shown to other members of shared subscriptions
+	Timezone    string `json:"timezone"`     // IANA timezone, defaults to UTC
+	Currency    string `json:"currency"`     // Preferred currency code, defaults to RUB
+}
+
+// Validate checks the user preferences and fills in defaults
+func (u *User) Validate() error {
+	if u.Timezone == "" {
+		u.Timezone = DefaultTimezone
+	}
+	if u.Currency == "" {
+		u.Currency = DefaultCurrency
+	}
+	return validateUserPreferences(u.Timezone, u.Currency)
+}
+
+// UserDB represents the user model for database operations
+// JSON tags define the payload of user events written to the outbox
+type UserDB struct {
+	Id          uuid.UUID `json:"id" db:"id"`                     // Unique identifier
+	DisplayName string    `json:"display_name" db:"display_name"` // Display name
+	Timezone    string    `json:"timezone" db:"timezone"`         // IANA timezone
+	Currency    string    `json:"currency" db:"currency"`         // Preferred currency code
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`     // Creation time
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`     // Last update time
+}
+
+// UpdateUser defines the structure for user update requests
+// Uses pointer fields to distinguish between missing values and zero values
+// @Description User update data
+type UpdateUser struct {
+	DisplayName *string `json:"display_name"` // Optional new display name
+	Timezone    *string `json:"timezone"`     // Optional new IANA timezone
+	Currency    *string `json:"currency"`     // Optional new preferred currency code
+}
+
+// Validate ensures the update request contains at least one valid field to update
+func (i UpdateUser) Validate() error {
+	if i.DisplayName == nil && i.Timezone == nil && i.Currency == nil {
+		return errors.New("update structure has no values")
+	}
+
+	timezone, currency := DefaultTimezone, DefaultCurrency
+	if i.Timezone != nil {
+		timezone = *i.Timezone
+	}
+	if i.Currency != nil {
+		currency = *i.Currency
+	}
+	return validateUserPreferences(timezone, currency)
+}
+
+// validateUserPreferences checks that the timezone is known and the currency is supported
+// Prices are stored in a single currency, so other currencies cannot be displayed yet
+func validateUserPreferences(timezone, currency string) error {
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return errors.New("unknown timezone " + timezone)
+	}
+	if currency != DefaultCurrency {
+		return errors.New("unsupported currency, only " + DefaultCurrency + " is supported")
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/sirupsen/logrus"
+)
+
+// envelope is the JSON document sent to external systems for every outbox event
+type envelope struct {
+	Id            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// newEnvelope wraps an outbox event into its wire representation
+func newEnvelope(event models.OutboxEvent) envelope {
+	return envelope{
+		Id:            event.Id,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		EventType:     event.EventType,
+		OccurredAt:    event.CreatedAt,
+		Payload:       json.RawMessage(event.Payload),
+	}
+}
+
+// LogPublisher writes events to the application log
+// Used when no external integration is configured
+type LogPublisher struct{}
+
+// NewLogPublisher creates a new log publisher instance
+func NewLogPublisher() *LogPublisher {
+	return &LogPublisher{}
+}
+
+// Publish logs the event with its identifying fields
+func (p *LogPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	logrus.WithFields(logrus.Fields{
+		"event_id":       event.Id,
+		"event_type":     event.EventType,
+		"aggregate_type": event.AggregateType,
+		"aggregate_id":   event.AggregateID,
+	}).Info("outbox event published")
+	return nil
+}
+
+// WebhookPublisher delivers events as JSON POST requests to a configured URL
+// Any non-2xx response is treated as a failure and the event is retried later
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookPublisher creates a new webhook publisher instance
+func NewWebhookPublisher(url string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Publish sends the event to the webhook endpoint
+// The event ID is passed in the Idempotency-Key header so receivers can drop duplicates
+func (p *WebhookPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	body, err := json.Marshal(newEnvelope(event))
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", strconv.FormatInt(event.Id, 10))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultInterval    = 5 * time.Second  // Default pause between outbox polls
+	DefaultBatchSize   = 100              // Default number of events claimed at once
+	DefaultLease       = 5 * time.Minute  // Time a claimed event is reserved for one relay
+	DefaultMaxAttempts = 10               // Failed deliveries after which an event is dead-lettered
+	DefaultBackoff     = 5 * time.Second  // Delay before the first retry, doubled on every failure
+	DefaultMaxBackoff  = 30 * time.Minute // Upper bound of the retry delay
+)
+
+// Publisher delivers outbox events to an external system (webhook, message bus, etc.)
+// Implementations must be idempotent-friendly: an event may be delivered more than once
+type Publisher interface {
+	Publish(ctx context.Context, event models.OutboxEvent) error
+}
+
+// Relay periodically moves pending events from the outbox table to a Publisher
+// Provides at-least-once delivery: events are marked delivered only after a successful publish.
+// Publishing happens outside any database transaction, failed events are retried with
+// exponential backoff and dead-lettered after DefaultMaxAttempts failures
+type Relay struct {
+	store       postgres.OutboxStore
+	publisher   Publisher
+	interval    time.Duration
+	batchSize   int
+	lease       time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+}
+
+// NewRelay creates a new outbox relay instance
+func NewRelay(store postgres.OutboxStore, publisher Publisher, interval time.Duration, batchSize int) *Relay {
+	return &Relay{
+		store:       store,
+		publisher:   publisher,
+		interval:    interval,
+		batchSize:   batchSize,
+		lease:       DefaultLease,
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+	}
+}
+
+// Run polls the outbox until the context is cancelled
+// Blocks the caller, so it is expected to be started in a separate goroutine
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain publishes batches until no event is due
+// Every claimed event ends up delivered, scheduled for a retry or dead-lettered,
+// so the loop stops once the remaining events wait for their next attempt
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		events, err := r.store.Claim(ctx, r.batchSize, r.lease)
+		if err != nil {
+			logrus.Errorf("outbox relay: %s", err.Error())
+			return
+		}
+		if len(events) == 0 {
+			return
+		}
+
+		for _, event := range events {
+			err := r.deliver(ctx, event)
+			if errors.Is(err, postgres.ErrLeaseLost) {
+				// Another relay owns the event now and records its own outcome
+				logrus.Warnf("outbox relay: %s", err.Error())
+				continue
+			}
+			if err != nil {
+				// The lease expires and the event is claimed again on a later tick
+				logrus.Errorf("outbox relay: %s", err.Error())
+				return
+			}
+		}
+	}
+}
+
+// deliver publishes one claimed event and records the outcome
+func (r *Relay) deliver(ctx context.Context, event models.OutboxEvent) error {
+	publishErr := r.publisher.Publish(ctx, event)
+	if publishErr == nil {
+		return r.store.MarkDelivered(ctx, event)
+	}
+
+	attempts := event.Attempts + 1
+	fields := logrus.Fields{"event_id": event.Id, "event_type": event.EventType, "attempts": attempts}
+	if attempts >= r.maxAttempts {
+		logrus.WithFields(fields).Errorf("outbox event dead-lettered: %s", publishErr.Error())
+		return r.store.MarkFailed(ctx, event, publishErr.Error(), nil)
+	}
+
+	retryAt := time.Now().Add(r.retryDelay(attempts))
+	logrus.WithFields(fields).Warnf("outbox event publish failed, retrying at %s: %s", retryAt.Format(time.RFC3339), publishErr.Error())
+	return r.store.MarkFailed(ctx, event, publishErr.Error(), &retryAt)
+}
+
+// retryDelay returns the backoff after the given number of failed attempts
+func (r *Relay) retryDelay(attempts int) time.Duration {
+	delay := r.backoff
+	for i := 1; i < attempts && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.maxBackoff {
+		delay = r.maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"time"
+)
+
+// maxKeyLength is the longest bucket key stored as is, the size of rate_limits.key
+// Longer keys are replaced by their SHA-256 so they fit every store
+const maxKeyLength = 255
+
+// Limit is a token bucket: Rate tokens are added per second up to Burst
+type Limit struct {
+	Rate  float64 // Sustained requests per second
+	Burst int     // Maximum requests allowed at once
+}
+
+// Window returns the time an empty bucket takes to refill completely
+func (l Limit) Window() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// Store keeps token buckets by key
+// Take refills the bucket, removes one token when available and returns the tokens left
+type Store interface {
+	Take(ctx context.Context, key string, rate float64, burst int) (tokens float64, allowed bool, err error)
+}
+
+// Result describes the state of a bucket after a request
+type Result struct {
+	Allowed    bool
+	Limit      int           // Burst of the bucket
+	Remaining  int           // Whole tokens left
+	Reset      time.Duration // Time until the bucket is full again
+	RetryAfter time.Duration // Time until the next token, set only for rejected requests
+}
+
+// Class groups routes sharing a limit; each client has a separate bucket per class
+type Class string
+
+const (
+	ClassRead    Class = "read"
+	ClassWrite   Class = "write"
+	ClassSummary Class = "summary"
+)
+
+// Limiter applies the limit of a route class to client keys using a store
+type Limiter struct {
+	store  Store
+	limits map[Class]Limit
+}
+
+// NewLimiter creates a limiter with the class limits from cfg backed by the store
+func NewLimiter(store Store, cfg Config) *Limiter {
+	return &Limiter{
+		store: store,
+		limits: map[Class]Limit{
+			ClassRead:    cfg.Read,
+			ClassWrite:   cfg.Write,
+			ClassSummary: cfg.Summary,
+		},
+	}
+}
+
+// Limit returns the limit of the class
+func (l *Limiter) Limit(class Class) Limit {
+	return l.limits[class]
+}
+
+// Allow takes a token from the bucket of the client key in the class
+func (l *Limiter) Allow(ctx context.Context, class Class, key string) (Result, error) {
+	limit := l.limits[class]
+
+	tokens, allowed, err := l.store.Take(ctx, bucketKey(class, key), limit.Rate, limit.Burst)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+
+	return result, nil
+}
+
+// bucketKey returns the store key of the client's bucket in the class
+func bucketKey(class Class, key string) string {
+	bucket := string(class) + ":" + key
+	if len(bucket) <= maxKeyLength {
+		return bucket
+	}
+	sum := sha256.Sum256([]byte(key))
+	return string(class) + ":sha256:" + hex.EncodeToString(sum[:])
+}
+
+// seconds converts a number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Max(0, s) * float64(time.Second))
+}
+
+// Supported bucket stores
+const (
+	StoreMemory   = "memory"   // Buckets per replica in process memory
+	StorePostgres = "postgres" // Buckets shared by all replicas in the database
+)
+
+// Config holds the limits of each route class and the bucket store
+type Config struct {
+	Enabled bool
+	Store   string // StoreMemory or StorePostgres
+	Read    Limit  // Plain reads
+	Write   Limit  // Requests changing data
+	Summary Limit  // Expensive aggregations such as totals and analytics
+}
+
+// ValidStore reports whether the store name is supported
+func ValidStore(store string) bool {
+	return store == StoreMemory || store == StorePostgres
+}
@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrBudgetNotFound is returned when the budget does not exist or belongs to another user
+var ErrBudgetNotFound = errors.New("budget not found")
+
+// BudgetRepository implements BudgetStore for PostgreSQL
+type BudgetRepository struct {
+	db *sqlx.DB
+}
+
+// NewBudgetRepository creates a new budget repository instance
+func NewBudgetRepository(db *sqlx.DB) *BudgetRepository {
+	return &BudgetRepository{db: db}
+}
+
+// Create inserts a new budget record and returns its ID
+func (r *BudgetRepository) Create(ctx context.Context, budget models.BudgetDB) (int, error) {
+	var budgetID int
+	query := fmt.Sprintf("INSERT INTO %s (user_id, amount, currency, category) VALUES ($1, $2, $3, $4) RETURNING id", budgetTable)
+	if err := r.db.GetContext(ctx, &budgetID, query, budget.UserID, budget.Amount, budget.Currency, budget.Category); err != nil {
+		if isConstraintViolation(err, "foreign_key_violation", "budgets_user_id_fkey") {
+			return 0, ErrUserNotFound
+		}
+		return 0, fmt.Errorf("failed to create budget: %w", err)
+	}
+
+	return budgetID, nil
+}
+
+// GetByUser returns all budgets of the given user ordered by creation
+func (r *BudgetRepository) GetByUser(ctx context.Context, userID string) ([]models.BudgetDB, error) {
+	var budgets []models.BudgetDB
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1 ORDER BY id", budgetTable)
+	err := r.db.SelectContext(ctx, &budgets, query, userID)
+
+	return budgets, err
+}
+
+// GetById returns a single budget that belongs to the given user
+func (r *BudgetRepository) GetById(ctx context.Context, userID string, budgetID int) (models.BudgetDB, error) {
+	var budget models.BudgetDB
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1 AND user_id = $2", budgetTable)
+	err := r.db.GetContext(ctx, &budget, query, budgetID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return budget, ErrBudgetNotFound
+	}
+
+	return budget, err
+}
+
+// Delete removes a budget that belongs to the given user
+func (r *BudgetRepository) Delete(ctx context.Context, userID string, budgetID int) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND user_id = $2", budgetTable)
+	result, err := r.db.ExecContext(ctx, query, budgetID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+// GetUserIDs returns the users that have at least one budget
+func (r *BudgetRepository) GetUserIDs(ctx context.Context) ([]string, error) {
+	var userIDs []string
+
+	query := fmt.Sprintf("SELECT DISTINCT user_id FROM %s ORDER BY user_id", budgetTable)
+	err := r.db.SelectContext(ctx, &userIDs, query)
+
+	return userIDs, err
+}
+
+// Evaluate recalculates the budgets of the user for the given month in one transaction
+// The budget rows are locked, so concurrent evaluations of the same user are serialized
+// and see each other's results. The spend is the user's share of the month's charges,
+// the same amount the summary endpoint reports. A budget crossing its limit gets a
+// "budget.breached" event in the outbox once per month; the breach is cleared when
+// the spend drops below the limit again
+func (r *BudgetRepository) Evaluate(ctx context.Context, userID string, month time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var budgets []models.BudgetDB
+	lockQuery := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1 ORDER BY id FOR UPDATE", budgetTable)
+	if err := tx.SelectContext(ctx, &budgets, lockQuery, userID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to retrieve budgets: %w", err)
+	}
+
+	usedQuery := fmt.Sprintf(`
+        SELECT COALESCE(SUM(c.amount), 0)
+        FROM (%s) c
+        WHERE c.user_id = $1 AND (c.category = $3 OR $3 IS NULL)
+    `, sharesSource("$2::date", "$2::date"))
+	updateQuery := fmt.Sprintf("UPDATE %s SET breached_month = $1 WHERE id = $2", budgetTable)
+
+	for _, budget := range budgets {
+		var used int
+		if err := tx.GetContext(ctx, &used, usedQuery, userID, month.Format("2006-01-02"), budget.Category); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to calculate budget %d usage: %w", budget.Id, err)
+		}
+
+		breached := used > budget.Amount
+		alerted := budget.BreachedMonth != nil && budget.BreachedMonth.Equal(month)
+
+		switch {
+		case breached && !alerted:
+			if _, err := tx.ExecContext(ctx, updateQuery, month.Format("2006-01-02"), budget.Id); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to update budget state: %w", err)
+			}
+
+			alert := models.BudgetAlert{
+				BudgetID: budget.Id,
+				UserID:   budget.UserID,
+				Amount:   budget.Amount,
+				Currency: budget.Currency,
+				Category: budget.Category,
+				Month:    month.Format("01-2006"),
+				Used:     used,
+			}
+			if err := insertOutboxEvent(ctx, tx, models.AggregateBudget, strconv.Itoa(budget.Id), models.EventBudgetBreached, alert); err != nil {
+				tx.Rollback()
+				return err
+			}
+		case !breached && budget.BreachedMonth != nil:
+			if _, err := tx.ExecContext(ctx, updateQuery, nil, budget.Id); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to update budget state: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// HealthRepository implements HealthStore for PostgreSQL
+type HealthRepository struct {
+	db *sqlx.DB
+}
+
+// NewHealthRepository creates a new health repository instance
+func NewHealthRepository(db *sqlx.DB) *HealthRepository {
+	return &HealthRepository{db: db}
+}
+
+// Ping verifies that a connection to the database can be established
+func (r *HealthRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// MigrationVersion returns the applied schema version recorded by golang-migrate
+// and whether the last migration failed halfway (dirty)
+func (r *HealthRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var migration struct {
+		Version uint `db:"version"`
+		Dirty   bool `db:"dirty"`
+	}
+
+	query := "SELECT version, dirty FROM schema_migrations LIMIT 1"
+	if err := r.db.GetContext(ctx, &migration, query); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+
+	return migration.Version, migration.Dirty, nil
+}
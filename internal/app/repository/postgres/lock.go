@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// tryAdvisoryLock takes a transaction-scoped Postgres advisory lock identified by name
+// Returns false without waiting when another session holds the lock
+// The lock is released automatically when the transaction commits or rolls back
+func tryAdvisoryLock(ctx context.Context, tx *sqlx.Tx, name string) (bool, error) {
+	var locked bool
+	if err := tx.GetContext(ctx, &locked, "SELECT pg_try_advisory_xact_lock(hashtext($1))", name); err != nil {
+		return false, fmt.Errorf("failed to acquire advisory lock %s: %w", name, err)
+	}
+	return locked, nil
+}
+
+// advisoryLock takes a transaction-scoped Postgres advisory lock identified by name,
+// waiting while another session holds it or until ctx is cancelled
+func advisoryLock(ctx context.Context, tx *sqlx.Tx, name string) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", name); err != nil {
+		return fmt.Errorf("failed to acquire advisory lock %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// Errors returned when a membership change conflicts with the stored members
+var (
+	ErrMemberExists   = errors.New("user is already a member of the subscription")
+	ErrMemberNotFound = errors.New("member not found")
+	ErrFixedAmounts   = errors.New("fixed amounts of members exceed the subscription price")
+)
+
+// checkFixedAmounts returns ErrFixedAmounts when the fixed amounts of the subscription's members
+// add up to more than its charge, which would leave the owner a negative share
+// Called within the transaction changing members or prices, after the change
+func checkFixedAmounts(ctx context.Context, tx *sqlx.Tx, subID int) error {
+	var totals struct {
+		Fixed  int `db:"fixed_total"`
+		Charge int `db:"charge"`
+	}
+	query := fmt.Sprintf(`
+        SELECT COALESCE(SUM(m.fixed_amount), 0) AS fixed_total, COALESCE(s.price_after_trial, s.price) AS charge
+        FROM %s s
+        LEFT JOIN %s m ON m.subscription_id = s.id
+        WHERE s.id = $1
+        GROUP BY s.id`, subscriptionTable, memberTable)
+	if err := tx.GetContext(ctx, &totals, query, subID); err != nil {
+		return fmt.Errorf("failed to check fixed amounts of members: %w", err)
+	}
+
+	if totals.Fixed > totals.Charge {
+		return fmt.Errorf("%w: %d > %d", ErrFixedAmounts, totals.Fixed, totals.Charge)
+	}
+	return nil
+}
+
+// MemberRepository implements MemberStore for PostgreSQL
+type MemberRepository struct {
+	db *sqlx.DB
+}
+
+// NewMemberRepository creates a new member repository instance
+func NewMemberRepository(db *sqlx.DB) *MemberRepository {
+	return &MemberRepository{db: db}
+}
+
+// Add stores a new member of a shared subscription
+// A "subscription.member_added" event is written to the outbox in the same transaction
+// Members with a fixed amount are rejected with ErrFixedAmounts if the fixed amounts exceed the price
+func (r *MemberRepository) Add(ctx context.Context, member models.MemberDB) (models.MemberDB, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.MemberDB{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Locking the subscription serializes the check with other member changes and price updates
+	lockQuery := fmt.Sprintf("SELECT id FROM %s WHERE id = $1 FOR UPDATE", subscriptionTable)
+	if _, err := tx.ExecContext(ctx, lockQuery, member.SubscriptionID); err != nil {
+		tx.Rollback()
+		return models.MemberDB{}, fmt.Errorf("failed to lock subscription: %w", err)
+	}
+
+	var created models.MemberDB
+	query := fmt.Sprintf("INSERT INTO %s (subscription_id, user_id, share_weight, fixed_amount) VALUES ($1, $2, $3, $4) RETURNING *", memberTable)
+	if err := tx.GetContext(ctx, &created, query, member.SubscriptionID, member.UserID, member.ShareWeight, member.FixedAmount); err != nil {
+		tx.Rollback()
+		switch {
+		case isConstraintViolation(err, "unique_violation", "subscription_members_subscription_id_user_id_key"):
+			return models.MemberDB{}, ErrMemberExists
+		case isConstraintViolation(err, "foreign_key_violation", "subscription_members_subscription_id_fkey"):
+			return models.MemberDB{}, ErrSubscriptionNotFound
+		case isConstraintViolation(err, "foreign_key_violation", "subscription_members_user_id_fkey"):
+			return models.MemberDB{}, ErrUserNotFound
+		}
+		return models.MemberDB{}, fmt.Errorf("failed to add member: %w", err)
+	}
+
+	if member.FixedAmount != nil {
+		if err := checkFixedAmounts(ctx, tx, member.SubscriptionID); err != nil {
+			tx.Rollback()
+			return models.MemberDB{}, err
+		}
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(member.SubscriptionID), models.EventMemberAdded, created); err != nil {
+		tx.Rollback()
+		return models.MemberDB{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.MemberDB{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetBySubscription returns the members of a subscription in the order they were added
+func (r *MemberRepository) GetBySubscription(ctx context.Context, subID int) ([]models.MemberDB, error) {
+	var members []models.MemberDB
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE subscription_id = $1 ORDER BY id", memberTable)
+	err := r.db.SelectContext(ctx, &members, query, subID)
+
+	return members, err
+}
+
+// Remove deletes a member from a shared subscription
+// A "subscription.member_removed" event is written to the outbox in the same transaction
+func (r *MemberRepository) Remove(ctx context.Context, subID int, userID string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var removed models.MemberDB
+	query := fmt.Sprintf("DELETE FROM %s WHERE subscription_id = $1 AND user_id = $2 RETURNING *", memberTable)
+	if err := tx.GetContext(ctx, &removed, query, subID, userID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrMemberNotFound
+		}
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(subID), models.EventMemberRemoved, removed); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetDebts returns, per month, how much every member owes each owner of subscriptions they share
+// Owners' own shares are not debts and are left out, as are subscriptions paid by an organization
+func (r *MemberRepository) GetDebts(ctx context.Context, filter models.SettlementFilter) ([]models.DebtDB, error) {
+	query := fmt.Sprintf(`
+        SELECT c.month, c.user_id AS debtor, c.owner_id AS creditor, SUM(c.amount) AS amount
+        FROM (%s) c
+        WHERE
+            c.user_id <> c.owner_id AND
+            c.organization_id IS NULL AND
+            ($1::uuid IS NULL OR c.user_id = $1 OR c.owner_id = $1)
+        GROUP BY c.month, c.user_id, c.owner_id
+        HAVING SUM(c.amount) > 0
+        ORDER BY c.month, debtor, creditor
+    `, sharesSource("$2::date", "$3::date"))
+
+	var debts []models.DebtDB
+	if err := r.db.SelectContext(ctx, &debts, query, filter.UserID, filter.From.Format("2006-01-02"), filter.To.Format("2006-01-02")); err != nil {
+		return nil, fmt.Errorf("failed to calculate debts: %w", err)
+	}
+
+	return debts, nil
+}
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/migrations"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jmoiron/sqlx"
+)
+
+// Migrator applies the migrations embedded into the binary to the database
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+// NewMigrator creates a migrator on a dedicated connection of the pool
+// Close must be called to return the connection; the pool itself stays open
+func NewMigrator(ctx context.Context, db *sqlx.DB) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedded migrations: %w", err)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a migration connection: %w", err)
+	}
+
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to initialize migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("failed to initialize migrations: %w", err)
+	}
+
+	return &Migrator{m: m}, nil
+}
+
+// Up applies all pending migrations; an up-to-date schema is not an error
+func (m *Migrator) Up() error {
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
+
+// Down rolls back the last n applied migrations
+func (m *Migrator) Down(n int) error {
+	if n < 1 {
+		return errors.New("number of migrations to roll back must be positive")
+	}
+	if err := m.m.Steps(-n); err != nil {
+		return fmt.Errorf("failed to roll back migrations: %w", err)
+	}
+	return nil
+}
+
+// Version returns the applied schema version and whether the last migration failed halfway
+// An empty database has version 0
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// Force records the version as applied and clears the dirty flag without running migrations
+// It is used to recover after a migration failed halfway and the schema was fixed by hand
+func (m *Migrator) Force(version int) error {
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("failed to force migration version: %w", err)
+	}
+	return nil
+}
+
+// Close releases the migration connection
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// RunMigrations applies all pending embedded migrations
+func RunMigrations(db *sqlx.DB) error {
+	m, err := NewMigrator(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return m.Up()
+}
+
+// LatestMigrationVersion returns the version of the newest embedded migration
+// The readiness probe expects the database schema to be at this version
+func LatestMigrationVersion() (uint, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, fmt.Errorf("failed to open embedded migrations: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
+	}
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// SubscriptionChannel is the notification channel on which triggers announce changed subscriptions
+// The payload is the ID of the subscription whose row, pauses or members changed
+const SubscriptionChannel = "subscription_changes"
+
+// Reconnect delays of the listener connection and how often it is checked
+const (
+	listenerMinReconnect = time.Second
+	listenerMaxReconnect = time.Minute
+	listenerPingInterval = 90 * time.Second
+)
+
+// ChangeListener receives the subscription changes committed by any replica
+// It holds a dedicated connection outside the pool, as LISTEN is bound to a session
+type ChangeListener struct {
+	dsn string
+}
+
+// NewChangeListener creates a listener connecting with the given settings
+func NewChangeListener(cfg Config) (*ChangeListener, error) {
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeListener{dsn: dsn}, nil
+}
+
+// Run listens until ctx is cancelled, calling changed with the ID of every changed subscription
+// reset is called once listening starts and after every reconnect, since changes committed while
+// the connection was down are never delivered. Blocks the caller
+func (l *ChangeListener) Run(ctx context.Context, changed func(subID int), reset func()) {
+	listener := pq.NewListener(l.dsn, listenerMinReconnect, listenerMaxReconnect, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logrus.WithError(err).Warn("subscription change listener: connection failed, reconnecting")
+		}
+	})
+	defer listener.Close()
+
+	// Listen blocks until the connection is established
+	go func() {
+		if err := listener.Listen(SubscriptionChannel); err != nil {
+			if ctx.Err() == nil {
+				logrus.WithError(err).Error("subscription change listener: failed to listen")
+			}
+			return
+		}
+		reset()
+	}()
+
+	ping := time.NewTicker(listenerPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case notification := <-listener.Notify:
+			// A nil notification follows a reconnect
+			if notification == nil {
+				reset()
+				continue
+			}
+			subID, err := strconv.Atoi(notification.Extra)
+			if err != nil {
+				logrus.Warnf("subscription change listener: unexpected payload %q", notification.Extra)
+				reset()
+				continue
+			}
+			changed(subID)
+		case <-ping.C:
+			// A failed ping makes the listener notice a dead connection and reconnect
+			go listener.Ping()
+		}
+	}
+}
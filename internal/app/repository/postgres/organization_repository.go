@@ -0,0 +1,297 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// Errors returned for missing organizations and conflicting membership changes
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrNotOrganizationMember = errors.New("user is not a member of the organization")
+	ErrLastOwner             = errors.New("organization must keep at least one owner")
+)
+
+// OrganizationRepository implements OrganizationStore for PostgreSQL
+type OrganizationRepository struct {
+	db *sqlx.DB
+}
+
+// NewOrganizationRepository creates a new organization repository instance
+func NewOrganizationRepository(db *sqlx.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// Create inserts a new organization with the given user as its first owner
+// An "organization.created" event is written to the outbox in the same transaction
+func (r *OrganizationRepository) Create(ctx context.Context, name, ownerID string) (models.OrganizationDB, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.OrganizationDB{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var created models.OrganizationDB
+	query := fmt.Sprintf("INSERT INTO %s (name) VALUES ($1) RETURNING *", organizationTable)
+	if err := tx.GetContext(ctx, &created, query, name); err != nil {
+		tx.Rollback()
+		return models.OrganizationDB{}, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	memberQuery := fmt.Sprintf("INSERT INTO %s (organization_id, user_id, role) VALUES ($1, $2, $3)", organizationMemberTable)
+	if _, err := tx.ExecContext(ctx, memberQuery, created.Id, ownerID, models.RoleOwner); err != nil {
+		tx.Rollback()
+		if isConstraintViolation(err, "foreign_key_violation", "organization_members_user_id_fkey") {
+			return models.OrganizationDB{}, ErrUserNotFound
+		}
+		return models.OrganizationDB{}, fmt.Errorf("failed to add organization owner: %w", err)
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateOrganization, strconv.Itoa(created.Id), models.EventOrganizationCreated, created); err != nil {
+		tx.Rollback()
+		return models.OrganizationDB{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.OrganizationDB{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetById returns a single organization
+func (r *OrganizationRepository) GetById(ctx context.Context, orgID int) (models.OrganizationDB, error) {
+	var org models.OrganizationDB
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", organizationTable)
+	err := r.db.GetContext(ctx, &org, query, orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return org, ErrOrganizationNotFound
+	}
+
+	return org, err
+}
+
+// Update renames an organization
+// An "organization.updated" event is written to the outbox in the same transaction
+func (r *OrganizationRepository) Update(ctx context.Context, orgID int, name string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var updated models.OrganizationDB
+	query := fmt.Sprintf("UPDATE %s SET name = $1 WHERE id = $2 RETURNING *", organizationTable)
+	if err := tx.GetContext(ctx, &updated, query, name, orgID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOrganizationNotFound
+		}
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateOrganization, strconv.Itoa(orgID), models.EventOrganizationUpdated, updated); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes an organization and its memberships; its subscriptions stay with their owners
+// An "organization.deleted" event is written to the outbox in the same transaction
+func (r *OrganizationRepository) Delete(ctx context.Context, orgID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var deleted models.OrganizationDB
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 RETURNING *", organizationTable)
+	if err := tx.GetContext(ctx, &deleted, query, orgID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOrganizationNotFound
+		}
+		return fmt.Errorf("failed to delete organization: %w", err)
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateOrganization, strconv.Itoa(orgID), models.EventOrganizationDeleted, deleted); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetRole returns the role of the user in the organization
+// ErrNotOrganizationMember is returned when the user has no role there
+func (r *OrganizationRepository) GetRole(ctx context.Context, orgID int, userID string) (string, error) {
+	var role string
+
+	query := fmt.Sprintf("SELECT role FROM %s WHERE organization_id = $1 AND user_id = $2", organizationMemberTable)
+	err := r.db.GetContext(ctx, &role, query, orgID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotOrganizationMember
+	}
+
+	return role, err
+}
+
+// GetMembers returns the members of the organization ordered by role and join time
+func (r *OrganizationRepository) GetMembers(ctx context.Context, orgID int) ([]models.OrganizationMemberDB, error) {
+	var members []models.OrganizationMemberDB
+
+	query := fmt.Sprintf(`
+        SELECT * FROM %s
+        WHERE organization_id = $1
+        ORDER BY CASE role WHEN 'owner' THEN 1 WHEN 'admin' THEN 2 WHEN 'member' THEN 3 ELSE 4 END, created_at
+    `, organizationMemberTable)
+	err := r.db.SelectContext(ctx, &members, query, orgID)
+
+	return members, err
+}
+
+// SetMember adds a user to the organization or changes their role
+// An "organization.member_changed" event is written to the outbox in the same transaction
+func (r *OrganizationRepository) SetMember(ctx context.Context, orgID int, userID, role string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var member models.OrganizationMemberDB
+	query := fmt.Sprintf(`
+        INSERT INTO %s (organization_id, user_id, role) VALUES ($1, $2, $3)
+        ON CONFLICT (organization_id, user_id) DO UPDATE SET role = EXCLUDED.role
+        RETURNING *
+    `, organizationMemberTable)
+	if err := tx.GetContext(ctx, &member, query, orgID, userID, role); err != nil {
+		tx.Rollback()
+		switch {
+		case isConstraintViolation(err, "foreign_key_violation", "organization_members_organization_id_fkey"):
+			return ErrOrganizationNotFound
+		case isConstraintViolation(err, "foreign_key_violation", "organization_members_user_id_fkey"):
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to set organization member: %w", err)
+	}
+
+	if err := ensureOwner(ctx, tx, orgID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateOrganization, strconv.Itoa(orgID), models.EventOrganizationMemberChanged, member); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveMember removes a user from the organization
+// An "organization.member_removed" event is written to the outbox in the same transaction
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID int, userID string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var removed models.OrganizationMemberDB
+	query := fmt.Sprintf("DELETE FROM %s WHERE organization_id = $1 AND user_id = $2 RETURNING *", organizationMemberTable)
+	if err := tx.GetContext(ctx, &removed, query, orgID, userID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotOrganizationMember
+		}
+		return fmt.Errorf("failed to remove organization member: %w", err)
+	}
+
+	if err := ensureOwner(ctx, tx, orgID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateOrganization, strconv.Itoa(orgID), models.EventOrganizationMemberRemoved, removed); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ensureOwner fails with ErrLastOwner when a membership change left the organization without owners
+// The organization row is locked first so concurrent changes cannot remove the last two owners at once
+func ensureOwner(ctx context.Context, tx *sqlx.Tx, orgID int) error {
+	lockQuery := fmt.Sprintf("SELECT id FROM %s WHERE id = $1 FOR UPDATE", organizationTable)
+	var id int
+	if err := tx.GetContext(ctx, &id, lockQuery, orgID); err != nil {
+		return fmt.Errorf("failed to lock organization: %w", err)
+	}
+
+	var owners int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE organization_id = $1 AND role = 'owner'", organizationMemberTable)
+	if err := tx.GetContext(ctx, &owners, query, orgID); err != nil {
+		return fmt.Errorf("failed to count organization owners: %w", err)
+	}
+
+	if owners == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// SetSubscriptionOrganization attaches a subscription to an organization or detaches it when orgID is nil
+// A "subscription.updated" event carrying the new row is written to the outbox in the same transaction
+func (r *OrganizationRepository) SetSubscriptionOrganization(ctx context.Context, subID int, orgID *int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var updated models.SubscriptionDB
+	query := fmt.Sprintf("UPDATE %s SET organization_id = $1 WHERE id = $2 RETURNING *", subscriptionTable)
+	if err := tx.GetContext(ctx, &updated, query, orgID, subID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSubscriptionNotFound
+		}
+		if isConstraintViolation(err, "foreign_key_violation", "subscriptions_organization_id_fkey") {
+			return ErrOrganizationNotFound
+		}
+		return fmt.Errorf("failed to update subscription organization: %w", err)
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(subID), models.EventSubscriptionUpdated, updated); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// execer is implemented by both *sql.Tx and *sqlx.Tx
+// Allows outbox writes to join whichever transaction the caller has opened
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertOutboxEvent stores an event in the outbox table using the caller's transaction
+// The event becomes visible to the relay only if the surrounding transaction commits
+func insertOutboxEvent(ctx context.Context, tx execer, aggregateType, aggregateID, eventType string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox payload: %w", err)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (aggregate_type, aggregate_id, event_type, payload) VALUES ($1, $2, $3, $4)", outboxTable)
+	if _, err := tx.ExecContext(ctx, query, aggregateType, aggregateID, eventType, body); err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// ErrLeaseLost is returned when the outcome of a publish is recorded after the lease of the event
+// expired and another relay claimed it, or the event was already settled
+var ErrLeaseLost = errors.New("outbox event lease was lost")
+
+// OutboxRepository implements OutboxStore for PostgreSQL
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+// NewOutboxRepository creates a new outbox repository instance
+func NewOutboxRepository(db *sqlx.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// Claim leases up to limit events that are due for delivery and returns them in order
+// Only the oldest undelivered event of every aggregate is claimed, so events of one
+// aggregate are published one after another even with several relays; dead-lettered
+// events no longer hold back the aggregate. Rows are picked with FOR UPDATE SKIP LOCKED,
+// the lease is committed right away and the caller publishes outside any transaction.
+// An event whose lease expires (e.g. the relay crashed) is claimed again.
+func (r *OutboxRepository) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer tx.Rollback()
+
+	var events []models.OutboxEvent
+	claimQuery := fmt.Sprintf(`
+        WITH due AS (
+            SELECT e.id
+            FROM %[1]s e
+            WHERE e.delivered_at IS NULL AND e.dead_at IS NULL
+              AND e.next_attempt_at <= NOW()
+              AND (e.claimed_until IS NULL OR e.claimed_until < NOW())
+              AND NOT EXISTS (
+                  SELECT 1 FROM %[1]s o
+                  WHERE o.aggregate_type = e.aggregate_type AND o.aggregate_id = e.aggregate_id
+                    AND o.id < e.id AND o.delivered_at IS NULL AND o.dead_at IS NULL
+              )
+            ORDER BY e.id
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE %[1]s e SET claimed_until = NOW() + $2 * INTERVAL '1 second'
+        FROM due
+        WHERE e.id = due.id
+        RETURNING e.id, e.aggregate_type, e.aggregate_id, e.event_type, e.payload, e.created_at, e.attempts, e.claimed_until
+    `, outboxTable)
+	if err := tx.SelectContext(ctx, &events, claimQuery, limit, lease.Seconds()); err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// UPDATE ... RETURNING does not keep the order of the CTE
+	sort.Slice(events, func(i, j int) bool { return events[i].Id < events[j].Id })
+
+	return events, nil
+}
+
+// MarkDelivered records a successful publish and releases the lease
+// Returns ErrLeaseLost unless the event is still held by the claim that returned it
+func (r *OutboxRepository) MarkDelivered(ctx context.Context, event models.OutboxEvent) error {
+	query := fmt.Sprintf(`
+        UPDATE %s
+        SET delivered_at = NOW(), claimed_until = NULL, last_error = NULL
+        WHERE id = $1 AND claimed_until = $2 AND delivered_at IS NULL AND dead_at IS NULL
+    `, outboxTable)
+	result, err := r.db.ExecContext(ctx, query, event.Id, event.ClaimedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event delivered: %w", err)
+	}
+	return leaseHeld(result, event.Id)
+}
+
+// MarkFailed records a failed publish and schedules the next attempt at retryAt
+// A nil retryAt moves the event to the dead-letter state: it is kept for operators
+// but never published again. Returns ErrLeaseLost unless the event is still held by the claim
+func (r *OutboxRepository) MarkFailed(ctx context.Context, event models.OutboxEvent, cause string, retryAt *time.Time) error {
+	query := fmt.Sprintf(`
+        UPDATE %s
+        SET attempts = attempts + 1, last_error = $2, claimed_until = NULL,
+            next_attempt_at = COALESCE($3::timestamptz, next_attempt_at),
+            dead_at = CASE WHEN $3::timestamptz IS NULL THEN NOW() END
+        WHERE id = $1 AND claimed_until = $4 AND delivered_at IS NULL AND dead_at IS NULL
+    `, outboxTable)
+	result, err := r.db.ExecContext(ctx, query, event.Id, cause, retryAt, event.ClaimedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to record outbox failure: %w", err)
+	}
+	return leaseHeld(result, event.Id)
+}
+
+// leaseHeld returns ErrLeaseLost when the update matched no row
+// The lease end is reset by every claim, so it tells the current claim from earlier ones
+func leaseHeld(result sql.Result, eventID int64) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: event %d", ErrLeaseLost, eventID)
+	}
+	return nil
+}
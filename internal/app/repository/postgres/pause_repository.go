@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// Errors returned when a pause or resume conflicts with the stored pauses
+var (
+	ErrAlreadyPaused = errors.New("subscription is already paused")
+	ErrNotPaused     = errors.New("subscription is not paused")
+	ErrPauseOverlap  = errors.New("pause overlaps a previous pause")
+)
+
+// PauseRepository implements PauseStore for PostgreSQL
+type PauseRepository struct {
+	db *sqlx.DB
+}
+
+// NewPauseRepository creates a new pause repository instance
+func NewPauseRepository(db *sqlx.DB) *PauseRepository {
+	return &PauseRepository{db: db}
+}
+
+// Pause opens a pause interval starting with the given month and sets the new status
+// (unchanged for a pause starting in a later month)
+// The subscription must still have the expected status, otherwise ErrStatusConflict is returned
+// A "subscription.paused" event is written to the outbox in the same transaction
+func (r *PauseRepository) Pause(ctx context.Context, subID int, from time.Time, expectedStatus, newStatus string) (models.PauseDB, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.PauseDB{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Lock the subscription row so concurrent pause and resume requests are serialized
+	if err := lockSubscription(ctx, tx, subID, expectedStatus); err != nil {
+		tx.Rollback()
+		return models.PauseDB{}, err
+	}
+
+	var conflict string
+	checkQuery := fmt.Sprintf(`
+        SELECT CASE WHEN resumed_from IS NULL THEN 'open' ELSE 'overlap' END
+        FROM %s
+        WHERE subscription_id = $1 AND (resumed_from IS NULL OR resumed_from > $2::date)
+        LIMIT 1
+    `, pauseTable)
+	err = tx.GetContext(ctx, &conflict, checkQuery, subID, from.Format("2006-01-02"))
+	switch {
+	case err == nil && conflict == "open":
+		tx.Rollback()
+		return models.PauseDB{}, ErrAlreadyPaused
+	case err == nil:
+		tx.Rollback()
+		return models.PauseDB{}, ErrPauseOverlap
+	case !errors.Is(err, sql.ErrNoRows):
+		tx.Rollback()
+		return models.PauseDB{}, fmt.Errorf("failed to check existing pauses: %w", err)
+	}
+
+	var pause models.PauseDB
+	insertQuery := fmt.Sprintf("INSERT INTO %s (subscription_id, paused_from) VALUES ($1, $2::date) RETURNING *", pauseTable)
+	if err := tx.GetContext(ctx, &pause, insertQuery, subID, from.Format("2006-01-02")); err != nil {
+		tx.Rollback()
+		return models.PauseDB{}, fmt.Errorf("failed to pause subscription: %w", err)
+	}
+
+	if err := setStatus(ctx, tx, subID, newStatus); err != nil {
+		tx.Rollback()
+		return models.PauseDB{}, err
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(subID), models.EventSubscriptionPaused, pause); err != nil {
+		tx.Rollback()
+		return models.PauseDB{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PauseDB{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return pause, nil
+}
+
+// Resume closes the open pause so billing continues from the given month and sets the new status
+// A pause resumed before any of its months passed is removed entirely
+// A "subscription.resumed" event is written to the outbox in the same transaction
+func (r *PauseRepository) Resume(ctx context.Context, subID int, from time.Time, expectedStatus, newStatus string) (models.PauseDB, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.PauseDB{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := lockSubscription(ctx, tx, subID, expectedStatus); err != nil {
+		tx.Rollback()
+		return models.PauseDB{}, err
+	}
+
+	var pause models.PauseDB
+	selectQuery := fmt.Sprintf("SELECT * FROM %s WHERE subscription_id = $1 AND resumed_from IS NULL", pauseTable)
+	if err := tx.GetContext(ctx, &pause, selectQuery, subID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.PauseDB{}, ErrNotPaused
+		}
+		return models.PauseDB{}, fmt.Errorf("failed to find open pause: %w", err)
+	}
+
+	if from.After(pause.PausedFrom) {
+		updateQuery := fmt.Sprintf("UPDATE %s SET resumed_from = $1::date WHERE id = $2 RETURNING *", pauseTable)
+		if err := tx.GetContext(ctx, &pause, updateQuery, from.Format("2006-01-02"), pause.Id); err != nil {
+			tx.Rollback()
+			return models.PauseDB{}, fmt.Errorf("failed to resume subscription: %w", err)
+		}
+	} else {
+		// No month was skipped, so the pause leaves no trace in billing
+		deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE id = $1", pauseTable)
+		if _, err := tx.ExecContext(ctx, deleteQuery, pause.Id); err != nil {
+			tx.Rollback()
+			return models.PauseDB{}, fmt.Errorf("failed to resume subscription: %w", err)
+		}
+		pause.ResumedFrom = &pause.PausedFrom
+	}
+
+	if err := setStatus(ctx, tx, subID, newStatus); err != nil {
+		tx.Rollback()
+		return models.PauseDB{}, err
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(subID), models.EventSubscriptionResumed, pause); err != nil {
+		tx.Rollback()
+		return models.PauseDB{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.PauseDB{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return pause, nil
+}
+
+// lockSubscription takes a row lock on the subscription for the rest of the transaction
+// and verifies that it still has the expected status
+func lockSubscription(ctx context.Context, tx *sqlx.Tx, subID int, expectedStatus string) error {
+	var status string
+	query := fmt.Sprintf("SELECT status FROM %s WHERE id = $1 FOR UPDATE", subscriptionTable)
+	if err := tx.GetContext(ctx, &status, query, subID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSubscriptionNotFound
+		}
+		return err
+	}
+
+	if status != expectedStatus {
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+// setStatus updates the lifecycle status of a subscription locked by the transaction
+func setStatus(ctx context.Context, tx *sqlx.Tx, subID int, status string) error {
+	query := fmt.Sprintf("UPDATE %s SET status = $1 WHERE id = $2", subscriptionTable)
+	if _, err := tx.ExecContext(ctx, query, status, subID); err != nil {
+		return fmt.Errorf("failed to update subscription status: %w", err)
+	}
+	return nil
+}
+
+// SyncStatuses moves subscriptions whose pause started or ended by the given month into the
+// matching status: trials and active subscriptions inside a pause become paused, paused
+// subscriptions outside of any pause return to trial (unconverted trials) or active
+// Returns the number of subscriptions whose status changed
+func (r *PauseRepository) SyncStatuses(ctx context.Context, month time.Time) (int, error) {
+	query := fmt.Sprintf(`
+        WITH paused AS (
+            SELECT s.id, EXISTS (
+                SELECT 1 FROM %[2]s p
+                WHERE p.subscription_id = s.id AND p.paused_from <= $1::date
+                  AND (p.resumed_from IS NULL OR p.resumed_from > $1::date)
+            ) AS inside
+            FROM %[1]s s
+            WHERE s.status IN ('trial', 'active', 'paused')
+        )
+        UPDATE %[1]s s
+        SET status = CASE
+            WHEN paused.inside THEN 'paused'
+            WHEN s.trial_end IS NOT NULL AND s.trial_converted_at IS NULL THEN 'trial'
+            ELSE 'active' END
+        FROM paused
+        WHERE s.id = paused.id AND paused.inside <> (s.status = 'paused')
+    `, subscriptionTable, pauseTable)
+
+	result, err := r.db.ExecContext(ctx, query, month.Format("2006-01-02"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to update statuses of paused subscriptions: %w", err)
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to update statuses of paused subscriptions: %w", err)
+	}
+
+	return int(changed), nil
+}
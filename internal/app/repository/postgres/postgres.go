@@ -1,86 +1,205 @@
 package postgres
 
 import (
+	"cmp"
 	"context"
+	"database/sql/driver"
 	"fmt"
-	"log"
+	"math/rand/v2"
+	"strings"
 	"time"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	subscriptionTable = "subscriptions" // Database table name for subscriptions
+	subscriptionTable       = "subscriptions"        // Database table name for subscriptions
+	outboxTable             = "outbox"               // Database table name for pending integration events
+	budgetTable             = "budgets"              // Database table name for user spending limits
+	pauseTable              = "subscription_pauses"  // Database table name for subscription pause intervals
+	memberTable             = "subscription_members" // Database table name for users sharing a subscription
+	userTable               = "users"                // Database table name for users
+	organizationTable       = "organizations"        // Database table name for organizations
+	organizationMemberTable = "organization_members" // Database table name for organization members and their roles
+	rateLimitTable          = "rate_limits"          // Database table name for shared rate limit buckets
+	spendTable              = "monthly_spend"        // Database table name for per-month spend of users on services
+	spendStateTable         = "monthly_spend_state"  // Database table name for the months covered by the spend aggregate
+	spendDirtyTable         = "monthly_spend_dirty"  // Database table name for users and services with a stale aggregate
 )
 
 // Config holds PostgreSQL connection configuration parameters
 type Config struct {
+	// URL is a complete connection string, either a postgres:// URL or a key=value DSN
+	// When set it replaces the separate connection fields below
+	URL string
+
 	Host     string
 	Port     string
 	Username string
 	Password string
 	DBName   string
 	SSLMode  string
+
+	// Files for verify-ca/verify-full and client certificate authentication, empty to omit
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	// Connection pool settings, zero values keep the database/sql defaults
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Startup retries: the first retry waits RetryInitialBackoff, every next one twice as long
+	// up to RetryMaxBackoff, with random jitter; NewPostgresDB gives up after ConnectTimeout
+	// Zero values use the Default* constants
+	ConnectTimeout      time.Duration
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
 }
 
-// NewPostgresDB creates a new PostgreSQL database connection with context support
-// Returns sqlx.DB instance or error if connection fails
-func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// Defaults of the startup retry settings
+const (
+	DefaultConnectTimeout      = 30 * time.Second
+	DefaultRetryInitialBackoff = 500 * time.Millisecond
+	DefaultRetryMaxBackoff     = 10 * time.Second
+)
+
+// pingTimeout limits a single connection attempt so a hanging server does not use up the retries
+const pingTimeout = 5 * time.Second
+
+// DSN returns the connection string in the key=value form understood by lib/pq
+// Values are quoted, so passwords with spaces or quotes are passed as is
+func (c Config) DSN() (string, error) {
+	var params []string
+	if c.URL != "" {
+		dsn := c.URL
+		if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+			parsed, err := pq.ParseURL(dsn)
+			if err != nil {
+				return "", fmt.Errorf("invalid database URL: %w", err)
+			}
+			dsn = parsed
+		}
+		params = append(params, dsn)
+	} else {
+		params = appendParam(params, "host", c.Host)
+		params = appendParam(params, "port", c.Port)
+		params = appendParam(params, "user", c.Username)
+		params = appendParam(params, "password", c.Password)
+		params = appendParam(params, "dbname", c.DBName)
+		params = appendParam(params, "sslmode", c.SSLMode)
+	}
+
+	// Certificate files are added to the URL too, later values take precedence in lib/pq
+	params = appendParam(params, "sslrootcert", c.SSLRootCert)
+	params = appendParam(params, "sslcert", c.SSLCert)
+	params = appendParam(params, "sslkey", c.SSLKey)
+
+	return strings.Join(params, " "), nil
+}
+
+// appendParam adds a quoted key=value pair unless the value is empty
+func appendParam(params []string, key, value string) []string {
+	if value == "" {
+		return params
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value)
+	return append(params, key+"='"+escaped+"'")
+}
+
+// Open creates the connection pool without connecting; see WaitReady
+func Open(cfg Config) (*sqlx.DB, error) {
+	dsn, err := cfg.DSN()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openTraced(dsn)
+	if err != nil {
+		return nil, err
+	}
 
-	connectionString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.DBName, cfg.SSLMode)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
-	var db *sqlx.DB
-	var err error
+	return db, nil
+}
 
-	// Retry logic for connection
-	for i := 0; i < 3; i++ {
-		db, err = sqlx.Open("postgres", connectionString)
-		if err != nil {
-			time.Sleep(time.Duration(i+1) * time.Second)
-			continue
+// WaitReady pings the database until it answers or ctx ends, backing off exponentially
+// with jitter between attempts so replicas starting together do not retry in lockstep
+func WaitReady(ctx context.Context, db *sqlx.DB, cfg Config) error {
+	delay := cmp.Or(cfg.RetryInitialBackoff, DefaultRetryInitialBackoff)
+	maxDelay := cmp.Or(cfg.RetryMaxBackoff, DefaultRetryMaxBackoff)
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
 		}
 
-		if err = db.PingContext(ctx); err != nil {
-			time.Sleep(time.Duration(i+1) * time.Second)
-			continue
+		// Half of the delay is fixed and half random
+		wait := delay/2 + rand.N(delay/2+1)
+		logrus.WithError(err).Warnf("database is not available (attempt %d), retrying in %s", attempt, wait.Round(time.Millisecond))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not available after %d attempts: %w", attempt, err)
+		case <-time.After(wait):
 		}
 
-		break
+		delay = min(delay*2, maxDelay)
 	}
+}
 
+// NewPostgresDB creates the connection pool and waits up to ConnectTimeout for the database
+// Returns sqlx.DB instance or error if the database does not become available
+func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
+	db, err := Open(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect after retries: %w", err)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cmp.Or(cfg.ConnectTimeout, DefaultConnectTimeout))
+	defer cancel()
+
+	if err := WaitReady(ctx, db, cfg); err != nil {
+		db.Close()
+		return nil, err
 	}
 
 	return db, nil
 }
 
-// RunMigrations applies database migrations from the migrations folder.
-// Returns error if migration fails (ignores the case when there are no changes).
-func RunMigrations(db *sqlx.DB) error {
-	// Initialize PostgreSQL driver instance
-	driver, err := postgres.WithInstance(db.DB, &postgres.Config{})
-
-	// Create migrator instance with file-based migrations
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations", // migrations are loaded from filesystem
-		"postgres", driver)
-
-	// Apply all pending migrations (Up)
-	err = m.Up()
-	// Ignore "no change" error (when all migrations are already applied)
-	if err != nil && err != migrate.ErrNoChange {
-		return err
+// openTraced opens the database with a driver that records a span for every statement
+// Only statements running within a traced request or job get a span, so background
+// queries without a context do not start traces of their own. The statement text is
+// recorded, parameter values never are
+func openTraced(connectionString string) (*sqlx.DB, error) {
+	db, err := otelsql.Open("postgres", connectionString,
+		otelsql.WithAttributes(attribute.String("db.system", "postgresql")),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitRows:             true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
+	if err != nil {
+		return nil, err
 	}
 
-	// Log success and return
-	log.Println("Migrations applied successfully")
-	return nil
+	return sqlx.NewDb(db, "postgres"), nil
 }
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RateLimitRepository implements RateLimitStore for PostgreSQL
+type RateLimitRepository struct {
+	db *sqlx.DB
+}
+
+// NewRateLimitRepository creates a new rate limit repository instance
+func NewRateLimitRepository(db *sqlx.DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+// Take refills the bucket by the time passed since its last update and removes one token
+// when available; the whole step is a single upsert so concurrent replicas cannot overspend
+func (r *RateLimitRepository) Take(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	var bucket struct {
+		Tokens  float64 `db:"tokens"`
+		Allowed bool    `db:"allowed"`
+	}
+
+	refilled := fmt.Sprintf(
+		"LEAST($2::float8, %[1]s.tokens + EXTRACT(EPOCH FROM NOW() - %[1]s.updated_at) * $3::float8)",
+		rateLimitTable)
+	query := fmt.Sprintf(`INSERT INTO %[1]s (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::float8 - 1, TRUE, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE WHEN %[2]s >= 1 THEN %[2]s - 1 ELSE %[2]s END,
+			allowed = %[2]s >= 1,
+			updated_at = NOW()
+		RETURNING tokens, allowed`, rateLimitTable, refilled)
+
+	if err := r.db.GetContext(ctx, &bucket, query, key, burst, rate); err != nil {
+		return 0, false, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	return bucket.Tokens, bucket.Allowed, nil
+}
+
+// DeleteIdle removes buckets not touched for idleFor; they would be full by now anyway
+func (r *RateLimitRepository) DeleteIdle(ctx context.Context, idleFor time.Duration) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE updated_at < NOW() - $1::float8 * INTERVAL '1 second'", rateLimitTable)
+
+	res, err := r.db.ExecContext(ctx, query, idleFor.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete idle rate limits: %w", err)
+	}
+
+	return res.RowsAffected()
+}
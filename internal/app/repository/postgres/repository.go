@@ -1,28 +1,129 @@
 package postgres
 
 import (
+	"context"
+	"time"
+
 	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
 	"github.com/jmoiron/sqlx"
 )
 
 // SubscriptionStore defines CRUD operations for subscription management
 type SubscriptionStore interface {
-	Create(sub models.Subscription) (int, error)
-	GetAll() ([]models.SubscriptionDB, error)
-	GetById(subID int) (models.SubscriptionDB, error)
-	Delete(subID int) error
-	Update(subID int, input models.UpdateSubscription) error
-	GetSubscriptionSummary(filter models.SubscriptionFilter) (int, error)
+	Create(ctx context.Context, sub models.Subscription) (int, error)
+	GetAll(ctx context.Context, filter models.SubscriptionListFilter) ([]models.SubscriptionDB, error)
+	GetById(ctx context.Context, subID int) (models.SubscriptionDB, error)
+	Delete(ctx context.Context, subID int) error
+	Update(ctx context.Context, subID int, input models.UpdateSubscription) error
+	GetSubscriptionSummary(ctx context.Context, filter models.SubscriptionFilter) (int, error)
+	GetCharges(ctx context.Context, filter models.ChargeFilter) ([]models.ChargeDB, error)
+	FindOverlaps(ctx context.Context, filter models.OverlapFilter) ([]models.SubscriptionDB, error)
+	GetDuplicates(ctx context.Context) ([]models.SubscriptionDB, error)
+	GetEndingTrials(ctx context.Context, from, to time.Time) ([]models.SubscriptionDB, error)
+	ConvertEndedTrials(ctx context.Context, month time.Time) ([]models.SubscriptionDB, error)
+	ChangeStatus(ctx context.Context, subID int, change models.StatusChange) (models.SubscriptionDB, error)
+	ExpireEnded(ctx context.Context, month time.Time) ([]models.SubscriptionDB, error)
+	CountByStatus(ctx context.Context) ([]models.StatusCountDB, error)
+}
+
+// OutboxStore defines operations used by the outbox relay to deliver pending events
+type OutboxStore interface {
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	MarkDelivered(ctx context.Context, event models.OutboxEvent) error
+	MarkFailed(ctx context.Context, event models.OutboxEvent, cause string, retryAt *time.Time) error
+}
+
+// BudgetStore defines persistence operations for user budgets
+type BudgetStore interface {
+	Create(ctx context.Context, budget models.BudgetDB) (int, error)
+	GetByUser(ctx context.Context, userID string) ([]models.BudgetDB, error)
+	GetById(ctx context.Context, userID string, budgetID int) (models.BudgetDB, error)
+	Delete(ctx context.Context, userID string, budgetID int) error
+	GetUserIDs(ctx context.Context) ([]string, error)
+	Evaluate(ctx context.Context, userID string, month time.Time) error
+}
+
+// MemberStore defines persistence operations for members of shared subscriptions
+type MemberStore interface {
+	Add(ctx context.Context, member models.MemberDB) (models.MemberDB, error)
+	GetBySubscription(ctx context.Context, subID int) ([]models.MemberDB, error)
+	Remove(ctx context.Context, subID int, userID string) error
+	GetDebts(ctx context.Context, filter models.SettlementFilter) ([]models.DebtDB, error)
+}
+
+// UserStore defines persistence operations for users
+type UserStore interface {
+	Create(ctx context.Context, user models.UserDB) (models.UserDB, error)
+	GetById(ctx context.Context, userID string) (models.UserDB, error)
+	Update(ctx context.Context, userID string, input models.UpdateUser) error
+	Delete(ctx context.Context, userID string, cascade bool) error
+}
+
+// OrganizationStore defines persistence operations for organizations and their members
+type OrganizationStore interface {
+	Create(ctx context.Context, name, ownerID string) (models.OrganizationDB, error)
+	GetById(ctx context.Context, orgID int) (models.OrganizationDB, error)
+	Update(ctx context.Context, orgID int, name string) error
+	Delete(ctx context.Context, orgID int) error
+	GetRole(ctx context.Context, orgID int, userID string) (string, error)
+	GetMembers(ctx context.Context, orgID int) ([]models.OrganizationMemberDB, error)
+	SetMember(ctx context.Context, orgID int, userID, role string) error
+	RemoveMember(ctx context.Context, orgID int, userID string) error
+	SetSubscriptionOrganization(ctx context.Context, subID int, orgID *int) error
+}
+
+// HealthStore defines database checks used by the readiness probe
+type HealthStore interface {
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (uint, bool, error)
+}
+
+// RateLimitStore defines token bucket operations shared by all replicas
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, rate float64, burst int) (float64, bool, error)
+	DeleteIdle(ctx context.Context, idleFor time.Duration) (int64, error)
+}
+
+// PauseStore defines persistence operations for subscription pauses
+type PauseStore interface {
+	Pause(ctx context.Context, subID int, from time.Time, expectedStatus, newStatus string) (models.PauseDB, error)
+	Resume(ctx context.Context, subID int, from time.Time, expectedStatus, newStatus string) (models.PauseDB, error)
+	SyncStatuses(ctx context.Context, month time.Time) (int, error)
+}
+
+// SpendStore defines maintenance of the monthly spend aggregate read by subscription summaries
+type SpendStore interface {
+	Refresh(ctx context.Context, until time.Time) (models.SpendRefresh, error)
+	Rebuild(ctx context.Context, until time.Time) (int64, error)
+	Check(ctx context.Context, from, to time.Time) ([]models.SpendMismatchDB, error)
 }
 
 // Repository aggregates all store interfaces for database operations
 type Repository struct {
 	SubscriptionStore
+	OutboxStore
+	BudgetStore
+	PauseStore
+	MemberStore
+	UserStore
+	OrganizationStore
+	HealthStore
+	RateLimitStore
+	SpendStore
 }
 
 // NewRepository constructs a new Repository with all available stores
 func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{
 		SubscriptionStore: NewSubscriptionRepository(db),
+		OutboxStore:       NewOutboxRepository(db),
+		BudgetStore:       NewBudgetRepository(db),
+		PauseStore:        NewPauseRepository(db),
+		MemberStore:       NewMemberRepository(db),
+		UserStore:         NewUserRepository(db),
+		OrganizationStore: NewOrganizationRepository(db),
+		HealthStore:       NewHealthRepository(db),
+		RateLimitStore:    NewRateLimitRepository(db),
+		SpendStore:        NewSpendRepository(db),
 	}
 }
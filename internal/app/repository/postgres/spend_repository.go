@@ -0,0 +1,319 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// spendLock names the advisory lock that serializes changes of the monthly spend aggregate
+const spendLock = "monthly-spend"
+
+// spendFrom is the first month of the aggregate: every charge since the first subscription is included
+const spendFrom = "'-infinity'::date"
+
+// spendSubscriptions selects the subscriptions counted by the aggregate: summaries without
+// an organization filter cover personal subscriptions only
+var spendSubscriptions = fmt.Sprintf("(SELECT * FROM %s WHERE organization_id IS NULL)", subscriptionTable)
+
+// ErrSpendNotBuilt is returned when the monthly spend aggregate has not been built yet
+var ErrSpendNotBuilt = errors.New("monthly spend aggregate has not been built")
+
+// SpendConfig holds settings of the monthly spend aggregate
+type SpendConfig struct {
+	RefreshInterval time.Duration // How often pending changes are applied to the aggregate, 0 disables the job
+	HorizonMonths   int           // Months after the current one kept in the aggregate
+}
+
+// Until returns the last month the aggregate covers at the given time
+// Open-ended subscriptions are charged forever, so the aggregate stops at a horizon
+// and summaries reaching beyond it are calculated from raw data
+func (c SpendConfig) Until(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, c.HorizonMonths, 0)
+}
+
+// SpendRepository maintains the monthly_spend aggregate used by subscription summaries
+// Triggers record the users and services affected by every change of a subscription,
+// its pauses or members; Refresh recalculates them from the raw subscriptions
+type SpendRepository struct {
+	db *sqlx.DB
+}
+
+// NewSpendRepository creates a new spend repository instance
+func NewSpendRepository(db *sqlx.DB) *SpendRepository {
+	return &SpendRepository{db: db}
+}
+
+// Refresh applies pending changes and extends the aggregate up to the until month
+// An aggregate that has not been built yet is built from scratch. Only one replica refreshes
+// at a time: when the advisory lock is held elsewhere the call returns without changes
+func (r *SpendRepository) Refresh(ctx context.Context, until time.Time) (models.SpendRefresh, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.SpendRefresh{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer tx.Rollback()
+
+	locked, err := tryAdvisoryLock(ctx, tx, spendLock)
+	if err != nil || !locked {
+		return models.SpendRefresh{}, err
+	}
+
+	coveredUntil, built, err := spendCoveredUntil(ctx, tx)
+	if err != nil {
+		return models.SpendRefresh{}, err
+	}
+
+	var result models.SpendRefresh
+	if !built {
+		if _, err := rebuildSpend(ctx, tx, until); err != nil {
+			return models.SpendRefresh{}, err
+		}
+		result.Rebuilt = true
+	} else {
+		// A shorter horizon keeps the months already covered
+		if coveredUntil.Before(until) {
+			if err := extendSpend(ctx, tx, coveredUntil, until); err != nil {
+				return models.SpendRefresh{}, err
+			}
+			result.Months = (until.Year()-coveredUntil.Year())*12 + int(until.Month()-coveredUntil.Month())
+		} else {
+			until = coveredUntil
+		}
+
+		if result.Refreshed, err = refreshDirtySpend(ctx, tx, until); err != nil {
+			return models.SpendRefresh{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.SpendRefresh{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// Rebuild recalculates the whole aggregate up to the until month and returns the number of rows
+// Waits for a refresh running on another replica to finish
+func (r *SpendRepository) Rebuild(ctx context.Context, until time.Time) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := advisoryLock(ctx, tx, spendLock); err != nil {
+		return 0, err
+	}
+
+	rows, err := rebuildSpend(ctx, tx, until)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return rows, nil
+}
+
+// Check compares the aggregate with the spend calculated from raw data between from and to
+// (inclusive, limited to the months covered) and returns every month that differs, ordered by
+// month. Users and services with pending changes are expected to differ and are left out
+func (r *SpendRepository) Check(ctx context.Context, from, to time.Time) ([]models.SpendMismatchDB, error) {
+	// Both sides are read from the same snapshot
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	coveredUntil, built, err := spendCoveredUntil(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if !built {
+		return nil, ErrSpendNotBuilt
+	}
+	if to.After(coveredUntil) {
+		to = coveredUntil
+	}
+
+	query := fmt.Sprintf(`
+        SELECT
+            COALESCE(a.user_id, s.user_id) AS user_id,
+            COALESCE(a.service_name, s.service_name) AS service_name,
+            COALESCE(a.month, s.month) AS month,
+            COALESCE(s.amount, 0) AS aggregated,
+            COALESCE(a.amount, 0) AS actual
+        FROM (
+            SELECT c.user_id, c.service_name, c.month, SUM(c.amount) AS amount
+            FROM (%[1]s) c
+            GROUP BY c.user_id, c.service_name, c.month
+        ) a
+        FULL JOIN (
+            SELECT user_id, service_name, month, amount
+            FROM %[2]s
+            WHERE month BETWEEN $1::date AND $2::date
+        ) s ON s.user_id = a.user_id AND s.service_name = a.service_name AND s.month = a.month
+        WHERE
+            COALESCE(s.amount, 0) <> COALESCE(a.amount, 0) AND
+            NOT EXISTS (
+                SELECT 1 FROM %[3]s d
+                WHERE d.user_id = COALESCE(a.user_id, s.user_id) AND d.service_name = COALESCE(a.service_name, s.service_name)
+            )
+        ORDER BY 3, 1, 2
+    `, sharesSourceOf(spendSubscriptions, "$1::date", "$2::date"), spendTable, spendDirtyTable)
+
+	var mismatches []models.SpendMismatchDB
+	if err := tx.SelectContext(ctx, &mismatches, query, from.Format("2006-01-02"), to.Format("2006-01-02")); err != nil {
+		return nil, fmt.Errorf("failed to check monthly spend: %w", err)
+	}
+
+	return mismatches, nil
+}
+
+// spendCoveredUntil returns the last month covered by the aggregate; built is false before the first build
+func spendCoveredUntil(ctx context.Context, tx *sqlx.Tx) (coveredUntil time.Time, built bool, err error) {
+	err = tx.GetContext(ctx, &coveredUntil, fmt.Sprintf("SELECT covered_until FROM %s", spendStateTable))
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to read monthly spend state: %w", err)
+	}
+	return coveredUntil, true, nil
+}
+
+// rebuildSpend replaces the aggregate with the spend of every month up to until
+// Pending changes are dropped first: in READ COMMITTED every statement sees the changes
+// committed before it starts, so those are included in the rebuilt rows
+func rebuildSpend(ctx context.Context, tx *sqlx.Tx, until time.Time) (int64, error) {
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", spendDirtyTable)); err != nil {
+		return 0, fmt.Errorf("failed to clear pending spend changes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", spendTable)); err != nil {
+		return 0, fmt.Errorf("failed to clear monthly spend: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+        INSERT INTO %s (user_id, service_name, month, amount)
+        SELECT c.user_id, c.service_name, c.month, SUM(c.amount)
+        FROM (%s) c
+        GROUP BY c.user_id, c.service_name, c.month
+    `, spendTable, sharesSourceOf(spendSubscriptions, spendFrom, "$1::date"))
+
+	result, err := tx.ExecContext(ctx, query, until.Format("2006-01-02"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build monthly spend: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	stateQuery := fmt.Sprintf(`
+        INSERT INTO %s (covered_until) VALUES ($1::date)
+        ON CONFLICT (id) DO UPDATE SET covered_until = EXCLUDED.covered_until, rebuilt_at = NOW()
+    `, spendStateTable)
+	if _, err := tx.ExecContext(ctx, stateQuery, until.Format("2006-01-02")); err != nil {
+		return 0, fmt.Errorf("failed to update monthly spend state: %w", err)
+	}
+
+	return rows, nil
+}
+
+// extendSpend adds the months after coveredUntil up to until
+// Rows of these months do not exist yet, so they are inserted without touching older months
+func extendSpend(ctx context.Context, tx *sqlx.Tx, coveredUntil, until time.Time) error {
+	query := fmt.Sprintf(`
+        INSERT INTO %s (user_id, service_name, month, amount)
+        SELECT c.user_id, c.service_name, c.month, SUM(c.amount)
+        FROM (%s) c
+        GROUP BY c.user_id, c.service_name, c.month
+    `, spendTable, sharesSourceOf(spendSubscriptions, "($1::date + INTERVAL '1 month')::date", "$2::date"))
+
+	if _, err := tx.ExecContext(ctx, query, coveredUntil.Format("2006-01-02"), until.Format("2006-01-02")); err != nil {
+		return fmt.Errorf("failed to extend monthly spend: %w", err)
+	}
+
+	stateQuery := fmt.Sprintf("UPDATE %s SET covered_until = $1::date", spendStateTable)
+	if _, err := tx.ExecContext(ctx, stateQuery, until.Format("2006-01-02")); err != nil {
+		return fmt.Errorf("failed to update monthly spend state: %w", err)
+	}
+
+	return nil
+}
+
+// spendKey identifies the aggregate rows of a user and service
+type spendKey struct {
+	UserID      string `db:"user_id"`
+	ServiceName string `db:"service_name"`
+}
+
+// refreshDirtySpend recalculates every user and service with pending changes up to until
+// and returns their number. Only subscriptions of these services the users take part in are
+// expanded. Changes committed after the pending keys are taken stay pending for the next run
+func refreshDirtySpend(ctx context.Context, tx *sqlx.Tx, until time.Time) (int, error) {
+	var keys []spendKey
+	takeQuery := fmt.Sprintf(`
+        WITH taken AS (DELETE FROM %s RETURNING user_id, service_name)
+        SELECT DISTINCT user_id, service_name FROM taken
+    `, spendDirtyTable)
+	if err := tx.SelectContext(ctx, &keys, takeQuery); err != nil {
+		return 0, fmt.Errorf("failed to take pending spend changes: %w", err)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	users := make([]string, len(keys))
+	services := make([]string, len(keys))
+	for i, key := range keys {
+		users[i], services[i] = key.UserID, key.ServiceName
+	}
+
+	deleteQuery := fmt.Sprintf(`
+        DELETE FROM %s a
+        USING unnest($1::uuid[], $2::text[]) k(user_id, service_name)
+        WHERE a.user_id = k.user_id AND a.service_name = k.service_name
+    `, spendTable)
+	if _, err := tx.ExecContext(ctx, deleteQuery, pq.Array(users), pq.Array(services)); err != nil {
+		return 0, fmt.Errorf("failed to clear stale monthly spend: %w", err)
+	}
+
+	subscriptions := fmt.Sprintf(`(
+            SELECT s.*
+            FROM %[1]s s
+            WHERE s.organization_id IS NULL AND EXISTS (
+                SELECT 1
+                FROM unnest($1::uuid[], $2::text[]) k(user_id, service_name)
+                WHERE k.service_name = s.service_name AND (
+                    k.user_id = s.user_id OR
+                    EXISTS (SELECT 1 FROM %[2]s m WHERE m.subscription_id = s.id AND m.user_id = k.user_id)
+                )
+            )
+        )`, subscriptionTable, memberTable)
+
+	insertQuery := fmt.Sprintf(`
+        INSERT INTO %s (user_id, service_name, month, amount)
+        SELECT c.user_id, c.service_name, c.month, SUM(c.amount)
+        FROM (%s) c
+        JOIN unnest($1::uuid[], $2::text[]) k(user_id, service_name)
+            ON k.user_id = c.user_id AND k.service_name = c.service_name
+        GROUP BY c.user_id, c.service_name, c.month
+    `, spendTable, sharesSourceOf(subscriptions, spendFrom, "$3::date"))
+	if _, err := tx.ExecContext(ctx, insertQuery, pq.Array(users), pq.Array(services), until.Format("2006-01-02")); err != nil {
+		return 0, fmt.Errorf("failed to refresh monthly spend: %w", err)
+	}
+
+	return len(keys), nil
+}
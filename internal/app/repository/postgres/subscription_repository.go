@@ -1,9 +1,13 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
 	"github.com/jmoiron/sqlx"
@@ -21,78 +25,136 @@ func NewSubscriptionRepository(db *sqlx.DB) *SubscriptionRepository {
 
 // Create inserts a new subscription record into the database
 // Returns the ID of the newly created subscription or an error
-func (r *SubscriptionRepository) Create(subDB models.Subscription) (int, error) {
+// A "subscription.created" event is written to the outbox in the same transaction
+// With RejectOverlaps set, ErrOverlap is returned instead of storing an overlapping subscription
+func (r *SubscriptionRepository) Create(ctx context.Context, subDB models.Subscription) (int, error) {
 	// Begin a database transaction to ensure atomic operation
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 
-	var subId int
-	// Prepare SQL query for subscription insertion with parameter binding
-	// Uses RETURNING clause to get the auto-generated ID
-	createSubQuery := fmt.Sprintf("INSERT INTO %s (service_name, price, user_id, start_date) VALUES ($1, $2, $3, TO_DATE($4, 'MM-YYYY')) RETURNING id", subscriptionTable)
+	if subDB.RejectOverlaps {
+		if _, err := tx.ExecContext(ctx, lockServiceQuery, subDB.UserID, subDB.ServiceName); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to lock subscriptions of the service: %w", err)
+		}
+	}
 
-	// Execute the query within the transaction
-	row := tx.QueryRow(createSubQuery, subDB.ServiceName, subDB.Price, subDB.UserID, subDB.StartDate)
+	var created models.SubscriptionDB
+	// Prepare SQL query for subscription insertion with parameter binding
+	// Uses RETURNING clause to get the stored row including the auto-generated ID
+	createSubQuery := fmt.Sprintf("INSERT INTO %s (service_name, price, user_id, start_date, category, finish_date, billing_cycle, trial_end, price_after_trial, status) VALUES ($1, $2, $3, TO_DATE($4, 'MM-YYYY'), NULLIF($5, ''), TO_DATE(NULLIF($6, ''), 'MM-YYYY'), $7, TO_DATE(NULLIF($8, ''), 'MM-YYYY'), $9, $10) RETURNING *", subscriptionTable)
 
-	// Retrieve the auto-generated ID from the result
-	if err := row.Scan(&subId); err != nil {
+	// Execute the query within the transaction and retrieve the stored row
+	if err := tx.GetContext(ctx, &created, createSubQuery, subDB.ServiceName, subDB.Price, subDB.UserID, subDB.StartDate, subDB.Category, subDB.FinishDate, subDB.BillingCycle, subDB.TrialEnd, subDB.PriceAfterTrial, subDB.Status); err != nil {
 		// Rollback transaction in case of error to maintain data consistency
 		tx.Rollback()
+		if isConstraintViolation(err, "foreign_key_violation", "subscriptions_user_id_fkey") {
+			return 0, ErrUserNotFound
+		}
 		return 0, fmt.Errorf("failed to create subscription: %w", err)
 	}
 
+	if subDB.RejectOverlaps {
+		if err := rejectOverlaps(ctx, tx, created); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	// Record the event so the outbox relay publishes it after commit
+	if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(created.Id), models.EventSubscriptionCreated, created); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
 	// Commit the transaction to persist changes
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return subId, nil
+	return created.Id, nil
 }
 
-// GetAll implements retrieval of all subscriptions (to be implemented)
-func (r *SubscriptionRepository) GetAll() ([]models.SubscriptionDB, error) {
+// selectWithPause returns a query selecting subscriptions together with the start of their open pause
+func selectWithPause() string {
+	return fmt.Sprintf(`
+        SELECT s.*, p.paused_from
+        FROM %s s
+        LEFT JOIN %s p ON p.subscription_id = s.id AND p.resumed_from IS NULL`, subscriptionTable, pauseTable)
+}
+
+// GetAll implements retrieval of all subscriptions, optionally filtered by status, organization
+// and user, in which case subscriptions shared with the user are included; Personal leaves out
+// subscriptions paid by an organization
+func (r *SubscriptionRepository) GetAll(ctx context.Context, filter models.SubscriptionListFilter) ([]models.SubscriptionDB, error) {
 	var subDB []models.SubscriptionDB
 
-	query := fmt.Sprintf("SELECT * FROM %s", subscriptionTable)
-	err := r.db.Select(&subDB, query)
+	query := selectWithPause() + fmt.Sprintf(`
+        WHERE
+            (s.status = $1 OR $1 IS NULL) AND
+            ($2::uuid IS NULL OR s.user_id = $2 OR EXISTS (
+                SELECT 1 FROM %s m WHERE m.subscription_id = s.id AND m.user_id = $2
+            )) AND
+            ($3::int IS NULL OR s.organization_id = $3) AND
+            (NOT $4 OR s.organization_id IS NULL)
+        ORDER BY s.id`, memberTable)
+	err := r.db.SelectContext(ctx, &subDB, query, filter.Status, filter.UserID, filter.OrganizationID, filter.Personal)
 
 	return subDB, err
 }
 
 // GetById implements retrieval of subscription by ID (to be implemented)
-func (r *SubscriptionRepository) GetById(subID int) (models.SubscriptionDB, error) {
+func (r *SubscriptionRepository) GetById(ctx context.Context, subID int) (models.SubscriptionDB, error) {
 
 	var subDB models.SubscriptionDB
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", subscriptionTable)
-	err := r.db.Get(&subDB, query, subID)
+	query := selectWithPause() + " WHERE s.id = $1"
+	err := r.db.GetContext(ctx, &subDB, query, subID)
 
 	return subDB, err
 }
 
-// Delete implements subscription deletion logic (to be implemented)
-func (r *SubscriptionRepository) Delete(subID int) error {
-
-	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", subscriptionTable)
-	result, err := r.db.Exec(query, subID)
+// Delete implements subscription deletion logic
+// A "subscription.deleted" event carrying the removed row is written to the outbox in the same transaction
+func (r *SubscriptionRepository) Delete(ctx context.Context, subID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var deleted models.SubscriptionDB
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 RETURNING *", subscriptionTable)
+	if err := tx.GetContext(ctx, &deleted, query, subID); err != nil {
+		tx.Rollback()
+		//Check if the card has been deleted
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSubscriptionNotFound
+		}
 		return err
 	}
 
-	//Check if the card has been deleted
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return errors.New("card not found")
+	if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(deleted.Id), models.EventSubscriptionDeleted, deleted); err != nil {
+		tx.Rollback()
+		return err
 	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 
 }
 
 // Update implements subscription update logic with partial update support
 // Handles dynamic SQL query generation based on provided fields
-func (r *SubscriptionRepository) Update(subID int, input models.UpdateSubscription) error {
+// A "subscription.updated" event carrying the new row is written to the outbox in the same transaction
+// Date and trial changes of cancelled and expired subscriptions are rejected with ErrStatusConflict.
+// With RejectOverlaps set, ErrOverlap is returned instead of storing an overlapping period,
+// and ErrFixedAmounts is returned if the new price no longer covers the fixed amounts of members
+func (r *SubscriptionRepository) Update(ctx context.Context, subID int, input models.UpdateSubscription) error {
 	// Initialize slices for building dynamic SET clause and arguments
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
@@ -114,18 +176,285 @@ func (r *SubscriptionRepository) Update(subID int, input models.UpdateSubscripti
 
 	}
 
+	// Handle category update if provided (an empty string removes the category)
+	if input.Category != nil {
+		setValues = append(setValues, fmt.Sprintf("category=NULLIF($%d, '')", argId))
+		args = append(args, *input.Category)
+		argId++
+	}
+
+	// Handle finish date update if provided (an empty string makes the subscription open-ended)
+	if input.FinishDate != nil {
+		setValues = append(setValues, fmt.Sprintf("finish_date=TO_DATE(NULLIF($%d, ''), 'MM-YYYY')", argId))
+		args = append(args, *input.FinishDate)
+		argId++
+	}
+
+	// Handle billing cycle update if provided
+	if input.BillingCycle != nil {
+		setValues = append(setValues, fmt.Sprintf("billing_cycle=$%d", argId))
+		args = append(args, *input.BillingCycle)
+		argId++
+	}
+
+	// Handle trial end update if provided (an empty string removes the trial)
+	// Moving the trial resets the conversion marker so the new end is tracked again
+	if input.TrialEnd != nil {
+		setValues = append(setValues, fmt.Sprintf("trial_end=TO_DATE(NULLIF($%d, ''), 'MM-YYYY'), trial_converted_at=NULL", argId))
+		args = append(args, *input.TrialEnd)
+		argId++
+
+		if *input.TrialEnd == "" && input.PriceAfterTrial == nil {
+			setValues = append(setValues, "price_after_trial=NULL")
+		}
+	}
+
+	// Handle price after trial update if provided
+	if input.PriceAfterTrial != nil {
+		setValues = append(setValues, fmt.Sprintf("price_after_trial=$%d", argId))
+		args = append(args, *input.PriceAfterTrial)
+		argId++
+	}
+
 	// Join SET clauses with commas
 	setQuery := strings.Join(setValues, ", ")
 
 	// Build final SQL query with WHERE clause
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", subscriptionTable, setQuery, argId)
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d RETURNING *", subscriptionTable, setQuery, argId)
 
 	// Add subscription ID as the last parameter
 	args = append(args, subID)
 
-	// Execute the query
-	_, err := r.db.Exec(query, args...)
-	return err
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Cancelled and expired subscriptions keep their final period
+	if input.StartDate != nil || input.FinishDate != nil || input.TrialEnd != nil || input.PriceAfterTrial != nil {
+		var status string
+		statusQuery := fmt.Sprintf("SELECT status FROM %s WHERE id = $1 FOR UPDATE", subscriptionTable)
+		if err := tx.GetContext(ctx, &status, statusQuery, subID); err != nil {
+			tx.Rollback()
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrSubscriptionNotFound
+			}
+			return fmt.Errorf("failed to lock subscription: %w", err)
+		}
+		if status == models.StatusCancelled || status == models.StatusExpired {
+			tx.Rollback()
+			return fmt.Errorf("%w: dates of a %s subscription cannot be changed", ErrStatusConflict, status)
+		}
+	}
+
+	// The owner and service of a subscription never change, so the stored ones select the lock
+	if input.RejectOverlaps {
+		lockQuery := fmt.Sprintf("SELECT pg_advisory_xact_lock(hashtext(user_id::text || ':' || LOWER(service_name))) FROM %s WHERE id = $1", subscriptionTable)
+		if _, err := tx.ExecContext(ctx, lockQuery, subID); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to lock subscriptions of the service: %w", err)
+		}
+	}
+
+	// Execute the query and capture the updated row for the event payload
+	var updated models.SubscriptionDB
+	if err := tx.GetContext(ctx, &updated, query, args...); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSubscriptionNotFound
+		}
+		return err
+	}
+
+	if input.RejectOverlaps {
+		if err := rejectOverlaps(ctx, tx, updated); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// A lower price must still cover the fixed amounts of the members
+	if input.Price != nil || input.TrialEnd != nil || input.PriceAfterTrial != nil {
+		if err := checkFixedAmounts(ctx, tx, subID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(updated.Id), models.EventSubscriptionUpdated, updated); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ErrOverlap is returned by create and update when overlap rejection is requested
+// and the stored period intersects another subscription of the same user and service
+var ErrOverlap = errors.New("overlaps subscription")
+
+// lockServiceQuery takes the advisory lock serializing writes of one user and service (compared
+// case-insensitively), so that two concurrent writes cannot both miss each other's overlap
+const lockServiceQuery = "SELECT pg_advisory_xact_lock(hashtext($1::text || ':' || LOWER($2)))"
+
+// rejectOverlaps returns ErrOverlap listing the subscriptions that overlap the stored row
+func rejectOverlaps(ctx context.Context, tx *sqlx.Tx, sub models.SubscriptionDB) error {
+	overlaps, err := findOverlaps(ctx, tx, models.OverlapFilter{
+		UserID:      sub.UserID.String(),
+		ServiceName: sub.ServiceName,
+		StartDate:   sub.StartDate,
+		FinishDate:  sub.FinishDate,
+		ExcludeID:   sub.Id,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check for overlapping subscriptions: %w", err)
+	}
+	if len(overlaps) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(overlaps))
+	for _, overlap := range overlaps {
+		ids = append(ids, strconv.Itoa(overlap.Id))
+	}
+	return fmt.Errorf("%w %s", ErrOverlap, strings.Join(ids, ", "))
+}
+
+// FindOverlaps returns personal subscriptions of the same user and service whose periods intersect the filter period
+// Service names are compared case-insensitively and NULL finish dates are treated as open-ended
+func (r *SubscriptionRepository) FindOverlaps(ctx context.Context, filter models.OverlapFilter) ([]models.SubscriptionDB, error) {
+	return findOverlaps(ctx, r.db, filter)
+}
+
+// findOverlaps runs the FindOverlaps query on the database or within a transaction
+func findOverlaps(ctx context.Context, q sqlx.QueryerContext, filter models.OverlapFilter) ([]models.SubscriptionDB, error) {
+	query := fmt.Sprintf(`
+        SELECT *
+        FROM %s
+        WHERE
+            user_id = $1 AND
+            organization_id IS NULL AND
+            LOWER(service_name) = LOWER($2) AND
+            id <> $3 AND
+            start_date < COALESCE($4::date, 'infinity'::date) AND
+            $5::date < COALESCE(finish_date, 'infinity'::date)
+        ORDER BY start_date, id
+    `, subscriptionTable)
+
+	var subsDB []models.SubscriptionDB
+	err := sqlx.SelectContext(ctx, q, &subsDB, query, filter.UserID, filter.ServiceName, filter.ExcludeID, formatDate(filter.FinishDate), filter.StartDate.Format("2006-01-02"))
+
+	return subsDB, err
+}
+
+// GetDuplicates returns every personal subscription that overlaps another personal subscription
+// of the same user and service. Rows are ordered so that members of one duplicate group are adjacent
+func (r *SubscriptionRepository) GetDuplicates(ctx context.Context) ([]models.SubscriptionDB, error) {
+	query := fmt.Sprintf(`
+        SELECT s.*
+        FROM %[1]s s
+        WHERE s.organization_id IS NULL AND EXISTS (
+            SELECT 1
+            FROM %[1]s o
+            WHERE
+                o.id <> s.id AND
+                o.organization_id IS NULL AND
+                o.user_id = s.user_id AND
+                LOWER(o.service_name) = LOWER(s.service_name) AND
+                o.start_date < COALESCE(s.finish_date, 'infinity'::date) AND
+                s.start_date < COALESCE(o.finish_date, 'infinity'::date)
+        )
+        ORDER BY s.user_id, LOWER(s.service_name), s.start_date, s.id
+    `, subscriptionTable)
+
+	var subsDB []models.SubscriptionDB
+	err := r.db.SelectContext(ctx, &subsDB, query)
+
+	return subsDB, err
+}
+
+// chargesSource returns a subquery that expands subscriptions into one row per billed month
+// from and to are SQL expressions for the first and last month of the period (inclusive).
+// Billing starts with the start month, or the month after trial_end for trials,
+// repeats every BillingCycleMonths and stops before the finish date
+// (finish_date is exclusive, NULL means open-ended). Trial months are never charged
+// and charges after a trial use price_after_trial when it is set.
+// Months covered by a pause are skipped without shifting the billing cycle
+func chargesSource(from, to string) string {
+	return chargesSourceOf(subscriptionTable, from, to)
+}
+
+// chargesSourceOf is chargesSource limited to the subscriptions selected by an SQL table expression
+func chargesSourceOf(subscriptions, from, to string) string {
+	return fmt.Sprintf(`
+        SELECT s.id AS subscription_id, s.user_id, s.service_name, s.category, s.status, s.organization_id,
+               COALESCE(s.price_after_trial, s.price) AS amount, m.month::date AS month
+        FROM %[1]s s
+        CROSS JOIN LATERAL (
+            SELECT COALESCE(s.trial_end + INTERVAL '1 month', s.start_date)::date AS billing_start
+        ) b
+        CROSS JOIN LATERAL generate_series(
+            GREATEST(b.billing_start, %[2]s)::timestamp,
+            LEAST(COALESCE(s.finish_date - INTERVAL '1 month', %[3]s), %[3]s)::timestamp,
+            INTERVAL '1 month'
+        ) AS m(month)
+        WHERE ((EXTRACT(YEAR FROM m.month) - EXTRACT(YEAR FROM b.billing_start)) * 12 +
+               (EXTRACT(MONTH FROM m.month) - EXTRACT(MONTH FROM b.billing_start)))::int
+              %% (CASE s.billing_cycle WHEN 'quarterly' THEN 3 WHEN 'yearly' THEN 12 ELSE 1 END) = 0
+          AND NOT EXISTS (
+              SELECT 1
+              FROM %[4]s p
+              WHERE p.subscription_id = s.id
+                AND m.month >= p.paused_from
+                AND (p.resumed_from IS NULL OR m.month < p.resumed_from)
+          )`,
+		subscriptions, from, to, pauseTable)
+}
+
+// sharesSource returns a query splitting every charge of chargesSource between the participants
+// of the subscription: members with a fixed amount pay it, the rest of the charge is divided by
+// share weight among the other members and the owner (weight 1 unless listed as a member).
+// Rounding differences are assigned to the owner, so the shares of a charge always add up to it.
+// Fixed amounts exceeding the charge (e.g. stored before a price change) are reduced proportionally,
+// so the owner's share is never negative
+func sharesSource(from, to string) string {
+	return sharesSourceOf(subscriptionTable, from, to)
+}
+
+// sharesSourceOf is sharesSource limited to the subscriptions selected by an SQL table expression
+func sharesSourceOf(subscriptions, from, to string) string {
+	return fmt.Sprintf(`
+        SELECT x.subscription_id, x.owner_id, x.user_id, x.service_name, x.category, x.status, x.organization_id, x.month,
+               x.share + CASE WHEN x.user_id = x.owner_id
+                              THEN x.charge - SUM(x.share) OVER (PARTITION BY x.subscription_id, x.month)
+                              ELSE 0 END AS amount
+        FROM (
+            SELECT c.subscription_id, c.user_id AS owner_id, p.user_id, c.service_name, c.category, c.status,
+                   c.organization_id, c.month, c.amount AS charge,
+                   COALESCE(LEAST(p.fixed_amount, p.fixed_amount * c.amount / NULLIF(p.fixed_total, 0)),
+                            GREATEST(c.amount - p.fixed_total, 0) * p.share_weight / p.weight_total) AS share
+            FROM (%[1]s) c
+            JOIN (
+                SELECT q.*,
+                       COALESCE(SUM(q.fixed_amount) OVER w, 0) AS fixed_total,
+                       NULLIF(COALESCE(SUM(q.share_weight) OVER w, 0), 0) AS weight_total
+                FROM (
+                    SELECT subscription_id, user_id, share_weight, fixed_amount FROM %[2]s
+                    UNION ALL
+                    SELECT s.id, s.user_id, 1, NULL
+                    FROM %[3]s s
+                    WHERE NOT EXISTS (
+                        SELECT 1 FROM %[2]s m WHERE m.subscription_id = s.id AND m.user_id = s.user_id
+                    )
+                ) q
+                WINDOW w AS (PARTITION BY q.subscription_id)
+            ) p ON p.subscription_id = c.subscription_id
+        ) x`,
+		chargesSourceOf(subscriptions, from, to), memberTable, subscriptionTable)
 }
 
 // TotalCostResult holds the total cost result from the database query
@@ -134,22 +463,272 @@ type TotalCostResult struct {
 }
 
 // GetSubscriptionSummary calculates total subscription cost based on filters
-func (r *SubscriptionRepository) GetSubscriptionSummary(filter models.SubscriptionFilter) (int, error) {
+// Sums every charge billed within the period, so a monthly subscription active
+// for the whole period is counted once per month. Filtered by user, only the user's
+// shares of shared subscriptions are counted, including subscriptions they are a member of
+// Without an organization filter only personal subscriptions are counted
+// Summaries filtered by user and service only are read from the monthly_spend aggregate
+// when it is up to date, other summaries expand the raw subscriptions
+func (r *SubscriptionRepository) GetSubscriptionSummary(ctx context.Context, filter models.SubscriptionFilter) (int, error) {
+	if filter.Filters.Category == nil && filter.Filters.Status == nil && filter.Filters.OrganizationID == nil {
+		total, ok, err := r.aggregatedSummary(ctx, filter)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return total, nil
+		}
+	}
+
 	query := fmt.Sprintf(`
-        SELECT COALESCE(SUM(price), 0) AS total_cost
-        FROM %s
+        SELECT COALESCE(SUM(c.amount), 0) AS total_cost
+        FROM (%s) c
         WHERE 
-            (user_id = $1 OR $1 IS NULL) AND
-            (service_name = $2 OR $2 IS NULL) AND
-            start_date <= TO_DATE($4, 'MM-YYYY') AND 
-            finish_date >= TO_DATE($3, 'MM-YYYY')
-    `, subscriptionTable)
+            (c.user_id = $1 OR $1 IS NULL) AND
+            (c.service_name = $2 OR $2 IS NULL) AND
+            (c.category = $5 OR $5 IS NULL) AND
+            (c.status = $6 OR $6 IS NULL) AND
+            c.organization_id IS NOT DISTINCT FROM $7::int
+    `, sharesSource("TO_DATE($3, 'MM-YYYY')", "TO_DATE($4, 'MM-YYYY')"))
 
 	var result TotalCostResult
-	err := r.db.Get(&result, query, filter.Filters.UserID, filter.Filters.ServiceName, filter.Period.StartDate, filter.Period.FinishDate)
+	err := r.db.GetContext(ctx, &result, query, filter.Filters.UserID, filter.Filters.ServiceName, filter.Period.StartDate, filter.Period.FinishDate, filter.Filters.Category, filter.Filters.Status, filter.Filters.OrganizationID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to calculate total cost: %w", err)
 	}
 
 	return result.TotalCost, err
 }
+
+// aggregatedSummary sums the monthly_spend rows of the period
+// Periods always consist of whole months, so the aggregate can answer them as long as it
+// covers the last month and none of the matching users and services has pending changes.
+// Both conditions are checked by the same statement, i.e. against the same snapshot as the
+// sum; ok is false when the summary has to be calculated from raw data
+func (r *SubscriptionRepository) aggregatedSummary(ctx context.Context, filter models.SubscriptionFilter) (total int, ok bool, err error) {
+	query := fmt.Sprintf(`
+        SELECT CASE WHEN
+            EXISTS (SELECT 1 FROM %[1]s WHERE covered_until >= TO_DATE($4, 'MM-YYYY')) AND
+            NOT EXISTS (
+                SELECT 1 FROM %[2]s d
+                WHERE (d.user_id = $1 OR $1 IS NULL) AND (d.service_name = $2 OR $2 IS NULL)
+            )
+        THEN (
+            SELECT COALESCE(SUM(a.amount), 0)
+            FROM %[3]s a
+            WHERE
+                a.month BETWEEN TO_DATE($3, 'MM-YYYY') AND TO_DATE($4, 'MM-YYYY') AND
+                (a.user_id = $1 OR $1 IS NULL) AND
+                (a.service_name = $2 OR $2 IS NULL)
+        ) END
+    `, spendStateTable, spendDirtyTable, spendTable)
+
+	var result sql.NullInt64
+	if err := r.db.GetContext(ctx, &result, query, filter.Filters.UserID, filter.Filters.ServiceName, filter.Period.StartDate, filter.Period.FinishDate); err != nil {
+		return 0, false, fmt.Errorf("failed to calculate total cost: %w", err)
+	}
+
+	return int(result.Int64), result.Valid, nil
+}
+
+// GetCharges returns every charge billed within the period ordered by month
+// Filtered by user, the user's shares of shared subscriptions are returned instead of full charges
+// Charges always expand the raw subscriptions, even when monthly_spend covers the period:
+// the aggregate keeps one sum per user, service and month, while forecasts, settlements and
+// reports list every charge with its subscription and settlements need the payer of each one
+// Charges of subscriptions paid by an organization are left out
+func (r *SubscriptionRepository) GetCharges(ctx context.Context, filter models.ChargeFilter) ([]models.ChargeDB, error) {
+	source := chargesSource("$2::date", "$3::date")
+	if filter.UserID != nil {
+		source = sharesSource("$2::date", "$3::date")
+	}
+
+	query := fmt.Sprintf(`
+        SELECT c.subscription_id, c.user_id, c.service_name, c.month, c.amount
+        FROM (%s) c
+        WHERE c.organization_id IS NULL AND (c.user_id = $1 OR $1 IS NULL)
+        ORDER BY c.month, c.subscription_id
+    `, source)
+
+	var charges []models.ChargeDB
+	if err := r.db.SelectContext(ctx, &charges, query, filter.UserID, filter.From.Format("2006-01-02"), filter.To.Format("2006-01-02")); err != nil {
+		return nil, fmt.Errorf("failed to calculate charges: %w", err)
+	}
+
+	return charges, nil
+}
+
+// GetEndingTrials returns unconverted personal trials whose last free month falls within the period
+func (r *SubscriptionRepository) GetEndingTrials(ctx context.Context, from, to time.Time) ([]models.SubscriptionDB, error) {
+	query := fmt.Sprintf(`
+        SELECT *
+        FROM %s
+        WHERE
+            organization_id IS NULL AND
+            trial_end BETWEEN $1::date AND $2::date AND
+            trial_converted_at IS NULL
+        ORDER BY trial_end, id
+    `, subscriptionTable)
+
+	var subsDB []models.SubscriptionDB
+	err := r.db.SelectContext(ctx, &subsDB, query, from.Format("2006-01-02"), to.Format("2006-01-02"))
+
+	return subsDB, err
+}
+
+// ConvertEndedTrials marks trials that ended before the given month as converted to paid
+// A "subscription.trial_converted" event is written to the outbox for every converted row
+// in the same transaction, so each conversion is announced exactly once
+func (r *SubscriptionRepository) ConvertEndedTrials(ctx context.Context, month time.Time) ([]models.SubscriptionDB, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+        UPDATE %s
+        SET
+            trial_converted_at = NOW(),
+            status = CASE WHEN status = 'trial' THEN 'active' ELSE status END
+        WHERE
+            trial_end < $1::date AND
+            trial_converted_at IS NULL
+        RETURNING *
+    `, subscriptionTable)
+
+	var converted []models.SubscriptionDB
+	if err := tx.SelectContext(ctx, &converted, query, month.Format("2006-01-02")); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to convert ended trials: %w", err)
+	}
+
+	for _, sub := range converted {
+		if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(sub.Id), models.EventTrialConverted, sub); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return converted, nil
+}
+
+// expiryLock names the advisory lock that serializes expiry runs across replicas
+const expiryLock = "subscription-expiry"
+
+// ExpireEnded marks subscriptions whose exclusive finish date is not after the given month as expired
+// Only one replica expires subscriptions at a time: when the advisory lock is held elsewhere
+// the call returns without changes. A "subscription.expired" event is written for every row
+func (r *SubscriptionRepository) ExpireEnded(ctx context.Context, month time.Time) ([]models.SubscriptionDB, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer tx.Rollback()
+
+	locked, err := tryAdvisoryLock(ctx, tx, expiryLock)
+	if err != nil || !locked {
+		return nil, err
+	}
+
+	// Cancelled subscriptions keep their terminal status when their last month passes
+	query := fmt.Sprintf(`
+        UPDATE %s
+        SET status = 'expired'
+        WHERE
+            finish_date <= $1::date AND
+            status NOT IN ('cancelled', 'expired')
+        RETURNING *
+    `, subscriptionTable)
+
+	var expired []models.SubscriptionDB
+	if err := tx.SelectContext(ctx, &expired, query, month.Format("2006-01-02")); err != nil {
+		return nil, fmt.Errorf("failed to expire ended subscriptions: %w", err)
+	}
+
+	for _, sub := range expired {
+		if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(sub.Id), models.EventSubscriptionExpired, sub); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return expired, nil
+}
+
+// ErrStatusConflict is returned when the subscription no longer has the status a transition expects
+var ErrStatusConflict = errors.New("subscription status was changed concurrently")
+
+// ErrSubscriptionNotFound is returned when a change targets a subscription that does not exist
+var ErrSubscriptionNotFound = errors.New("card not found")
+
+// ChangeStatus applies a lifecycle transition if the subscription still has the expected status
+// The optional event of the change is written to the outbox in the same transaction
+func (r *SubscriptionRepository) ChangeStatus(ctx context.Context, subID int, change models.StatusChange) (models.SubscriptionDB, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.SubscriptionDB{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Cancellation details and the finish date are only overwritten when provided
+	query := fmt.Sprintf(`
+        UPDATE %s
+        SET
+            status = $1,
+            cancelled_at = COALESCE($2::date, cancelled_at),
+            cancellation_reason = COALESCE($3, cancellation_reason),
+            finish_date = COALESCE($4::date, finish_date)
+        WHERE id = $5 AND status = $6
+        RETURNING *
+    `, subscriptionTable)
+
+	var updated models.SubscriptionDB
+	if err := tx.GetContext(ctx, &updated, query, change.To, formatDate(change.CancelledAt), change.Reason, formatDate(change.FinishDate), subID, change.From); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SubscriptionDB{}, ErrStatusConflict
+		}
+		return models.SubscriptionDB{}, fmt.Errorf("failed to change subscription status: %w", err)
+	}
+
+	if change.EventType != "" {
+		if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(subID), change.EventType, updated); err != nil {
+			tx.Rollback()
+			return models.SubscriptionDB{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.SubscriptionDB{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updated, nil
+}
+
+// formatDate converts an optional date into a query parameter (NULL when nil)
+func formatDate(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format("2006-01-02")
+	return &formatted
+}
+
+// CountByStatus returns the number of subscriptions in each lifecycle status
+func (r *SubscriptionRepository) CountByStatus(ctx context.Context) ([]models.StatusCountDB, error) {
+	query := fmt.Sprintf("SELECT status, COUNT(*) AS count FROM %s GROUP BY status ORDER BY status", subscriptionTable)
+
+	var counts []models.StatusCountDB
+	if err := r.db.SelectContext(ctx, &counts, query); err != nil {
+		return nil, fmt.Errorf("failed to count subscriptions by status: %w", err)
+	}
+
+	return counts, nil
+}
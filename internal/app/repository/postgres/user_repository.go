@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Errors returned for missing users and users that cannot be removed
+var (
+	ErrUserNotFound         = errors.New("user not found")
+	ErrUserExists           = errors.New("user already exists")
+	ErrUserHasSubscriptions = errors.New("user still owns subscriptions")
+)
+
+// isConstraintViolation reports whether err is a Postgres error of the given class raised by the given constraint
+func isConstraintViolation(err error, code, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == code && pqErr.Constraint == constraint
+}
+
+// UserRepository implements UserStore for PostgreSQL
+type UserRepository struct {
+	db *sqlx.DB
+}
+
+// NewUserRepository creates a new user repository instance
+func NewUserRepository(db *sqlx.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Create inserts a new user; the ID is generated by the database when not set
+// A "user.created" event is written to the outbox in the same transaction
+func (r *UserRepository) Create(ctx context.Context, user models.UserDB) (models.UserDB, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.UserDB{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var created models.UserDB
+	query := fmt.Sprintf(`
+        INSERT INTO %s (id, display_name, timezone, currency)
+        VALUES (COALESCE($1, uuid_generate_v4()), $2, $3, $4)
+        RETURNING *
+    `, userTable)
+
+	var id *string
+	if user.Id != uuid.Nil {
+		formatted := user.Id.String()
+		id = &formatted
+	}
+	if err := tx.GetContext(ctx, &created, query, id, user.DisplayName, user.Timezone, user.Currency); err != nil {
+		tx.Rollback()
+		if isConstraintViolation(err, "unique_violation", "users_pkey") {
+			return models.UserDB{}, ErrUserExists
+		}
+		return models.UserDB{}, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateUser, created.Id.String(), models.EventUserCreated, created); err != nil {
+		tx.Rollback()
+		return models.UserDB{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.UserDB{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetById returns a single user
+func (r *UserRepository) GetById(ctx context.Context, userID string) (models.UserDB, error) {
+	var user models.UserDB
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", userTable)
+	err := r.db.GetContext(ctx, &user, query, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user, ErrUserNotFound
+	}
+
+	return user, err
+}
+
+// Update applies a partial update to the user's profile
+// A "user.updated" event carrying the new row is written to the outbox in the same transaction
+func (r *UserRepository) Update(ctx context.Context, userID string, input models.UpdateUser) error {
+	setValues := []string{"updated_at=NOW()"}
+	args := make([]interface{}, 0)
+	argId := 1 // Positional parameter counter
+
+	if input.DisplayName != nil {
+		setValues = append(setValues, fmt.Sprintf("display_name=$%d", argId))
+		args = append(args, *input.DisplayName)
+		argId++
+	}
+
+	if input.Timezone != nil {
+		setValues = append(setValues, fmt.Sprintf("timezone=$%d", argId))
+		args = append(args, *input.Timezone)
+		argId++
+	}
+
+	if input.Currency != nil {
+		setValues = append(setValues, fmt.Sprintf("currency=$%d", argId))
+		args = append(args, *input.Currency)
+		argId++
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d RETURNING *", userTable, strings.Join(setValues, ", "), argId)
+	args = append(args, userID)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var updated models.UserDB
+	if err := tx.GetContext(ctx, &updated, query, args...); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateUser, userID, models.EventUserUpdated, updated); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a user together with their budgets and memberships
+// Owned subscriptions block the deletion unless cascade is set, in which case personal ones are
+// deleted as well and a "subscription.deleted" event is written for each of them. Subscriptions
+// paid by an organization are never deleted this way: they keep blocking the deletion until an
+// organization admin detaches them
+func (r *UserRepository) Delete(ctx context.Context, userID string, cascade bool) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if cascade {
+		var deletedSubs []models.SubscriptionDB
+		subsQuery := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND organization_id IS NULL RETURNING *", subscriptionTable)
+		if err := tx.SelectContext(ctx, &deletedSubs, subsQuery, userID); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to delete subscriptions of user: %w", err)
+		}
+
+		for _, sub := range deletedSubs {
+			if err := insertOutboxEvent(ctx, tx, models.AggregateSubscription, strconv.Itoa(sub.Id), models.EventSubscriptionDeleted, sub); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	var deleted models.UserDB
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 RETURNING *", userTable)
+	if err := tx.GetContext(ctx, &deleted, query, userID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		if isConstraintViolation(err, "foreign_key_violation", "subscriptions_user_id_fkey") {
+			if cascade {
+				return fmt.Errorf("%w paid by an organization", ErrUserHasSubscriptions)
+			}
+			return ErrUserHasSubscriptions
+		}
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	if err := insertOutboxEvent(ctx, tx, models.AggregateUser, userID, models.EventUserDeleted, deleted); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
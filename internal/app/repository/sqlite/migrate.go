@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+
+	sqlitemigrations "github.com/evgeney-fullstack/subscription-aggregator-app/migrations/sqlite"
+	"github.com/golang-migrate/migrate/v4"
+	migratesqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jmoiron/sqlx"
+)
+
+// RunMigrations applies all pending embedded SQLite migrations
+func RunMigrations(db *sqlx.DB) error {
+	src, err := iofs.New(sqlitemigrations.FS, ".")
+	if err != nil {
+		return fmt.Errorf("failed to open embedded migrations: %w", err)
+	}
+	// The migration driver closes the database it wraps, so only the source is closed
+	defer src.Close()
+
+	driver, err := migratesqlite.WithInstance(db.DB, &migratesqlite.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to initialize migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "sqlite", driver)
+	if err != nil {
+		return fmt.Errorf("failed to initialize migrations: %w", err)
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
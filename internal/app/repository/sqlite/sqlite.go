@@ -0,0 +1,113 @@
+// Package sqlite implements the subscription store on an embedded SQLite database
+// It uses a pure-Go driver, so the binary is built without cgo. The schema and queries
+// mirror the PostgreSQL repository and share its models and errors
+// Only subscriptions are implemented, so the server is not wired to this store yet
+package sqlite
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const (
+	subscriptionTable = "subscriptions"        // Database table name for subscriptions
+	outboxTable       = "outbox"               // Database table name for pending integration events
+	pauseTable        = "subscription_pauses"  // Database table name for subscription pause intervals
+	memberTable       = "subscription_members" // Database table name for users sharing a subscription
+)
+
+// MemoryPath opens a private in-memory database that disappears when it is closed
+const MemoryPath = ":memory:"
+
+// busyTimeout is how long a statement waits for another process holding the database file
+const busyTimeout = 5 * time.Second
+
+// monthLayouts maps the TO_DATE formats used by the queries to Go layouts
+var monthLayouts = map[string]string{
+	"MM-YYYY": "1-2006",
+}
+
+func init() {
+	// TO_DATE and LOWER behave like in PostgreSQL, so the queries of both stores read the same
+	if err := sqlite.RegisterDeterministicScalarFunction("to_date", 2, toDate); err != nil {
+		panic(err)
+	}
+	if err := sqlite.RegisterDeterministicScalarFunction("lower", 1, lower); err != nil {
+		panic(err)
+	}
+}
+
+// toDate implements TO_DATE(value, format) for the formats in monthLayouts
+// It returns the first day of the month as stored in DATE columns; NULL stays NULL
+func toDate(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	value, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("to_date: expected a text value, got %T", args[0])
+	}
+	format, _ := args[1].(string)
+	layout, ok := monthLayouts[format]
+	if !ok {
+		return nil, fmt.Errorf("to_date: unsupported format %q", format)
+	}
+
+	date, err := time.Parse(layout, value)
+	if err != nil {
+		return nil, fmt.Errorf("to_date: invalid value %q for format %s", value, format)
+	}
+	return date.Format(time.DateOnly), nil
+}
+
+// lower replaces the built-in LOWER, which only folds ASCII letters, so service names
+// in any alphabet are compared case-insensitively like in PostgreSQL
+func lower(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	switch value := args[0].(type) {
+	case string:
+		return strings.ToLower(value), nil
+	case []byte:
+		return strings.ToLower(string(value)), nil
+	default:
+		return value, nil
+	}
+}
+
+// Open opens the database file at path, or a private in-memory database for MemoryPath
+// Foreign keys are enforced. The pool holds a single connection: SQLite serializes writers
+// anyway, and an in-memory database exists only within its connection
+func Open(path string) (*sqlx.DB, error) {
+	dsn := fmt.Sprintf("%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(%d)", path, busyTimeout.Milliseconds())
+
+	db, err := sqlx.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+// isConstraintViolation reports whether err is a violation of the given extended constraint code
+func isConstraintViolation(err error, code int) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == code
+}
+
+// isForeignKeyViolation reports whether err is a foreign key violation
+// SQLite does not name the violated constraint, so callers must know which one it can be
+func isForeignKeyViolation(err error) bool {
+	return isConstraintViolation(err, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY)
+}
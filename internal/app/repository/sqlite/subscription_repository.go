@@ -0,0 +1,705 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// SubscriptionRepository implements SubscriptionStore for SQLite
+// Queries follow the PostgreSQL repository; dates are compared as 'YYYY-MM-DD' text
+type SubscriptionRepository struct {
+	db *sqlx.DB
+}
+
+var _ postgres.SubscriptionStore = (*SubscriptionRepository)(nil)
+
+// NewSubscriptionRepository creates a new subscription repository instance
+func NewSubscriptionRepository(db *sqlx.DB) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+// openEnded stands in for PostgreSQL 'infinity' when comparing open-ended finish dates
+const openEnded = "9999-12-31"
+
+// insertOutboxEventContext records an integration event within the caller's transaction
+func insertOutboxEventContext(ctx context.Context, tx *sqlx.Tx, aggregateType, aggregateID, eventType string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode outbox payload: %w", err)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (aggregate_type, aggregate_id, event_type, payload) VALUES ($1, $2, $3, $4)", outboxTable)
+	if _, err := tx.ExecContext(ctx, query, aggregateType, aggregateID, eventType, string(body)); err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// userParam normalizes a user ID the way the PostgreSQL uuid type does, so IDs
+// differing only in case match; values that are not UUIDs are passed as is
+func userParam(userID *string) *string {
+	if userID == nil {
+		return nil
+	}
+	parsed, err := uuid.Parse(*userID)
+	if err != nil {
+		return userID
+	}
+	normalized := parsed.String()
+	return &normalized
+}
+
+// formatDate converts an optional date into a query parameter (NULL when nil)
+func formatDate(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.DateOnly)
+	return &formatted
+}
+
+// Create inserts a new subscription record into the database
+// Returns the ID of the newly created subscription or an error
+// A "subscription.created" event is written to the outbox in the same transaction
+// With RejectOverlaps set, postgres.ErrOverlap is returned instead of storing an overlapping period
+func (r *SubscriptionRepository) Create(ctx context.Context, subDB models.Subscription) (int, error) {
+	userID, err := uuid.Parse(subDB.UserID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var created models.SubscriptionDB
+	createSubQuery := fmt.Sprintf("INSERT INTO %s (service_name, price, user_id, start_date, category, finish_date, billing_cycle, trial_end, price_after_trial, status) VALUES ($1, $2, $3, TO_DATE($4, 'MM-YYYY'), NULLIF($5, ''), TO_DATE(NULLIF($6, ''), 'MM-YYYY'), $7, TO_DATE(NULLIF($8, ''), 'MM-YYYY'), $9, $10) RETURNING *", subscriptionTable)
+
+	if err := tx.GetContext(ctx, &created, createSubQuery, subDB.ServiceName, subDB.Price, userID.String(), subDB.StartDate, subDB.Category, subDB.FinishDate, subDB.BillingCycle, subDB.TrialEnd, subDB.PriceAfterTrial, subDB.Status); err != nil {
+		tx.Rollback()
+		// The owner is the only foreign key set on insert
+		if isForeignKeyViolation(err) {
+			return 0, postgres.ErrUserNotFound
+		}
+		return 0, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	if subDB.RejectOverlaps {
+		if err := rejectOverlaps(ctx, tx, created); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	if err := insertOutboxEventContext(ctx, tx, models.AggregateSubscription, strconv.Itoa(created.Id), models.EventSubscriptionCreated, created); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created.Id, nil
+}
+
+// selectWithPause returns a query selecting subscriptions together with the start of their open pause
+func selectWithPause() string {
+	return fmt.Sprintf(`
+        SELECT s.*, p.paused_from
+        FROM %s s
+        LEFT JOIN %s p ON p.subscription_id = s.id AND p.resumed_from IS NULL`, subscriptionTable, pauseTable)
+}
+
+// GetAll implements retrieval of all subscriptions, optionally filtered by status, organization
+// and user, in which case subscriptions shared with the user are included; Personal leaves out
+// subscriptions paid by an organization
+func (r *SubscriptionRepository) GetAll(ctx context.Context, filter models.SubscriptionListFilter) ([]models.SubscriptionDB, error) {
+	var subDB []models.SubscriptionDB
+
+	query := selectWithPause() + fmt.Sprintf(`
+        WHERE
+            (s.status = $1 OR $1 IS NULL) AND
+            ($2 IS NULL OR s.user_id = $2 OR EXISTS (
+                SELECT 1 FROM %s m WHERE m.subscription_id = s.id AND m.user_id = $2
+            )) AND
+            ($3 IS NULL OR s.organization_id = $3) AND
+            (NOT $4 OR s.organization_id IS NULL)
+        ORDER BY s.id`, memberTable)
+	err := r.db.SelectContext(ctx, &subDB, query, filter.Status, userParam(filter.UserID), filter.OrganizationID, filter.Personal)
+
+	return subDB, err
+}
+
+// GetById implements retrieval of subscription by ID
+func (r *SubscriptionRepository) GetById(ctx context.Context, subID int) (models.SubscriptionDB, error) {
+	var subDB models.SubscriptionDB
+
+	query := selectWithPause() + " WHERE s.id = $1"
+	err := r.db.GetContext(ctx, &subDB, query, subID)
+
+	return subDB, err
+}
+
+// Delete implements subscription deletion logic
+// A "subscription.deleted" event carrying the removed row is written to the outbox in the same transaction
+func (r *SubscriptionRepository) Delete(ctx context.Context, subID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	var deleted models.SubscriptionDB
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 RETURNING *", subscriptionTable)
+	if err := tx.GetContext(ctx, &deleted, query, subID); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return postgres.ErrSubscriptionNotFound
+		}
+		return err
+	}
+
+	if err := insertOutboxEventContext(ctx, tx, models.AggregateSubscription, strconv.Itoa(deleted.Id), models.EventSubscriptionDeleted, deleted); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Update implements subscription update logic with partial update support
+// A "subscription.updated" event carrying the new row is written to the outbox in the same transaction
+// Date and trial changes of cancelled and expired subscriptions are rejected with postgres.ErrStatusConflict.
+// With RejectOverlaps set, postgres.ErrOverlap is returned instead of storing an overlapping period,
+// and postgres.ErrFixedAmounts is returned if the new price no longer covers the fixed amounts of members
+func (r *SubscriptionRepository) Update(ctx context.Context, subID int, input models.UpdateSubscription) error {
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if input.Price != nil {
+		setValues = append(setValues, fmt.Sprintf("price=$%d", argId))
+		args = append(args, *input.Price)
+		argId++
+	}
+
+	if input.StartDate != nil {
+		setValues = append(setValues, fmt.Sprintf("start_date=TO_DATE($%d, 'MM-YYYY')", argId))
+		args = append(args, *input.StartDate)
+		argId++
+	}
+
+	// An empty string removes the category
+	if input.Category != nil {
+		setValues = append(setValues, fmt.Sprintf("category=NULLIF($%d, '')", argId))
+		args = append(args, *input.Category)
+		argId++
+	}
+
+	// An empty string makes the subscription open-ended
+	if input.FinishDate != nil {
+		setValues = append(setValues, fmt.Sprintf("finish_date=TO_DATE(NULLIF($%d, ''), 'MM-YYYY')", argId))
+		args = append(args, *input.FinishDate)
+		argId++
+	}
+
+	if input.BillingCycle != nil {
+		setValues = append(setValues, fmt.Sprintf("billing_cycle=$%d", argId))
+		args = append(args, *input.BillingCycle)
+		argId++
+	}
+
+	// An empty string removes the trial; moving the trial resets the conversion marker
+	if input.TrialEnd != nil {
+		setValues = append(setValues, fmt.Sprintf("trial_end=TO_DATE(NULLIF($%d, ''), 'MM-YYYY'), trial_converted_at=NULL", argId))
+		args = append(args, *input.TrialEnd)
+		argId++
+
+		if *input.TrialEnd == "" && input.PriceAfterTrial == nil {
+			setValues = append(setValues, "price_after_trial=NULL")
+		}
+	}
+
+	if input.PriceAfterTrial != nil {
+		setValues = append(setValues, fmt.Sprintf("price_after_trial=$%d", argId))
+		args = append(args, *input.PriceAfterTrial)
+		argId++
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d RETURNING *", subscriptionTable, strings.Join(setValues, ", "), argId)
+	args = append(args, subID)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Cancelled and expired subscriptions keep their final period
+	if input.StartDate != nil || input.FinishDate != nil || input.TrialEnd != nil || input.PriceAfterTrial != nil {
+		var status string
+		statusQuery := fmt.Sprintf("SELECT status FROM %s WHERE id = $1", subscriptionTable)
+		if err := tx.GetContext(ctx, &status, statusQuery, subID); err != nil {
+			tx.Rollback()
+			if errors.Is(err, sql.ErrNoRows) {
+				return postgres.ErrSubscriptionNotFound
+			}
+			return fmt.Errorf("failed to read subscription status: %w", err)
+		}
+		if status == models.StatusCancelled || status == models.StatusExpired {
+			tx.Rollback()
+			return fmt.Errorf("%w: dates of a %s subscription cannot be changed", postgres.ErrStatusConflict, status)
+		}
+	}
+
+	var updated models.SubscriptionDB
+	if err := tx.GetContext(ctx, &updated, query, args...); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return postgres.ErrSubscriptionNotFound
+		}
+		return err
+	}
+
+	if input.RejectOverlaps {
+		if err := rejectOverlaps(ctx, tx, updated); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// A lower price must still cover the fixed amounts of the members
+	if input.Price != nil || input.TrialEnd != nil || input.PriceAfterTrial != nil {
+		if err := checkFixedAmounts(ctx, tx, subID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := insertOutboxEventContext(ctx, tx, models.AggregateSubscription, strconv.Itoa(updated.Id), models.EventSubscriptionUpdated, updated); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// FindOverlaps returns personal subscriptions of the same user and service whose periods intersect the filter period
+// Service names are compared case-insensitively and NULL finish dates are treated as open-ended
+func (r *SubscriptionRepository) FindOverlaps(ctx context.Context, filter models.OverlapFilter) ([]models.SubscriptionDB, error) {
+	return findOverlaps(ctx, r.db, filter)
+}
+
+// findOverlaps runs the FindOverlaps query on the database or within a transaction
+func findOverlaps(ctx context.Context, q sqlx.QueryerContext, filter models.OverlapFilter) ([]models.SubscriptionDB, error) {
+	query := fmt.Sprintf(`
+        SELECT *
+        FROM %s
+        WHERE
+            user_id = $1 AND
+            organization_id IS NULL AND
+            LOWER(service_name) = LOWER($2) AND
+            id <> $3 AND
+            start_date < COALESCE($4, '%[2]s') AND
+            $5 < COALESCE(finish_date, '%[2]s')
+        ORDER BY start_date, id
+    `, subscriptionTable, openEnded)
+
+	var subsDB []models.SubscriptionDB
+	err := sqlx.SelectContext(ctx, q, &subsDB, query, userParam(&filter.UserID), filter.ServiceName, filter.ExcludeID, formatDate(filter.FinishDate), filter.StartDate.Format(time.DateOnly))
+
+	return subsDB, err
+}
+
+// checkFixedAmounts returns postgres.ErrFixedAmounts when the fixed amounts of the subscription's
+// members add up to more than its charge
+func checkFixedAmounts(ctx context.Context, tx *sqlx.Tx, subID int) error {
+	var totals struct {
+		Fixed  int `db:"fixed_total"`
+		Charge int `db:"charge"`
+	}
+	query := fmt.Sprintf(`
+        SELECT COALESCE(SUM(m.fixed_amount), 0) AS fixed_total, COALESCE(s.price_after_trial, s.price) AS charge
+        FROM %s s
+        LEFT JOIN %s m ON m.subscription_id = s.id
+        WHERE s.id = $1
+        GROUP BY s.id`, subscriptionTable, memberTable)
+	if err := tx.GetContext(ctx, &totals, query, subID); err != nil {
+		return fmt.Errorf("failed to check fixed amounts of members: %w", err)
+	}
+
+	if totals.Fixed > totals.Charge {
+		return fmt.Errorf("%w: %d > %d", postgres.ErrFixedAmounts, totals.Fixed, totals.Charge)
+	}
+	return nil
+}
+
+// rejectOverlaps returns postgres.ErrOverlap listing the subscriptions that overlap the stored row
+// The database has a single connection, so no other write can run between the check and the commit
+func rejectOverlaps(ctx context.Context, tx *sqlx.Tx, sub models.SubscriptionDB) error {
+	overlaps, err := findOverlaps(ctx, tx, models.OverlapFilter{
+		UserID:      sub.UserID.String(),
+		ServiceName: sub.ServiceName,
+		StartDate:   sub.StartDate,
+		FinishDate:  sub.FinishDate,
+		ExcludeID:   sub.Id,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check for overlapping subscriptions: %w", err)
+	}
+	if len(overlaps) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(overlaps))
+	for _, overlap := range overlaps {
+		ids = append(ids, strconv.Itoa(overlap.Id))
+	}
+	return fmt.Errorf("%w %s", postgres.ErrOverlap, strings.Join(ids, ", "))
+}
+
+// GetDuplicates returns every personal subscription that overlaps another personal subscription
+// of the same user and service. Rows are ordered so that members of one duplicate group are adjacent
+func (r *SubscriptionRepository) GetDuplicates(ctx context.Context) ([]models.SubscriptionDB, error) {
+	query := fmt.Sprintf(`
+        SELECT s.*
+        FROM %[1]s s
+        WHERE s.organization_id IS NULL AND EXISTS (
+            SELECT 1
+            FROM %[1]s o
+            WHERE
+                o.id <> s.id AND
+                o.organization_id IS NULL AND
+                o.user_id = s.user_id AND
+                LOWER(o.service_name) = LOWER(s.service_name) AND
+                o.start_date < COALESCE(s.finish_date, '%[2]s') AND
+                s.start_date < COALESCE(o.finish_date, '%[2]s')
+        )
+        ORDER BY s.user_id, LOWER(s.service_name), s.start_date, s.id
+    `, subscriptionTable, openEnded)
+
+	var subsDB []models.SubscriptionDB
+	err := r.db.SelectContext(ctx, &subsDB, query)
+
+	return subsDB, err
+}
+
+// monthIndex returns an SQL expression numbering the month of a date, so that
+// the difference of two indexes is the number of months between them
+func monthIndex(date string) string {
+	return fmt.Sprintf("(CAST(strftime('%%Y', %[1]s) AS INTEGER) * 12 + CAST(strftime('%%m', %[1]s) AS INTEGER))", date)
+}
+
+// chargesSource returns a subquery that expands subscriptions into one row per billed month
+// It applies the billing rules of the PostgreSQL repository: billing starts with the start month,
+// or the month after trial_end for trials, repeats every BillingCycleMonths, stops before the
+// exclusive finish date and skips paused months. SQLite has no generate_series, so the months
+// of the period come from a recursive calendar. from and to are SQL expressions for the first
+// and last month of the period (inclusive)
+func chargesSource(from, to string) string {
+	return fmt.Sprintf(`
+        WITH RECURSIVE calendar(month) AS (
+            SELECT date(%[2]s) WHERE date(%[2]s) <= date(%[3]s)
+            UNION ALL
+            SELECT date(month, '+1 month') FROM calendar WHERE month < date(%[3]s)
+        )
+        SELECT s.id AS subscription_id, s.user_id, s.service_name, s.category, s.status, s.organization_id,
+               COALESCE(s.price_after_trial, s.price) AS amount, m.month
+        FROM (
+            SELECT *, COALESCE(date(trial_end, '+1 month'), start_date) AS billing_start FROM %[1]s
+        ) s
+        JOIN calendar m ON m.month >= s.billing_start AND m.month < COALESCE(s.finish_date, '%[4]s')
+        WHERE (%[5]s - %[6]s)
+              %% (CASE s.billing_cycle WHEN 'quarterly' THEN 3 WHEN 'yearly' THEN 12 ELSE 1 END) = 0
+          AND NOT EXISTS (
+              SELECT 1
+              FROM %[7]s p
+              WHERE p.subscription_id = s.id
+                AND m.month >= p.paused_from
+                AND (p.resumed_from IS NULL OR m.month < p.resumed_from)
+          )`,
+		subscriptionTable, from, to, openEnded, monthIndex("m.month"), monthIndex("s.billing_start"), pauseTable)
+}
+
+// sharesSource returns a query splitting every charge of chargesSource between the participants
+// of the subscription exactly like the PostgreSQL repository, rounding differences going to the owner
+func sharesSource(from, to string) string {
+	return fmt.Sprintf(`
+        SELECT x.subscription_id, x.owner_id, x.user_id, x.service_name, x.category, x.status, x.organization_id, x.month,
+               x.share + CASE WHEN x.user_id = x.owner_id
+                              THEN x.charge - SUM(x.share) OVER (PARTITION BY x.subscription_id, x.month)
+                              ELSE 0 END AS amount
+        FROM (
+            SELECT c.subscription_id, c.user_id AS owner_id, p.user_id, c.service_name, c.category, c.status,
+                   c.organization_id, c.month, c.amount AS charge,
+                   COALESCE(MIN(p.fixed_amount, p.fixed_amount * c.amount / NULLIF(p.fixed_total, 0)),
+                            MAX(c.amount - p.fixed_total, 0) * p.share_weight / p.weight_total) AS share
+            FROM (%[1]s) c
+            JOIN (
+                SELECT q.*,
+                       COALESCE(SUM(q.fixed_amount) OVER w, 0) AS fixed_total,
+                       NULLIF(COALESCE(SUM(q.share_weight) OVER w, 0), 0) AS weight_total
+                FROM (
+                    SELECT subscription_id, user_id, share_weight, fixed_amount FROM %[2]s
+                    UNION ALL
+                    SELECT s.id, s.user_id, 1, NULL
+                    FROM %[3]s s
+                    WHERE NOT EXISTS (
+                        SELECT 1 FROM %[2]s m WHERE m.subscription_id = s.id AND m.user_id = s.user_id
+                    )
+                ) q
+                WINDOW w AS (PARTITION BY q.subscription_id)
+            ) p ON p.subscription_id = c.subscription_id
+        ) x`,
+		chargesSource(from, to), memberTable, subscriptionTable)
+}
+
+// TotalCostResult holds the total cost result from the database query
+type TotalCostResult struct {
+	TotalCost int `db:"total_cost"`
+}
+
+// GetSubscriptionSummary calculates total subscription cost based on filters
+// Sums every charge billed within the period; filtered by user, only the user's
+// shares of shared subscriptions are counted, including subscriptions they are a member of
+// Without an organization filter only personal subscriptions are counted
+func (r *SubscriptionRepository) GetSubscriptionSummary(ctx context.Context, filter models.SubscriptionFilter) (int, error) {
+	query := fmt.Sprintf(`
+        SELECT COALESCE(SUM(c.amount), 0) AS total_cost
+        FROM (%s) c
+        WHERE
+            (c.user_id = $1 OR $1 IS NULL) AND
+            (c.service_name = $2 OR $2 IS NULL) AND
+            (c.category = $5 OR $5 IS NULL) AND
+            (c.status = $6 OR $6 IS NULL) AND
+            c.organization_id IS $7
+    `, sharesSource("TO_DATE($3, 'MM-YYYY')", "TO_DATE($4, 'MM-YYYY')"))
+
+	var result TotalCostResult
+	err := r.db.GetContext(ctx, &result, query, userParam(filter.Filters.UserID), filter.Filters.ServiceName, filter.Period.StartDate, filter.Period.FinishDate, filter.Filters.Category, filter.Filters.Status, filter.Filters.OrganizationID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate total cost: %w", err)
+	}
+
+	return result.TotalCost, nil
+}
+
+// chargeRow is a charge as returned by SQLite, where computed dates are plain text
+type chargeRow struct {
+	SubscriptionID int    `db:"subscription_id"`
+	UserID         string `db:"user_id"`
+	ServiceName    string `db:"service_name"`
+	Month          string `db:"month"`
+	Amount         int    `db:"amount"`
+}
+
+// GetCharges returns every charge billed within the period ordered by month
+// Filtered by user, the user's shares of shared subscriptions are returned instead of full charges
+// Charges of subscriptions paid by an organization are left out
+func (r *SubscriptionRepository) GetCharges(ctx context.Context, filter models.ChargeFilter) ([]models.ChargeDB, error) {
+	source := chargesSource("$2", "$3")
+	if filter.UserID != nil {
+		source = sharesSource("$2", "$3")
+	}
+
+	query := fmt.Sprintf(`
+        SELECT c.subscription_id, c.user_id, c.service_name, c.month, c.amount
+        FROM (%s) c
+        WHERE c.organization_id IS NULL AND (c.user_id = $1 OR $1 IS NULL)
+        ORDER BY c.month, c.subscription_id
+    `, source)
+
+	var rows []chargeRow
+	if err := r.db.SelectContext(ctx, &rows, query, userParam(filter.UserID), filter.From.Format(time.DateOnly), filter.To.Format(time.DateOnly)); err != nil {
+		return nil, fmt.Errorf("failed to calculate charges: %w", err)
+	}
+
+	var charges []models.ChargeDB
+	for _, row := range rows {
+		month, err := time.Parse(time.DateOnly, row.Month)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calculate charges: %w", err)
+		}
+		charges = append(charges, models.ChargeDB{
+			SubscriptionID: row.SubscriptionID,
+			UserID:         row.UserID,
+			ServiceName:    row.ServiceName,
+			Month:          month,
+			Amount:         row.Amount,
+		})
+	}
+
+	return charges, nil
+}
+
+// GetEndingTrials returns unconverted personal trials whose last free month falls within the period
+func (r *SubscriptionRepository) GetEndingTrials(ctx context.Context, from, to time.Time) ([]models.SubscriptionDB, error) {
+	query := fmt.Sprintf(`
+        SELECT *
+        FROM %s
+        WHERE
+            organization_id IS NULL AND
+            trial_end BETWEEN $1 AND $2 AND
+            trial_converted_at IS NULL
+        ORDER BY trial_end, id
+    `, subscriptionTable)
+
+	var subsDB []models.SubscriptionDB
+	err := r.db.SelectContext(ctx, &subsDB, query, from.Format(time.DateOnly), to.Format(time.DateOnly))
+
+	return subsDB, err
+}
+
+// ConvertEndedTrials marks trials that ended before the given month as converted to paid
+// A "subscription.trial_converted" event is written to the outbox for every converted row
+// in the same transaction, so each conversion is announced exactly once
+func (r *SubscriptionRepository) ConvertEndedTrials(ctx context.Context, month time.Time) ([]models.SubscriptionDB, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+        UPDATE %s
+        SET
+            trial_converted_at = CURRENT_TIMESTAMP,
+            status = CASE WHEN status = 'trial' THEN 'active' ELSE status END
+        WHERE
+            trial_end < $1 AND
+            trial_converted_at IS NULL
+        RETURNING *
+    `, subscriptionTable)
+
+	var converted []models.SubscriptionDB
+	if err := tx.SelectContext(ctx, &converted, query, month.Format(time.DateOnly)); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to convert ended trials: %w", err)
+	}
+
+	for _, sub := range converted {
+		if err := insertOutboxEventContext(ctx, tx, models.AggregateSubscription, strconv.Itoa(sub.Id), models.EventTrialConverted, sub); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return converted, nil
+}
+
+// ExpireEnded marks subscriptions whose exclusive finish date is not after the given month as expired
+// SQLite runs one write transaction at a time, so unlike PostgreSQL no advisory lock is needed
+// to keep concurrent runs from expiring a subscription twice
+func (r *SubscriptionRepository) ExpireEnded(ctx context.Context, month time.Time) ([]models.SubscriptionDB, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer tx.Rollback()
+
+	// Cancelled subscriptions keep their terminal status when their last month passes
+	query := fmt.Sprintf(`
+        UPDATE %s
+        SET status = 'expired'
+        WHERE
+            finish_date <= $1 AND
+            status NOT IN ('cancelled', 'expired')
+        RETURNING *
+    `, subscriptionTable)
+
+	var expired []models.SubscriptionDB
+	if err := tx.SelectContext(ctx, &expired, query, month.Format(time.DateOnly)); err != nil {
+		return nil, fmt.Errorf("failed to expire ended subscriptions: %w", err)
+	}
+
+	for _, sub := range expired {
+		if err := insertOutboxEventContext(ctx, tx, models.AggregateSubscription, strconv.Itoa(sub.Id), models.EventSubscriptionExpired, sub); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return expired, nil
+}
+
+// ChangeStatus applies a lifecycle transition if the subscription still has the expected status
+// The optional event of the change is written to the outbox in the same transaction
+func (r *SubscriptionRepository) ChangeStatus(ctx context.Context, subID int, change models.StatusChange) (models.SubscriptionDB, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return models.SubscriptionDB{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Cancellation details and the finish date are only overwritten when provided
+	query := fmt.Sprintf(`
+        UPDATE %s
+        SET
+            status = $1,
+            cancelled_at = COALESCE($2, cancelled_at),
+            cancellation_reason = COALESCE($3, cancellation_reason),
+            finish_date = COALESCE($4, finish_date)
+        WHERE id = $5 AND status = $6
+        RETURNING *
+    `, subscriptionTable)
+
+	var updated models.SubscriptionDB
+	if err := tx.GetContext(ctx, &updated, query, change.To, formatDate(change.CancelledAt), change.Reason, formatDate(change.FinishDate), subID, change.From); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SubscriptionDB{}, postgres.ErrStatusConflict
+		}
+		return models.SubscriptionDB{}, fmt.Errorf("failed to change subscription status: %w", err)
+	}
+
+	if change.EventType != "" {
+		if err := insertOutboxEventContext(ctx, tx, models.AggregateSubscription, strconv.Itoa(subID), change.EventType, updated); err != nil {
+			tx.Rollback()
+			return models.SubscriptionDB{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.SubscriptionDB{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return updated, nil
+}
+
+// CountByStatus returns the number of subscriptions in each lifecycle status
+func (r *SubscriptionRepository) CountByStatus(ctx context.Context) ([]models.StatusCountDB, error) {
+	query := fmt.Sprintf("SELECT status, COUNT(*) AS count FROM %s GROUP BY status ORDER BY status", subscriptionTable)
+
+	var counts []models.StatusCountDB
+	if err := r.db.SelectContext(ctx, &counts, query); err != nil {
+		return nil, fmt.Errorf("failed to count subscriptions by status: %w", err)
+	}
+
+	return counts, nil
+}
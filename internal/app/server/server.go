@@ -3,55 +3,150 @@ package server
 import (
 	"context"
 
+	"net"
+
 	"net/http"
 
 	"time"
 )
 
+// Config holds the listen address, timeouts and TLS settings of the HTTP server
+type Config struct {
+	Host              string
+	Port              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration // Used by the caller to bound Shutdown
+	DrainDelay        time.Duration // Used by the caller to keep serving after readiness fails
+	MaxHeaderBytes    int
+	TLS               TLSConfig
+}
+
 // Server encapsulates an HTTP server and provides methods for managing its lifecycle.
 
 type Server struct {
-	httpServer *http.Server
+	httpServer     *http.Server
+	redirectServer *http.Server       // Optional HTTP to HTTPS redirect listener
+	certs          *certReloader      // Certificate source when TLS is enabled
+	reloadInterval time.Duration      // Period of the certificate file checks
+	reloadCtx      context.Context    // Lifetime of the certificate watcher
+	stopReload     context.CancelFunc // Stops the certificate watcher
 }
 
-// Run starts an HTTPS server with the specified host and port.
+// NewServer builds the server with the address, timeouts and TLS settings from the configuration.
+
+// The listeners and the certificate watcher are prepared here, before Run is started in its own
+// goroutine, so Shutdown never races with their creation.
+
+// Returns an error when the TLS certificates or the client CA cannot be loaded.
+func NewServer(cfg Config, handler http.Handler) (*Server, error) {
+
+	s := &Server{
+		httpServer: &http.Server{
+
+			Addr: net.JoinHostPort(cfg.Host, cfg.Port),
+
+			Handler: handler,
+
+			MaxHeaderBytes: cfg.MaxHeaderBytes,
+
+			ReadTimeout: cfg.ReadTimeout,
+
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+
+			WriteTimeout: cfg.WriteTimeout,
+
+			IdleTimeout: cfg.IdleTimeout,
+		},
+	}
+
+	// Plain HTTP behind a TLS-terminating proxy
+
+	if !cfg.TLS.Enabled {
+		return s, nil
+	}
+
+	certs, err := newCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := newTLSConfig(cfg.TLS, certs)
+	if err != nil {
+		return nil, err
+	}
+	s.httpServer.TLSConfig = tlsConfig
+	s.certs = certs
+	s.reloadInterval = cfg.TLS.ReloadInterval
+
+	if cfg.TLS.RedirectPort != "" {
+		s.redirectServer = newRedirectServer(cfg)
+	}
 
-// Uses pre-configured TLS certificates (cert.pem and key.pem).
+	// The watcher is started by Run and stopped by Shutdown
 
-// Returns an error in case of failure (for example, problems downloading certificates or a busy port).
+	s.reloadCtx, s.stopReload = context.WithCancel(context.Background())
 
-// Run starts an HTTPS server with the specified host and port.
+	return s, nil
 
-// Uses pre-configured TLS certificates (cert.pem and key.pem).
+}
 
-// Returns an error in case of failure (for example, problems downloading certificates or a busy port).
-func (s *Server) Run(host, port string, handler http.Handler) error {
+// Run starts serving plain HTTP when TLS is disabled, otherwise HTTPS with an optional redirect listener.
 
-	s.httpServer = &http.Server{
+// Returns an error in case of failure (for example, a busy port), or nil after Shutdown.
+func (s *Server) Run() error {
 
-		Addr: host + ":" + port,
+	if s.httpServer.TLSConfig == nil {
+		return ignoreClosed(s.httpServer.ListenAndServe())
+	}
 
-		Handler: handler,
+	// Watching the certificate files for rotation
 
-		MaxHeaderBytes: 1 << 20,
+	if s.reloadInterval > 0 {
+		go s.certs.watch(s.reloadCtx, s.reloadInterval)
+	}
 
-		ReadTimeout: 10 * time.Second,
+	// Launching the redirect listener next to the TLS server, the first failure stops Run
 
-		WriteTimeout: 10 * time.Second,
+	errs := make(chan error, 2)
+	if s.redirectServer != nil {
+		go func() {
+			errs <- ignoreClosed(s.redirectServer.ListenAndServe())
+		}()
 	}
 
-	// Launching a TLS-enabled server
+	go func() {
+		errs <- ignoreClosed(s.httpServer.ListenAndServeTLS("", ""))
+	}()
 
-	return s.httpServer.ListenAndServeTLS("cert.pem", "key.pem")
+	return <-errs
 
 }
 
-// Shutdown stops the server correctly, ending all active connections.
+// Shutdown stops the server correctly, waiting for active requests to finish.
 
-// Accepts a context for monitoring the execution time of the stop.
+// Accepts a context for monitoring the execution time of the stop; connections still active
+// at its deadline are closed forcibly and the context error is returned.
 
 func (s *Server) Shutdown(ctx context.Context) error {
 
-	return s.httpServer.Shutdown(ctx)
+	if s.stopReload != nil {
+		s.stopReload()
+	}
+
+	if s.redirectServer != nil {
+		if err := s.redirectServer.Shutdown(ctx); err != nil {
+			s.redirectServer.Close()
+		}
+	}
+
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		s.httpServer.Close()
+		return err
+	}
+
+	return nil
 
 }
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/google/uuid"
+)
+
+// MaxForecastMonths limits how far ahead a forecast can look
+const MaxForecastMonths = 120
+
+// ErrInvalidForecast is returned for forecasts and monthly spend requests with an invalid period or user ID
+var ErrInvalidForecast = errors.New("invalid forecast")
+
+// AnalyticsService implements spend analytics on top of subscription charges
+type AnalyticsService struct {
+	repo postgres.SubscriptionStore
+}
+
+// NewAnalyticsService creates a new analytics service instance
+func NewAnalyticsService(repo postgres.SubscriptionStore) *AnalyticsService {
+	return &AnalyticsService{repo: repo}
+}
+
+// Forecast projects charges of active subscriptions for the given number of months
+// starting with the current month, optionally limited to a single user
+func (s *AnalyticsService) Forecast(ctx context.Context, userID *string, months int) (models.Forecast, error) {
+	if months < 1 || months > MaxForecastMonths {
+		return models.Forecast{}, fmt.Errorf("%w: months must be between 1 and %d", ErrInvalidForecast, MaxForecastMonths)
+	}
+
+	if userID != nil {
+		if _, err := uuid.Parse(*userID); err != nil {
+			return models.Forecast{}, fmt.Errorf("%w: invalid user ID format: %s", ErrInvalidForecast, err.Error())
+		}
+	}
+
+	from := startOfMonth(time.Now())
+	to := from.AddDate(0, months-1, 0)
+
+	chargesDB, err := s.repo.GetCharges(ctx, models.ChargeFilter{UserID: userID, From: from, To: to})
+	if err != nil {
+		return models.Forecast{}, fmt.Errorf("failed to retrieve charges from repository: %w", err)
+	}
+
+	return buildForecast(from, months, chargesDB)
+}
+
+// MonthlySpend returns the charges of a user per month between from and to (MM-YYYY, inclusive)
+// The result has the shape of a forecast but may cover past months
+func (s *AnalyticsService) MonthlySpend(ctx context.Context, userID string, from, to string) (models.Forecast, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return models.Forecast{}, fmt.Errorf("%w: invalid user ID format: %s", ErrInvalidForecast, err.Error())
+	}
+
+	fromMonth, err := time.Parse("01-2006", from)
+	if err != nil {
+		return models.Forecast{}, fmt.Errorf("%w: invalid from format, expected MM-YYYY: %s", ErrInvalidForecast, err.Error())
+	}
+	toMonth, err := time.Parse("01-2006", to)
+	if err != nil {
+		return models.Forecast{}, fmt.Errorf("%w: invalid to format, expected MM-YYYY: %s", ErrInvalidForecast, err.Error())
+	}
+
+	months := (toMonth.Year()-fromMonth.Year())*12 + int(toMonth.Month()-fromMonth.Month()) + 1
+	if months < 1 || months > MaxForecastMonths {
+		return models.Forecast{}, fmt.Errorf("%w: period must cover between 1 and %d months", ErrInvalidForecast, MaxForecastMonths)
+	}
+
+	chargesDB, err := s.repo.GetCharges(ctx, models.ChargeFilter{UserID: &userID, From: fromMonth, To: toMonth})
+	if err != nil {
+		return models.Forecast{}, fmt.Errorf("failed to retrieve charges from repository: %w", err)
+	}
+
+	return buildForecast(fromMonth, months, chargesDB)
+}
+
+// buildForecast groups charges into per-month totals covering every month of the forecast
+func buildForecast(from time.Time, months int, chargesDB []models.ChargeDB) (models.Forecast, error) {
+	forecast := models.Forecast{
+		From:     from.Format("01-2006"),
+		To:       from.AddDate(0, months-1, 0).Format("01-2006"),
+		Currency: models.DefaultCurrency,
+		Months:   make([]models.MonthTotal, months),
+		Charges:  make([]models.Charge, 0, len(chargesDB)),
+	}
+
+	for i := range forecast.Months {
+		forecast.Months[i].Month = from.AddDate(0, i, 0).Format("01-2006")
+	}
+
+	for _, charge := range chargesDB {
+		index := (charge.Month.Year()-from.Year())*12 + int(charge.Month.Month()-from.Month())
+		if index < 0 || index >= months {
+			return models.Forecast{}, errors.New("charge outside of the forecast period")
+		}
+
+		forecast.Months[index].Total += charge.Amount
+		forecast.Total += charge.Amount
+		forecast.Charges = append(forecast.Charges, models.Charge{
+			SubscriptionID: charge.SubscriptionID,
+			UserID:         charge.UserID,
+			ServiceName:    charge.ServiceName,
+			Month:          charge.Month.Format("01-2006"),
+			Amount:         charge.Amount,
+		})
+	}
+
+	return forecast, nil
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Errors returned for invalid budgets and budgets that do not exist
+var (
+	ErrInvalidBudget  = errors.New("invalid budget")
+	ErrBudgetNotFound = errors.New("budget not found")
+)
+
+// BudgetService implements business logic for user budgets
+type BudgetService struct {
+	repo postgres.BudgetStore
+	subs postgres.SubscriptionStore
+}
+
+// NewBudgetService creates a new budget service instance
+// Subscription store is used to calculate spend with the same aggregation as the summary endpoint
+func NewBudgetService(repo postgres.BudgetStore, subs postgres.SubscriptionStore) *BudgetService {
+	return &BudgetService{repo: repo, subs: subs}
+}
+
+// Create validates and stores a new budget for the user
+func (s *BudgetService) Create(ctx context.Context, userID string, input models.Budget) (int, error) {
+	if err := validateUserID(userID); err != nil {
+		return 0, err
+	}
+
+	if err := input.Validate(); err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidBudget, err.Error())
+	}
+
+	budgetID, err := s.repo.Create(ctx, models.BudgetDB{
+		UserID:   userID,
+		Amount:   input.Amount,
+		Currency: input.Currency,
+		Category: input.Category,
+	})
+	if err != nil {
+		return 0, userError(err)
+	}
+
+	// Evaluate immediately so an already exceeded limit raises an alert
+	// The budget is already stored, so evaluation errors are logged instead of returned;
+	// the periodic evaluation catches up with budgets that could not be evaluated here
+	if err := s.EvaluateBudgets(ctx, userID); err != nil {
+		logrus.WithContext(ctx).Warnf("failed to evaluate budgets of user %s: %s", userID, err.Error())
+	}
+
+	return budgetID, nil
+}
+
+// GetAll returns the current month status of every budget of the user
+func (s *BudgetService) GetAll(ctx context.Context, userID string) ([]models.BudgetStatus, error) {
+	if err := validateUserID(userID); err != nil {
+		return nil, err
+	}
+
+	budgets, err := s.repo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve budgets from repository: %w", err)
+	}
+
+	month := currentMonth()
+	statuses := make([]models.BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		status, err := s.status(ctx, budget, month)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// GetById returns the current month status of a single budget
+func (s *BudgetService) GetById(ctx context.Context, userID string, budgetID int) (models.BudgetStatus, error) {
+	if err := validateUserID(userID); err != nil {
+		return models.BudgetStatus{}, err
+	}
+
+	budget, err := s.repo.GetById(ctx, userID, budgetID)
+	if err != nil {
+		return models.BudgetStatus{}, budgetError(err)
+	}
+
+	return s.status(ctx, budget, currentMonth())
+}
+
+// Delete removes a budget of the user
+func (s *BudgetService) Delete(ctx context.Context, userID string, budgetID int) error {
+	if err := validateUserID(userID); err != nil {
+		return err
+	}
+
+	return budgetError(s.repo.Delete(ctx, userID, budgetID))
+}
+
+// EvaluateBudgets recalculates all budgets of the user for the current month
+// Raises a "budget.breached" event only when a budget crosses its limit in the month,
+// so repeated evaluations of an already exceeded budget do not produce duplicate alerts
+func (s *BudgetService) EvaluateBudgets(ctx context.Context, userID string) error {
+	if err := s.repo.Evaluate(ctx, userID, startOfMonth(time.Now())); err != nil {
+		return fmt.Errorf("failed to evaluate budgets of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// EvaluateAll recalculates the budgets of every user and returns how many users were evaluated
+// Catches up with evaluations that failed after a change and raises alerts for budgets
+// exceeded again in a new month
+func (s *BudgetService) EvaluateAll(ctx context.Context) (int, error) {
+	userIDs, err := s.repo.GetUserIDs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve budget owners from repository: %w", err)
+	}
+
+	for i, userID := range userIDs {
+		if err := s.EvaluateBudgets(ctx, userID); err != nil {
+			return i, err
+		}
+	}
+
+	return len(userIDs), nil
+}
+
+// status calculates the projected spend of the budget's user for the given month
+func (s *BudgetService) status(ctx context.Context, budget models.BudgetDB, month string) (models.BudgetStatus, error) {
+	userID := budget.UserID
+	used, err := s.subs.GetSubscriptionSummary(ctx, models.SubscriptionFilter{
+		Period: models.Period{StartDate: month, FinishDate: month},
+		Filters: models.Filters{
+			UserID:   &userID,
+			Category: budget.Category,
+		},
+	})
+	if err != nil {
+		return models.BudgetStatus{}, fmt.Errorf("failed to calculate budget %d usage: %w", budget.Id, err)
+	}
+
+	remaining := budget.Amount - used
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return models.BudgetStatus{
+		Id:        budget.Id,
+		UserID:    budget.UserID,
+		Amount:    budget.Amount,
+		Currency:  budget.Currency,
+		Category:  budget.Category,
+		Month:     month,
+		Used:      used,
+		Remaining: remaining,
+		Breached:  used > budget.Amount,
+	}, nil
+}
+
+// validateUserID checks the format of the user ID a budget belongs to
+func validateUserID(userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("%w: invalid user ID format: %s", ErrInvalidUser, err.Error())
+	}
+	return nil
+}
+
+// budgetError marks missing budgets reported by the repository with ErrBudgetNotFound
+func budgetError(err error) error {
+	if errors.Is(err, postgres.ErrBudgetNotFound) {
+		return ErrBudgetNotFound
+	}
+	return err
+}
+
+// currentMonth returns the current month in the MM-YYYY format used by the API
+func currentMonth() string {
+	return time.Now().Format("01-2006")
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+)
+
+// Policies applied when a created or updated subscription overlaps an existing one
+const (
+	DuplicatePolicyWarn   = "warn"   // Store the subscription and report the overlap in the response
+	DuplicatePolicyReject = "reject" // Refuse to store the subscription
+)
+
+// ErrDuplicateSubscription is returned by create and update under the reject policy
+var ErrDuplicateSubscription = errors.New("overlapping subscription for the same service already exists")
+
+// ValidDuplicatePolicy reports whether the policy name is supported (empty means warn)
+func ValidDuplicatePolicy(policy string) bool {
+	return policy == "" || policy == DuplicatePolicyWarn || policy == DuplicatePolicyReject
+}
+
+// DuplicateDetector finds overlapping subscriptions of the same user and service,
+// which would otherwise be double-counted in summaries
+type DuplicateDetector struct {
+	repo   postgres.SubscriptionStore
+	policy string
+}
+
+// NewDuplicateDetector creates a new duplicate detector with the given policy
+func NewDuplicateDetector(repo postgres.SubscriptionStore, policy string) *DuplicateDetector {
+	if policy == "" {
+		policy = DuplicatePolicyWarn
+	}
+	return &DuplicateDetector{repo: repo, policy: policy}
+}
+
+// Rejects reports whether overlapping subscriptions are refused
+// The overlap check itself runs in the write transaction of the repository
+func (d *DuplicateDetector) Rejects() bool {
+	return d.policy == DuplicatePolicyReject
+}
+
+// duplicateError converts the repository overlap error into ErrDuplicateSubscription
+func duplicateError(err error) error {
+	if errors.Is(err, postgres.ErrOverlap) {
+		return fmt.Errorf("%w: %s", ErrDuplicateSubscription, err.Error())
+	}
+	return err
+}
+
+// Find returns the stored subscriptions that overlap the given one
+func (d *DuplicateDetector) Find(ctx context.Context, subDB models.SubscriptionDB) ([]models.Subscription, error) {
+	overlaps, err := d.repo.FindOverlaps(ctx, models.OverlapFilter{
+		UserID:      subDB.UserID.String(),
+		ServiceName: subDB.ServiceName,
+		StartDate:   subDB.StartDate,
+		FinishDate:  subDB.FinishDate,
+		ExcludeID:   subDB.Id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate subscriptions: %w", err)
+	}
+
+	subs := make([]models.Subscription, 0, len(overlaps))
+	for i := range overlaps {
+		subs = append(subs, сonvertDBToAPIModel(overlaps[i]))
+	}
+
+	return subs, nil
+}
+
+// Report groups all overlapping subscriptions in the database by user and service
+// Every group is a chain of overlapping periods: subscriptions of the same service that
+// do not overlap through any other subscription are reported in separate groups
+func (d *DuplicateDetector) Report(ctx context.Context) ([]models.DuplicateGroup, error) {
+	subsDB, err := d.repo.GetDuplicates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve duplicate subscriptions from repository: %w", err)
+	}
+
+	groups := make([]models.DuplicateGroup, 0)
+	// Exclusive end of the latest period in the current group, nil while it is open-ended
+	var groupEnd *time.Time
+	for i := range subsDB {
+		sub := сonvertDBToAPIModel(subsDB[i])
+
+		// Rows are ordered by user, service and start date, so a new key or a start
+		// after every period of the current group has ended starts a new group
+		last := len(groups) - 1
+		if last < 0 || groups[last].UserID != sub.UserID || !strings.EqualFold(groups[last].ServiceName, sub.ServiceName) ||
+			(groupEnd != nil && !subsDB[i].StartDate.Before(*groupEnd)) {
+			groups = append(groups, models.DuplicateGroup{
+				UserID:      sub.UserID,
+				ServiceName: sub.ServiceName,
+			})
+			last++
+			groupEnd = subsDB[i].FinishDate
+		} else if groupEnd != nil && (subsDB[i].FinishDate == nil || subsDB[i].FinishDate.After(*groupEnd)) {
+			groupEnd = subsDB[i].FinishDate
+		}
+		groups[last].Subscriptions = append(groups[last].Subscriptions, sub)
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidTransition is returned when a lifecycle change is not allowed from the current status
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// ErrInvalidStatus is returned when a status filter names an unknown status
+var ErrInvalidStatus = errors.New("invalid status")
+
+// transitions lists the statuses reachable from every status
+// Cancelled and expired are terminal: such subscriptions cannot be resumed or reactivated
+var transitions = map[string][]string{
+	models.StatusTrial:     {models.StatusActive, models.StatusPaused, models.StatusCancelled, models.StatusExpired},
+	models.StatusActive:    {models.StatusPaused, models.StatusCancelled, models.StatusExpired},
+	models.StatusPaused:    {models.StatusTrial, models.StatusActive, models.StatusCancelled, models.StatusExpired},
+	models.StatusCancelled: {},
+	models.StatusExpired:   {},
+}
+
+// ValidStatus reports whether the status is a known lifecycle status
+func ValidStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// ValidateTransition checks that a subscription may move from one status to another
+func ValidateTransition(from, to string) error {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: cannot move subscription from %s to %s", ErrInvalidTransition, from, to)
+}
+
+// initialStatus returns the status of a newly created subscription
+func initialStatus(trialEnd string) string {
+	if trialEnd != "" {
+		return models.StatusTrial
+	}
+	return models.StatusActive
+}
+
+// resumedStatus returns the status a paused subscription returns to
+// Trials that have not been converted yet continue as trials
+func resumedStatus(sub models.SubscriptionDB) string {
+	if sub.TrialEnd != nil && sub.TrialConvertedAt == nil {
+		return models.StatusTrial
+	}
+	return models.StatusActive
+}
+
+// LifecycleService implements lifecycle transitions of subscriptions
+type LifecycleService struct {
+	repo    postgres.SubscriptionStore
+	budgets BudgetEvaluator
+}
+
+// NewLifecycleService creates a new lifecycle service instance
+// Budgets of the owner are re-evaluated after every transition
+func NewLifecycleService(repo postgres.SubscriptionStore, budgets BudgetEvaluator) *LifecycleService {
+	return &LifecycleService{repo: repo, budgets: budgets}
+}
+
+// Cancel cancels the subscription effective from the next month
+// The current month is still billed; an earlier finish date is kept. A subscription
+// starting in a later month ends at its start date and is never billed
+func (s *LifecycleService) Cancel(ctx context.Context, subID int, input models.CancelInput) error {
+	sub, err := s.repo.GetById(ctx, subID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
+	}
+
+	if err := ValidateTransition(sub.Status, models.StatusCancelled); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	change := models.StatusChange{
+		From:        sub.Status,
+		To:          models.StatusCancelled,
+		CancelledAt: &now,
+		EventType:   models.EventSubscriptionCancelled,
+	}
+
+	// Billing stops at the end of the cancellation month
+	finishDate := startOfMonth(now).AddDate(0, 1, 0)
+	if finishDate.Before(sub.StartDate) {
+		finishDate = sub.StartDate
+	}
+	if sub.FinishDate == nil || sub.FinishDate.After(finishDate) {
+		change.FinishDate = &finishDate
+	}
+
+	if reason := strings.TrimSpace(input.Reason); reason != "" {
+		change.Reason = &reason
+	}
+
+	if _, err := s.repo.ChangeStatus(ctx, subID, change); err != nil {
+		if errors.Is(err, postgres.ErrStatusConflict) {
+			return fmt.Errorf("%w: %s", ErrInvalidTransition, err.Error())
+		}
+		return err
+	}
+
+	if err := s.budgets.EvaluateBudgets(ctx, sub.UserID.String()); err != nil {
+		logrus.WithContext(ctx).Warnf("failed to evaluate budgets of user %s: %s", sub.UserID.String(), err.Error())
+	}
+	return nil
+}
+
+// ExpireEnded moves subscriptions that reached their finish date to expired
+// and returns how many subscriptions were expired by this call
+func (s *LifecycleService) ExpireEnded(ctx context.Context) (int, error) {
+	expired, err := s.repo.ExpireEnded(ctx, startOfMonth(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(expired), nil
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// Errors returned for membership changes that conflict with the stored members
+var (
+	ErrMemberConflict = errors.New("member conflict")
+	ErrMemberNotFound = errors.New("member not found")
+)
+
+// MemberService implements business logic for shared subscriptions
+type MemberService struct {
+	repo    postgres.MemberStore
+	subs    postgres.SubscriptionStore
+	budgets BudgetEvaluator
+}
+
+// NewMemberService creates a new member service instance
+// Budgets of the owner and the member are re-evaluated after every membership change
+func NewMemberService(repo postgres.MemberStore, subs postgres.SubscriptionStore, budgets BudgetEvaluator) *MemberService {
+	return &MemberService{repo: repo, subs: subs, budgets: budgets}
+}
+
+// AddMember adds a user to the subscription with a weighted or fixed share of its cost
+func (s *MemberService) AddMember(ctx context.Context, subID int, input models.Member) error {
+	if err := input.Validate(); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	userID, err := uuid.Parse(input.UserID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	sub, err := s.subs.GetById(ctx, subID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
+	}
+
+	if _, err := s.repo.Add(ctx, models.MemberDB{
+		SubscriptionID: subID,
+		UserID:         userID,
+		ShareWeight:    input.ShareWeight,
+		FixedAmount:    input.FixedAmount,
+	}); err != nil {
+		// Fixed amounts must leave the owner something to split, otherwise the owner would be paid back more than the charge
+		if errors.Is(err, postgres.ErrMemberExists) || errors.Is(err, postgres.ErrFixedAmounts) {
+			return fmt.Errorf("%w: %s", ErrMemberConflict, err.Error())
+		}
+		return userError(err)
+	}
+
+	s.evaluateBudgets(ctx, sub.UserID.String(), input.UserID)
+	return nil
+}
+
+// GetMembers returns the members sharing the subscription
+func (s *MemberService) GetMembers(ctx context.Context, subID int) ([]models.Member, error) {
+	membersDB, err := s.repo.GetBySubscription(ctx, subID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve members from repository: %w", err)
+	}
+
+	members := make([]models.Member, 0, len(membersDB))
+	for _, member := range membersDB {
+		members = append(members, models.Member{
+			UserID:      member.UserID.String(),
+			ShareWeight: member.ShareWeight,
+			FixedAmount: member.FixedAmount,
+		})
+	}
+
+	return members, nil
+}
+
+// RemoveMember removes a user from the subscription; their share returns to the owner
+func (s *MemberService) RemoveMember(ctx context.Context, subID int, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	sub, err := s.subs.GetById(ctx, subID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
+	}
+
+	if err := s.repo.Remove(ctx, subID, userID); err != nil {
+		if errors.Is(err, postgres.ErrMemberNotFound) {
+			return fmt.Errorf("%w: user %s in subscription %d", ErrMemberNotFound, userID, subID)
+		}
+		return err
+	}
+
+	s.evaluateBudgets(ctx, sub.UserID.String(), userID)
+	return nil
+}
+
+// GetSettlements returns who owes whom for shared subscriptions in every month of the period
+// Debts between two users in opposite directions are netted against each other
+func (s *MemberService) GetSettlements(ctx context.Context, userID *string, from, to string) ([]models.Settlement, error) {
+	if userID != nil {
+		if _, err := uuid.Parse(*userID); err != nil {
+			return nil, fmt.Errorf("invalid user ID format: %w", err)
+		}
+	}
+
+	// The period defaults to the current month
+	fromMonth := startOfMonth(time.Now())
+	if from != "" {
+		parsed, err := time.Parse("01-2006", from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from format, expected MM-YYYY: %w", err)
+		}
+		fromMonth = parsed
+	}
+	toMonth := fromMonth
+	if to != "" {
+		parsed, err := time.Parse("01-2006", to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to format, expected MM-YYYY: %w", err)
+		}
+		toMonth = parsed
+	}
+	if toMonth.Before(fromMonth) {
+		return nil, errors.New("to must not be before from")
+	}
+	if toMonth.After(fromMonth.AddDate(0, MaxForecastMonths-1, 0)) {
+		return nil, fmt.Errorf("period must not exceed %d months", MaxForecastMonths)
+	}
+
+	debts, err := s.repo.GetDebts(ctx, models.SettlementFilter{UserID: userID, From: fromMonth, To: toMonth})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve debts from repository: %w", err)
+	}
+
+	return settle(debts), nil
+}
+
+// settle nets debts of every pair of users per month
+// The result is ordered by month and then by the pair of user IDs
+func settle(debts []models.DebtDB) []models.Settlement {
+	type pair struct {
+		month     time.Time
+		low, high string
+	}
+
+	// Positive balances mean the lower user ID owes the higher one
+	balances := make(map[pair]int)
+	keys := make([]pair, 0, len(debts))
+	for _, debt := range debts {
+		key, amount := pair{debt.Month, debt.Debtor, debt.Creditor}, debt.Amount
+		if debt.Creditor < debt.Debtor {
+			key, amount = pair{debt.Month, debt.Creditor, debt.Debtor}, -debt.Amount
+		}
+		if _, ok := balances[key]; !ok {
+			keys = append(keys, key)
+		}
+		balances[key] += amount
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if !a.month.Equal(b.month) {
+			return a.month.Before(b.month)
+		}
+		if a.low != b.low {
+			return a.low < b.low
+		}
+		return a.high < b.high
+	})
+
+	settlements := make([]models.Settlement, 0, len(keys))
+	for _, key := range keys {
+		balance := balances[key]
+		if balance == 0 {
+			continue
+		}
+
+		settlement := models.Settlement{
+			Month:      key.month.Format("01-2006"),
+			FromUserID: key.low,
+			ToUserID:   key.high,
+			Amount:     balance,
+			Currency:   models.DefaultCurrency,
+		}
+		if balance < 0 {
+			settlement.FromUserID, settlement.ToUserID, settlement.Amount = key.high, key.low, -balance
+		}
+		settlements = append(settlements, settlement)
+	}
+
+	return settlements
+}
+
+// evaluateBudgets re-checks the budgets of every user whose share changed
+// The change is already committed, so evaluation errors are logged instead of returned
+func (s *MemberService) evaluateBudgets(ctx context.Context, userIDs ...string) {
+	for _, userID := range userIDs {
+		if err := s.budgets.EvaluateBudgets(ctx, userID); err != nil {
+			logrus.WithContext(ctx).Warnf("failed to evaluate budgets of user %s: %s", userID, err.Error())
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/google/uuid"
+)
+
+// Errors returned for organization operations
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrOrganizationConflict  = errors.New("organization conflict")
+	ErrNotOrganizationMember = errors.New("user is not a member of the organization")
+	ErrForbidden             = errors.New("forbidden")
+)
+
+// OrganizationService implements business logic for organizations and role-based permissions
+type OrganizationService struct {
+	repo postgres.OrganizationStore
+	subs postgres.SubscriptionStore
+}
+
+// NewOrganizationService creates a new organization service instance
+func NewOrganizationService(repo postgres.OrganizationStore, subs postgres.SubscriptionStore) *OrganizationService {
+	return &OrganizationService{repo: repo, subs: subs}
+}
+
+// Create stores a new organization owned by the calling user and returns its ID
+func (s *OrganizationService) Create(ctx context.Context, callerID string, input models.Organization) (int, error) {
+	created, err := s.repo.Create(ctx, input.Name, callerID)
+	if err != nil {
+		return 0, organizationError(err)
+	}
+
+	return created.Id, nil
+}
+
+// GetById returns a single organization
+func (s *OrganizationService) GetById(ctx context.Context, orgID int) (models.Organization, error) {
+	org, err := s.repo.GetById(ctx, orgID)
+	if err != nil {
+		return models.Organization{}, organizationError(err)
+	}
+
+	return models.Organization{Id: org.Id, Name: org.Name}, nil
+}
+
+// Update renames an organization
+func (s *OrganizationService) Update(ctx context.Context, orgID int, input models.Organization) error {
+	return organizationError(s.repo.Update(ctx, orgID, input.Name))
+}
+
+// Delete removes an organization; its subscriptions stay with their owners
+func (s *OrganizationService) Delete(ctx context.Context, orgID int) error {
+	return organizationError(s.repo.Delete(ctx, orgID))
+}
+
+// Role returns the role of the user in the organization
+func (s *OrganizationService) Role(ctx context.Context, orgID int, userID string) (string, error) {
+	role, err := s.repo.GetRole(ctx, orgID, userID)
+	if err != nil {
+		return "", organizationError(err)
+	}
+
+	return role, nil
+}
+
+// GetMembers returns the members of the organization with their roles
+func (s *OrganizationService) GetMembers(ctx context.Context, orgID int) ([]models.OrganizationMember, error) {
+	membersDB, err := s.repo.GetMembers(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve organization members from repository: %w", err)
+	}
+
+	members := make([]models.OrganizationMember, 0, len(membersDB))
+	for _, member := range membersDB {
+		members = append(members, models.OrganizationMember{
+			UserID: member.UserID.String(),
+			Role:   member.Role,
+		})
+	}
+
+	return members, nil
+}
+
+// SetMember adds a user to the organization or changes their role
+// Only owners may grant the owner role or change the role of another owner
+func (s *OrganizationService) SetMember(ctx context.Context, callerRole string, orgID int, userID string, input models.OrganizationMember) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	if err := input.Validate(); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	if callerRole != models.RoleOwner {
+		if input.Role == models.RoleOwner {
+			return fmt.Errorf("%w: only owners may grant the owner role", ErrForbidden)
+		}
+		if err := s.requireNotOwner(ctx, orgID, userID); err != nil {
+			return err
+		}
+	}
+
+	return organizationError(s.repo.SetMember(ctx, orgID, userID, input.Role))
+}
+
+// RemoveMember removes a user from the organization
+// Only owners may remove another owner; the last owner cannot be removed
+func (s *OrganizationService) RemoveMember(ctx context.Context, callerRole string, orgID int, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	if callerRole != models.RoleOwner {
+		if err := s.requireNotOwner(ctx, orgID, userID); err != nil {
+			return err
+		}
+	}
+
+	return organizationError(s.repo.RemoveMember(ctx, orgID, userID))
+}
+
+// requireNotOwner fails with ErrForbidden when the user is an owner of the organization
+func (s *OrganizationService) requireNotOwner(ctx context.Context, orgID int, userID string) error {
+	role, err := s.repo.GetRole(ctx, orgID, userID)
+	if err != nil && !errors.Is(err, postgres.ErrNotOrganizationMember) {
+		return organizationError(err)
+	}
+	if role == models.RoleOwner {
+		return fmt.Errorf("%w: only owners may change other owners", ErrForbidden)
+	}
+	return nil
+}
+
+// AttachSubscription makes the organization pay for a subscription
+// Members may attach only their own subscriptions, admins and owners any subscription
+func (s *OrganizationService) AttachSubscription(ctx context.Context, callerID, callerRole string, orgID, subID int) error {
+	sub, err := s.subs.GetById(ctx, subID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
+	}
+
+	if models.RoleRank(callerRole) < models.RoleRank(models.RoleAdmin) && sub.UserID.String() != callerID {
+		return fmt.Errorf("%w: members may attach only their own subscriptions", ErrForbidden)
+	}
+
+	if sub.OrganizationID != nil {
+		if *sub.OrganizationID == orgID {
+			return nil
+		}
+		return fmt.Errorf("%w: subscription belongs to another organization", ErrOrganizationConflict)
+	}
+
+	return organizationError(s.repo.SetSubscriptionOrganization(ctx, subID, &orgID))
+}
+
+// DetachSubscription returns a subscription of the organization to its owner
+func (s *OrganizationService) DetachSubscription(ctx context.Context, orgID, subID int) error {
+	sub, err := s.subs.GetById(ctx, subID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
+	}
+
+	if sub.OrganizationID == nil || *sub.OrganizationID != orgID {
+		return fmt.Errorf("%w: subscription %d is not attached to the organization", ErrOrganizationNotFound, subID)
+	}
+
+	return organizationError(s.repo.SetSubscriptionOrganization(ctx, subID, nil))
+}
+
+// SubscriptionOrganization returns the organization paying for a subscription, nil for personal subscriptions
+// ErrSubscriptionNotFound is returned for missing subscriptions
+func (s *OrganizationService) SubscriptionOrganization(ctx context.Context, subID int) (*int, error) {
+	sub, err := s.subs.GetById(ctx, subID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
+	}
+
+	return sub.OrganizationID, nil
+}
+
+// GetSubscriptions returns the subscriptions of the organization, optionally filtered by status
+func (s *OrganizationService) GetSubscriptions(ctx context.Context, orgID int, status *string) ([]models.Subscription, error) {
+	if status != nil && !ValidStatus(*status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidStatus, *status)
+	}
+
+	subsDB, err := s.subs.GetAll(ctx, models.SubscriptionListFilter{Status: status, OrganizationID: &orgID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
+	}
+
+	subs := make([]models.Subscription, 0, len(subsDB))
+	for i := range subsDB {
+		subs = append(subs, сonvertDBToAPIModel(subsDB[i]))
+	}
+
+	return subs, nil
+}
+
+// GetSummary calculates the total cost of the organization's subscriptions
+// The organization filter always overrides the one given in the request
+func (s *OrganizationService) GetSummary(ctx context.Context, orgID int, filter models.SubscriptionFilter) (int, error) {
+	filter.Filters.OrganizationID = &orgID
+	return s.subs.GetSubscriptionSummary(ctx, filter)
+}
+
+// organizationError marks organization errors reported by the repository with the matching service error
+func organizationError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, postgres.ErrOrganizationNotFound):
+		return ErrOrganizationNotFound
+	case errors.Is(err, postgres.ErrNotOrganizationMember):
+		return ErrNotOrganizationMember
+	case errors.Is(err, postgres.ErrLastOwner):
+		return fmt.Errorf("%w: %s", ErrOrganizationConflict, err.Error())
+	}
+	return userError(err)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/sirupsen/logrus"
+)
+
+// Errors returned for invalid pause requests and conflicts with the current pause state
+var (
+	ErrInvalidPause  = errors.New("invalid pause")
+	ErrPauseConflict = errors.New("pause conflict")
+)
+
+// PauseService implements business logic for pausing and resuming subscriptions
+type PauseService struct {
+	repo    postgres.PauseStore
+	subs    postgres.SubscriptionStore
+	budgets BudgetEvaluator
+}
+
+// NewPauseService creates a new pause service instance
+// Budgets of the owner are re-evaluated after every pause and resume
+func NewPauseService(repo postgres.PauseStore, subs postgres.SubscriptionStore, budgets BudgetEvaluator) *PauseService {
+	return &PauseService{repo: repo, subs: subs, budgets: budgets}
+}
+
+// Pause stops billing of the subscription starting with the given month (current month by default)
+func (s *PauseService) Pause(ctx context.Context, subID int, input models.PauseInput) error {
+	sub, err := s.subs.GetById(ctx, subID)
+	if err != nil {
+		return pauseError(err)
+	}
+
+	from, err := pauseMonth(input)
+	if err != nil {
+		return err
+	}
+
+	// A pause outside of the billed period would have no effect
+	if from.Before(sub.StartDate) {
+		return fmt.Errorf("%w: pause must not start before the subscription start date", ErrInvalidPause)
+	}
+	if sub.FinishDate != nil && !from.Before(*sub.FinishDate) {
+		return fmt.Errorf("%w: pause must start before the subscription finish date", ErrInvalidPause)
+	}
+
+	if err := ValidateTransition(sub.Status, models.StatusPaused); err != nil {
+		return err
+	}
+
+	// A pause starting in a later month keeps the status until then, SyncStatuses applies it
+	status := sub.Status
+	if !from.After(startOfMonth(time.Now())) {
+		status = models.StatusPaused
+	}
+
+	if _, err := s.repo.Pause(ctx, subID, from, sub.Status, status); err != nil {
+		return pauseError(err)
+	}
+
+	s.evaluateBudgets(ctx, sub.UserID.String())
+	return nil
+}
+
+// Resume continues billing of a paused subscription from the given month (current month by default)
+func (s *PauseService) Resume(ctx context.Context, subID int, input models.PauseInput) error {
+	sub, err := s.subs.GetById(ctx, subID)
+	if err != nil {
+		return pauseError(err)
+	}
+
+	from, err := pauseMonth(input)
+	if err != nil {
+		return err
+	}
+
+	// Trials that were paused before converting continue as trials. A subscription stays paused
+	// until billing resumes in a later month, and a pause that has not started yet is cancelled
+	// without a status change; SyncStatuses catches up with the status when the month comes
+	status := resumedStatus(sub)
+	switch {
+	case sub.Status == models.StatusPaused && from.After(startOfMonth(time.Now())):
+		status = models.StatusPaused
+	case sub.Status == models.StatusTrial || sub.Status == models.StatusActive:
+		status = sub.Status
+	default:
+		if err := ValidateTransition(sub.Status, status); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.repo.Resume(ctx, subID, from, sub.Status, status); err != nil {
+		return pauseError(err)
+	}
+
+	s.evaluateBudgets(ctx, sub.UserID.String())
+	return nil
+}
+
+// SyncStatuses updates the status of subscriptions whose pause starts or ends in the current month
+// and returns how many subscriptions changed
+func (s *PauseService) SyncStatuses(ctx context.Context) (int, error) {
+	return s.repo.SyncStatuses(ctx, startOfMonth(time.Now()))
+}
+
+// evaluateBudgets re-checks the owner's budgets after billing changed
+// The change is already committed, so evaluation errors are logged instead of returned
+func (s *PauseService) evaluateBudgets(ctx context.Context, userID string) {
+	if err := s.budgets.EvaluateBudgets(ctx, userID); err != nil {
+		logrus.WithContext(ctx).Warnf("failed to evaluate budgets of user %s: %s", userID, err.Error())
+	}
+}
+
+// pauseError marks missing subscriptions and pause state conflicts reported by the repository
+// with the matching service error
+func pauseError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, postgres.ErrSubscriptionNotFound) {
+		return ErrSubscriptionNotFound
+	}
+	if errors.Is(err, postgres.ErrStatusConflict) {
+		return fmt.Errorf("%w: %s", ErrInvalidTransition, err.Error())
+	}
+	if errors.Is(err, postgres.ErrAlreadyPaused) || errors.Is(err, postgres.ErrNotPaused) || errors.Is(err, postgres.ErrPauseOverlap) {
+		return fmt.Errorf("%w: %s", ErrPauseConflict, err.Error())
+	}
+	return err
+}
+
+// pauseMonth parses the month of a pause or resume request, defaulting to the current month
+func pauseMonth(input models.PauseInput) (time.Time, error) {
+	if input.From == "" {
+		return startOfMonth(time.Now()), nil
+	}
+
+	from, err := time.Parse("01-2006", input.From)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid from format, expected MM-YYYY: %s", ErrInvalidPause, err.Error())
+	}
+	return from, nil
+}
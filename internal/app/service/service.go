@@ -1,28 +1,137 @@
 package service
 
 import (
+	"context"
+
 	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
 	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
 )
 
 // SubscriptionStore defines business logic operations for subscriptions
 type SubscriptionStore interface {
-	Create(sub models.Subscription) (int, error)
-	GetAll() ([]*models.Subscription, error)
-	GetById(subID int) (models.Subscription, error)
-	Delete(subID int) error
-	Update(subID int, input models.UpdateSubscription) error
-	GetSubscriptionSummary(filter models.SubscriptionFilter) (int, error)
+	Create(ctx context.Context, sub models.Subscription) (int, error)
+	GetAll(ctx context.Context, filter models.SubscriptionListFilter) ([]*models.Subscription, error)
+	GetById(ctx context.Context, subID int) (models.Subscription, error)
+	Delete(ctx context.Context, subID int) error
+	Update(ctx context.Context, subID int, input models.UpdateSubscription) error
+	GetSubscriptionSummary(ctx context.Context, filter models.SubscriptionFilter) (int, error)
+	FindDuplicates(ctx context.Context, subID int) ([]models.Subscription, error)
+	GetDuplicates(ctx context.Context) ([]models.DuplicateGroup, error)
+}
+
+// BudgetStore defines business logic operations for user budgets
+type BudgetStore interface {
+	Create(ctx context.Context, userID string, input models.Budget) (int, error)
+	GetAll(ctx context.Context, userID string) ([]models.BudgetStatus, error)
+	GetById(ctx context.Context, userID string, budgetID int) (models.BudgetStatus, error)
+	Delete(ctx context.Context, userID string, budgetID int) error
+	EvaluateBudgets(ctx context.Context, userID string) error
+	EvaluateAll(ctx context.Context) (int, error)
+}
+
+// AnalyticsStore defines spend analytics operations
+type AnalyticsStore interface {
+	Forecast(ctx context.Context, userID *string, months int) (models.Forecast, error)
+	MonthlySpend(ctx context.Context, userID string, from, to string) (models.Forecast, error)
+}
+
+// TrialStore defines business logic operations for free trials
+type TrialStore interface {
+	GetEndingTrials(ctx context.Context, months int) ([]models.Subscription, error)
+	ConvertEndedTrials(ctx context.Context) (int, error)
+}
+
+// PauseStore defines business logic operations for pausing subscriptions
+type PauseStore interface {
+	Pause(ctx context.Context, subID int, input models.PauseInput) error
+	Resume(ctx context.Context, subID int, input models.PauseInput) error
+	SyncStatuses(ctx context.Context) (int, error)
+}
+
+// MemberStore defines business logic operations for shared subscriptions
+type MemberStore interface {
+	AddMember(ctx context.Context, subID int, input models.Member) error
+	GetMembers(ctx context.Context, subID int) ([]models.Member, error)
+	RemoveMember(ctx context.Context, subID int, userID string) error
+	GetSettlements(ctx context.Context, userID *string, from, to string) ([]models.Settlement, error)
+}
+
+// UserStore defines business logic operations for users
+type UserStore interface {
+	Create(ctx context.Context, input models.User) (string, error)
+	GetById(ctx context.Context, userID string) (models.User, error)
+	Update(ctx context.Context, userID string, input models.UpdateUser) error
+	Delete(ctx context.Context, userID string, cascade bool) error
+	GetSubscriptions(ctx context.Context, userID string, status *string) ([]models.Subscription, error)
+}
+
+// OrganizationStore defines business logic operations for organizations and their members
+type OrganizationStore interface {
+	Create(ctx context.Context, callerID string, input models.Organization) (int, error)
+	GetById(ctx context.Context, orgID int) (models.Organization, error)
+	Update(ctx context.Context, orgID int, input models.Organization) error
+	Delete(ctx context.Context, orgID int) error
+	Role(ctx context.Context, orgID int, userID string) (string, error)
+	GetMembers(ctx context.Context, orgID int) ([]models.OrganizationMember, error)
+	SetMember(ctx context.Context, callerRole string, orgID int, userID string, input models.OrganizationMember) error
+	RemoveMember(ctx context.Context, callerRole string, orgID int, userID string) error
+	AttachSubscription(ctx context.Context, callerID, callerRole string, orgID, subID int) error
+	DetachSubscription(ctx context.Context, orgID, subID int) error
+	SubscriptionOrganization(ctx context.Context, subID int) (*int, error)
+	GetSubscriptions(ctx context.Context, orgID int, status *string) ([]models.Subscription, error)
+	GetSummary(ctx context.Context, orgID int, filter models.SubscriptionFilter) (int, error)
+}
+
+// LifecycleStore defines status transitions of subscriptions that are not covered by pausing
+type LifecycleStore interface {
+	Cancel(ctx context.Context, subID int, input models.CancelInput) error
+	ExpireEnded(ctx context.Context) (int, error)
+}
+
+// StatsStore defines aggregate statistics exported as business metrics
+type StatsStore interface {
+	GetStats(ctx context.Context) (models.SubscriptionStats, error)
+}
+
+// BudgetEvaluator re-checks user budgets after their subscriptions change
+type BudgetEvaluator interface {
+	EvaluateBudgets(ctx context.Context, userID string) error
+}
+
+// Config holds business rule settings of the service layer
+type Config struct {
+	DuplicatePolicy string // How overlapping subscriptions are handled: "warn" (default) or "reject"
 }
 
 // Service layer aggregates all business logic services
 type Service struct {
 	SubscriptionStore
+	BudgetStore
+	AnalyticsStore
+	TrialStore
+	PauseStore
+	LifecycleStore
+	MemberStore
+	UserStore
+	OrganizationStore
+	StatsStore
 }
 
 // NewService constructs new Service layer with business logic
-func NewService(repos *postgres.Repository) *Service {
+func NewService(repos *postgres.Repository, cfg Config) *Service {
+	budgets := NewBudgetService(repos.BudgetStore, repos.SubscriptionStore)
+	duplicates := NewDuplicateDetector(repos.SubscriptionStore, cfg.DuplicatePolicy)
+
 	return &Service{
-		SubscriptionStore: NewSubscriptionService(repos.SubscriptionStore),
+		SubscriptionStore: NewSubscriptionService(repos.SubscriptionStore, budgets, duplicates),
+		BudgetStore:       budgets,
+		AnalyticsStore:    NewAnalyticsService(repos.SubscriptionStore),
+		TrialStore:        NewTrialService(repos.SubscriptionStore),
+		PauseStore:        NewPauseService(repos.PauseStore, repos.SubscriptionStore, budgets),
+		LifecycleStore:    NewLifecycleService(repos.SubscriptionStore, budgets),
+		MemberStore:       NewMemberService(repos.MemberStore, repos.SubscriptionStore, budgets),
+		UserStore:         NewUserService(repos.UserStore, repos.SubscriptionStore),
+		OrganizationStore: NewOrganizationService(repos.OrganizationStore, repos.SubscriptionStore),
+		StatsStore:        NewStatsService(repos.SubscriptionStore),
 	}
 }
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+)
+
+// StatsService implements business logic for subscription statistics
+type StatsService struct {
+	repo postgres.SubscriptionStore
+}
+
+// NewStatsService creates a new statistics service instance
+func NewStatsService(repo postgres.SubscriptionStore) *StatsService {
+	return &StatsService{repo: repo}
+}
+
+// GetStats counts subscriptions per status and sums the charges of the current month
+// Every known status is present in the result, with zero when no subscription has it
+func (s *StatsService) GetStats(ctx context.Context) (models.SubscriptionStats, error) {
+	counts, err := s.repo.CountByStatus(ctx)
+	if err != nil {
+		return models.SubscriptionStats{}, err
+	}
+
+	stats := models.SubscriptionStats{ByStatus: make(map[string]int, len(transitions))}
+	for status := range transitions {
+		stats.ByStatus[status] = 0
+	}
+	for _, c := range counts {
+		stats.ByStatus[c.Status] = c.Count
+	}
+
+	month := time.Now().Format("01-2006")
+	stats.MonthlySpend, err = s.repo.GetSubscriptionSummary(ctx, models.SubscriptionFilter{
+		Period: models.Period{StartDate: month, FinishDate: month},
+	})
+	if err != nil {
+		return models.SubscriptionStats{}, fmt.Errorf("failed to calculate monthly spend: %w", err)
+	}
+
+	return stats, nil
+}
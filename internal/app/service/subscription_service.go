@@ -1,63 +1,208 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
 	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
+// ErrSubscriptionNotFound is returned when the requested subscription does not exist
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
 // SubscriptionService implements business logic for subscription operations
 type SubscriptionService struct {
-	repo postgres.SubscriptionStore
+	repo       postgres.SubscriptionStore
+	budgets    BudgetEvaluator
+	duplicates *DuplicateDetector
 }
 
 // NewSubscriptionService creates a new subscription service instance
-func NewSubscriptionService(repo postgres.SubscriptionStore) *SubscriptionService {
-	return &SubscriptionService{repo: repo}
+// Budgets of the affected user are re-evaluated after every create and update,
+// and overlapping subscriptions are handled according to the detector's policy
+func NewSubscriptionService(repo postgres.SubscriptionStore, budgets BudgetEvaluator, duplicates *DuplicateDetector) *SubscriptionService {
+	return &SubscriptionService{repo: repo, budgets: budgets, duplicates: duplicates}
 }
 
 // Create handles the business logic for creating a new subscription
 // Transforms API model (Subscription) to database model (SubscriptionDB)
 // Performs data validation and transformation before persistence
-func (s *SubscriptionService) Create(sub models.Subscription) (int, error) {
+func (s *SubscriptionService) Create(ctx context.Context, sub models.Subscription) (_ int, err error) {
+	ctx, end := startSpan(ctx, "SubscriptionService.Create")
+	defer end(&err)
 
 	// Parse string UserID from API request into UUID format for database storage
-	_, err := uuid.Parse(sub.UserID)
+	_, err = uuid.Parse(sub.UserID)
 	if err != nil {
 		return 0, fmt.Errorf("invalid user ID format: %w", err)
 	}
 
 	// Parse string date from API request into time.Time object
 	// Uses "01-2006" format (month-year) following Go's reference date format
-	_, err = time.Parse("01-2006", sub.StartDate)
+	startDate, err := time.Parse("01-2006", sub.StartDate)
 	if err != nil {
 		return 0, fmt.Errorf("invalid start date format, expected MM-YYYY: %w", err)
 	}
 
+	// Finish date is optional: an empty value means the subscription is open-ended
+	var finishDate *time.Time
+	if sub.FinishDate != "" {
+		parsed, err := time.Parse("01-2006", sub.FinishDate)
+		if err != nil {
+			return 0, fmt.Errorf("invalid finish date format, expected MM-YYYY: %w", err)
+		}
+		if err := validatePeriod(startDate, &parsed); err != nil {
+			return 0, err
+		}
+		finishDate = &parsed
+	}
+
+	// Trial is optional: billing starts the month after the last free month
+	var trialEnd *time.Time
+	if sub.TrialEnd != "" {
+		parsed, err := time.Parse("01-2006", sub.TrialEnd)
+		if err != nil {
+			return 0, fmt.Errorf("invalid trial end format, expected MM-YYYY: %w", err)
+		}
+		trialEnd = &parsed
+	}
+	if err := validateTrial(startDate, finishDate, trialEnd, sub.PriceAfterTrial); err != nil {
+		return 0, err
+	}
+
+	// Subscriptions are billed monthly unless another cadence is requested
+	if sub.BillingCycle == "" {
+		sub.BillingCycle = models.BillingMonthly
+	}
+	if err := validateBillingCycle(sub.BillingCycle); err != nil {
+		return 0, err
+	}
+
+	// Overlapping subscriptions of the same service would be double-counted in summaries
+	sub.RejectOverlaps = s.duplicates.Rejects()
+
+	// New subscriptions start in trial when a free period is given
+	sub.Status = initialStatus(sub.TrialEnd)
+
 	// Delegate to repository layer for actual database persistence
-	return s.repo.Create(sub)
+	subID, err := s.repo.Create(ctx, sub)
+	if err != nil {
+		return 0, duplicateError(userError(err))
+	}
+
+	s.evaluateBudgets(ctx, sub.UserID)
+
+	return subID, nil
+}
+
+// validatePeriod ensures the exclusive finish date leaves at least one billed month
+func validatePeriod(startDate time.Time, finishDate *time.Time) error {
+	if finishDate != nil && !finishDate.After(startDate) {
+		return errors.New("finish date must be after start date")
+	}
+	return nil
+}
+
+// validateTrial ensures the trial lies within the subscription period
+// and that a price after trial is only set for trials
+func validateTrial(startDate time.Time, finishDate, trialEnd *time.Time, priceAfterTrial *int) error {
+	if priceAfterTrial != nil {
+		if trialEnd == nil {
+			return errors.New("price after trial requires a trial end")
+		}
+		if *priceAfterTrial <= 0 {
+			return errors.New("price after trial must be positive")
+		}
+	}
+
+	if trialEnd == nil {
+		return nil
+	}
+	if trialEnd.Before(startDate) {
+		return errors.New("trial end must not be before start date")
+	}
+	if finishDate != nil && !trialEnd.Before(*finishDate) {
+		return errors.New("trial end must be before finish date")
+	}
+	return nil
+}
+
+// validateBillingCycle ensures the cadence is one of the supported billing cycles
+func validateBillingCycle(cycle string) error {
+	if models.BillingCycleMonths(cycle) == 0 {
+		return fmt.Errorf("invalid billing cycle %q, expected monthly, quarterly or yearly", cycle)
+	}
+	return nil
+}
+
+// evaluateBudgets re-checks the user's budgets after a subscription change
+// The change is already committed, so evaluation errors are logged instead of returned
+func (s *SubscriptionService) evaluateBudgets(ctx context.Context, userID string) {
+	if err := s.budgets.EvaluateBudgets(ctx, userID); err != nil {
+		logrus.WithContext(ctx).Warnf("failed to evaluate budgets of user %s: %s", userID, err.Error())
+	}
 }
 
 // ConvertDBToAPIModel transforms a database model to an API response model
 func сonvertDBToAPIModel(subdb models.SubscriptionDB) models.Subscription {
-	return models.Subscription{
-		Id:          subdb.Id,
-		ServiceName: subdb.ServiceName,
-		Price:       subdb.Price,
-		UserID:      subdb.UserID.String(),
-		StartDate:   subdb.StartDate.Format("01-2006"),
-		FinishDate:  subdb.FinishDate.Format("01-2006"),
+	sub := models.Subscription{
+		Id:             subdb.Id,
+		ServiceName:    subdb.ServiceName,
+		Price:          subdb.Price,
+		UserID:         subdb.UserID.String(),
+		StartDate:      subdb.StartDate.Format("01-2006"),
+		BillingCycle:   subdb.BillingCycle,
+		Status:         subdb.Status,
+		OrganizationID: subdb.OrganizationID,
+	}
+
+	// Open-ended subscriptions have no finish date
+	if subdb.FinishDate != nil {
+		sub.FinishDate = subdb.FinishDate.Format("01-2006")
+	}
+
+	// An open pause that already started means the current month is not billed
+	if subdb.PausedFrom != nil {
+		sub.PausedFrom = subdb.PausedFrom.Format("01-2006")
+		sub.Paused = !subdb.PausedFrom.After(time.Now())
+	}
+
+	if subdb.TrialEnd != nil {
+		sub.TrialEnd = subdb.TrialEnd.Format("01-2006")
+		sub.PriceAfterTrial = subdb.PriceAfterTrial
+	}
+
+	if subdb.Category != nil {
+		sub.Category = *subdb.Category
 	}
+
+	if subdb.CancelledAt != nil {
+		sub.CancelledAt = subdb.CancelledAt.Format("02-01-2006")
+	}
+	if subdb.CancellationReason != nil {
+		sub.CancellationReason = *subdb.CancellationReason
+	}
+
+	return sub
 }
 
 // GetAll retrieves all subscriptions from the repository and converts them to API model format
 // Returns a slice of Subscription models or an error if data retrieval fails
-func (s *SubscriptionService) GetAll() ([]*models.Subscription, error) {
+func (s *SubscriptionService) GetAll(ctx context.Context, filter models.SubscriptionListFilter) (_ []*models.Subscription, err error) {
+	ctx, end := startSpan(ctx, "SubscriptionService.GetAll")
+	defer end(&err)
+
+	if filter.Status != nil && !ValidStatus(*filter.Status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidStatus, *filter.Status)
+	}
+
 	// Retrieve all subscriptions from the repository layer (database)
-	subsDB, err := s.repo.GetAll()
+	subsDB, err := s.repo.GetAll(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
 	}
@@ -76,11 +221,12 @@ func (s *SubscriptionService) GetAll() ([]*models.Subscription, error) {
 }
 
 // GetById implements business logic for retrieving subscription by ID (to be implemented)
-func (s *SubscriptionService) GetById(subID int) (models.Subscription, error) {
-	var sub models.Subscription
+func (s *SubscriptionService) GetById(ctx context.Context, subID int) (sub models.Subscription, err error) {
+	ctx, end := startSpan(ctx, "SubscriptionService.GetById")
+	defer end(&err)
 
 	// Retrieve  subscription by ID from the repository layer (database)
-	subDB, err := s.repo.GetById(subID)
+	subDB, err := s.repo.GetById(ctx, subID)
 	if err != nil {
 		return sub, fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
 	}
@@ -92,34 +238,144 @@ func (s *SubscriptionService) GetById(subID int) (models.Subscription, error) {
 }
 
 // Delete implements subscription deletion business logic (to be implemented)
-func (s *SubscriptionService) Delete(subID int) error {
-	return s.repo.Delete(subID)
+func (s *SubscriptionService) Delete(ctx context.Context, subID int) (err error) {
+	ctx, end := startSpan(ctx, "SubscriptionService.Delete")
+	defer end(&err)
+
+	return s.repo.Delete(ctx, subID)
 }
 
 // Update handles the business logic for updating an existing subscription
 // Validates input data before delegating to the repository layer for persistence
-func (s *SubscriptionService) Update(subID int, input models.UpdateSubscription) error {
+func (s *SubscriptionService) Update(ctx context.Context, subID int, input models.UpdateSubscription) (err error) {
+	ctx, end := startSpan(ctx, "SubscriptionService.Update")
+	defer end(&err)
+
 	// Validate input data using the model's validation method
 	// This ensures business rules are enforced before database operations
 	if err := input.Validate(); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
 	}
 
-	if input.StartDate != nil {
-		// Parse string date from API request into time.Time object
-		// Uses "01-2006" format (month-year) following Go's reference date format
-		_, err := time.Parse("01-2006", *input.StartDate)
+	if input.BillingCycle != nil {
+		if err := validateBillingCycle(*input.BillingCycle); err != nil {
+			return err
+		}
+	}
+
+	// Date and trial changes are validated against the stored values of the fields that are not updated
+	if input.StartDate != nil || input.FinishDate != nil || input.TrialEnd != nil || input.PriceAfterTrial != nil {
+		current, err := s.repo.GetById(ctx, subID)
 		if err != nil {
-			return fmt.Errorf("invalid start date format, expected MM-YYYY: %w", err)
+			return fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
+		}
+		startDate, finishDate, trialEnd := current.StartDate, current.FinishDate, current.TrialEnd
+
+		if input.StartDate != nil {
+			// Parse string date from API request into time.Time object
+			// Uses "01-2006" format (month-year) following Go's reference date format
+			startDate, err = time.Parse("01-2006", *input.StartDate)
+			if err != nil {
+				return fmt.Errorf("invalid start date format, expected MM-YYYY: %w", err)
+			}
+		}
+
+		if input.FinishDate != nil {
+			finishDate = nil
+			if *input.FinishDate != "" {
+				parsed, err := time.Parse("01-2006", *input.FinishDate)
+				if err != nil {
+					return fmt.Errorf("invalid finish date format, expected MM-YYYY: %w", err)
+				}
+				finishDate = &parsed
+			}
+		}
+
+		if input.TrialEnd != nil {
+			trialEnd = nil
+			if *input.TrialEnd != "" {
+				parsed, err := time.Parse("01-2006", *input.TrialEnd)
+				if err != nil {
+					return fmt.Errorf("invalid trial end format, expected MM-YYYY: %w", err)
+				}
+				trialEnd = &parsed
+			}
+		}
+
+		// Removing the trial also removes its price after trial
+		priceAfterTrial := current.PriceAfterTrial
+		if trialEnd == nil {
+			priceAfterTrial = nil
+		}
+		if input.PriceAfterTrial != nil {
+			priceAfterTrial = input.PriceAfterTrial
+		}
+
+		if err := validatePeriod(startDate, finishDate); err != nil {
+			return err
 		}
+		if err := validateTrial(startDate, finishDate, trialEnd, priceAfterTrial); err != nil {
+			return err
+		}
+
+		// The new period must not overlap other subscriptions of the same service
+		input.RejectOverlaps = s.duplicates.Rejects()
 	}
 
 	// Delegate the update operation to the repository layer
 	// The repository handles the actual database interaction
-	return s.repo.Update(subID, input)
+	if err := s.repo.Update(ctx, subID, input); err != nil {
+		// A price below the fixed amounts of members would leave the owner a negative share
+		if errors.Is(err, postgres.ErrFixedAmounts) {
+			return fmt.Errorf("%w: %s", ErrMemberConflict, err.Error())
+		}
+		if errors.Is(err, postgres.ErrStatusConflict) {
+			return fmt.Errorf("%w: %s", ErrInvalidTransition, err.Error())
+		}
+		return duplicateError(err)
+	}
+
+	// Price, date or category changes may move the owner across a budget limit
+	subDB, err := s.repo.GetById(ctx, subID)
+	if err != nil {
+		logrus.WithContext(ctx).Warnf("failed to load subscription %d for budget evaluation: %s", subID, err.Error())
+		return nil
+	}
+	s.evaluateBudgets(ctx, subDB.UserID.String())
+
+	return nil
 }
 
 // GetSubscriptionSummary converts API filters to DB format and calculates total cost
-func (s *SubscriptionService) GetSubscriptionSummary(filter models.SubscriptionFilter) (int, error) {
-	return s.repo.GetSubscriptionSummary(filter)
+func (s *SubscriptionService) GetSubscriptionSummary(ctx context.Context, filter models.SubscriptionFilter) (_ int, err error) {
+	ctx, end := startSpan(ctx, "SubscriptionService.GetSubscriptionSummary")
+	defer end(&err)
+
+	return s.repo.GetSubscriptionSummary(ctx, filter)
+}
+
+// FindDuplicates returns subscriptions of the same user and service that overlap the given subscription
+// Under the reject policy a stored subscription has no overlaps, so nothing is queried
+func (s *SubscriptionService) FindDuplicates(ctx context.Context, subID int) (_ []models.Subscription, err error) {
+	ctx, end := startSpan(ctx, "SubscriptionService.FindDuplicates")
+	defer end(&err)
+
+	if s.duplicates.Rejects() {
+		return nil, nil
+	}
+
+	subDB, err := s.repo.GetById(ctx, subID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
+	}
+
+	return s.duplicates.Find(ctx, subDB)
+}
+
+// GetDuplicates reports all groups of overlapping subscriptions in existing data
+func (s *SubscriptionService) GetDuplicates(ctx context.Context) (_ []models.DuplicateGroup, err error) {
+	ctx, end := startSpan(ctx, "SubscriptionService.GetDuplicates")
+	defer end(&err)
+
+	return s.duplicates.Report(ctx)
 }
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// tracer creates the spans of service methods
+var tracer = otel.Tracer("github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service")
+
+// startSpan starts a span for a service method
+// The returned function ends the span and marks it failed when the method returned an error
+func startSpan(ctx context.Context, name string) (context.Context, func(err *error)) {
+	ctx, span := tracer.Start(ctx, name)
+	return ctx, func(err *error) {
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
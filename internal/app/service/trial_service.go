@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+)
+
+// MaxTrialLookaheadMonths limits how far ahead the ending trials view can look
+const MaxTrialLookaheadMonths = 24
+
+// ErrInvalidTrialLookahead is returned when the ending trials view is asked for an unsupported number of months
+var ErrInvalidTrialLookahead = errors.New("invalid trial lookahead")
+
+// TrialService implements business logic for free trials
+type TrialService struct {
+	repo postgres.SubscriptionStore
+}
+
+// NewTrialService creates a new trial service instance
+func NewTrialService(repo postgres.SubscriptionStore) *TrialService {
+	return &TrialService{repo: repo}
+}
+
+// GetEndingTrials returns trials whose last free month is within the given number of months,
+// starting with the current month, so users can cancel before the first charge
+func (s *TrialService) GetEndingTrials(ctx context.Context, months int) ([]models.Subscription, error) {
+	if months < 1 || months > MaxTrialLookaheadMonths {
+		return nil, fmt.Errorf("%w: months must be between 1 and %d", ErrInvalidTrialLookahead, MaxTrialLookaheadMonths)
+	}
+
+	from := startOfMonth(time.Now())
+	subsDB, err := s.repo.GetEndingTrials(ctx, from, from.AddDate(0, months-1, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve trials from repository: %w", err)
+	}
+
+	subs := make([]models.Subscription, 0, len(subsDB))
+	for i := range subsDB {
+		subs = append(subs, сonvertDBToAPIModel(subsDB[i]))
+	}
+
+	return subs, nil
+}
+
+// ConvertEndedTrials marks trials that ended before the current month as paid
+// and returns how many subscriptions were converted
+func (s *TrialService) ConvertEndedTrials(ctx context.Context) (int, error) {
+	converted, err := s.repo.ConvertEndedTrials(ctx, startOfMonth(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(converted), nil
+}
+
+// startOfMonth truncates a time to the first day of its month in UTC
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
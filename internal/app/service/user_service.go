@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/google/uuid"
+)
+
+// Errors returned for invalid input, missing users and conflicting user changes
+var (
+	ErrInvalidUser  = errors.New("invalid user")
+	ErrUserNotFound = errors.New("user not found")
+	ErrUserConflict = errors.New("user conflict")
+)
+
+// UserService implements business logic for users
+type UserService struct {
+	repo postgres.UserStore
+	subs postgres.SubscriptionStore
+}
+
+// NewUserService creates a new user service instance
+func NewUserService(repo postgres.UserStore, subs postgres.SubscriptionStore) *UserService {
+	return &UserService{repo: repo, subs: subs}
+}
+
+// Create validates and stores a new user and returns its ID
+// A client-provided ID is kept, otherwise a new one is generated
+func (s *UserService) Create(ctx context.Context, input models.User) (string, error) {
+	if err := input.Validate(); err != nil {
+		return "", fmt.Errorf("%w: validation failed: %s", ErrInvalidUser, err.Error())
+	}
+
+	userDB := models.UserDB{
+		DisplayName: input.DisplayName,
+		Timezone:    input.Timezone,
+		Currency:    input.Currency,
+	}
+	if input.Id != "" {
+		id, err := uuid.Parse(input.Id)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid user ID format: %s", ErrInvalidUser, err.Error())
+		}
+		userDB.Id = id
+	}
+
+	created, err := s.repo.Create(ctx, userDB)
+	if err != nil {
+		return "", userError(err)
+	}
+
+	return created.Id.String(), nil
+}
+
+// GetById returns a single user
+func (s *UserService) GetById(ctx context.Context, userID string) (models.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return models.User{}, fmt.Errorf("%w: invalid user ID format: %s", ErrInvalidUser, err.Error())
+	}
+
+	userDB, err := s.repo.GetById(ctx, userID)
+	if err != nil {
+		return models.User{}, userError(err)
+	}
+
+	return models.User{
+		Id:          userDB.Id.String(),
+		DisplayName: userDB.DisplayName,
+		Timezone:    userDB.Timezone,
+		Currency:    userDB.Currency,
+	}, nil
+}
+
+// Update changes the display name and preferences of a user
+func (s *UserService) Update(ctx context.Context, userID string, input models.UpdateUser) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("%w: invalid user ID format: %s", ErrInvalidUser, err.Error())
+	}
+
+	if err := input.Validate(); err != nil {
+		return fmt.Errorf("%w: validation failed: %s", ErrInvalidUser, err.Error())
+	}
+
+	return userError(s.repo.Update(ctx, userID, input))
+}
+
+// Delete removes a user; owned subscriptions are deleted too when cascade is set,
+// otherwise they block the deletion
+func (s *UserService) Delete(ctx context.Context, userID string, cascade bool) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("%w: invalid user ID format: %s", ErrInvalidUser, err.Error())
+	}
+
+	return userError(s.repo.Delete(ctx, userID, cascade))
+}
+
+// GetSubscriptions returns personal subscriptions the user owns or shares, optionally filtered by status
+// Subscriptions paid by an organization are visible to its members only
+func (s *UserService) GetSubscriptions(ctx context.Context, userID string, status *string) ([]models.Subscription, error) {
+	if _, err := s.GetById(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if status != nil && !ValidStatus(*status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidStatus, *status)
+	}
+
+	subsDB, err := s.subs.GetAll(ctx, models.SubscriptionListFilter{Status: status, UserID: &userID, Personal: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve subscriptions from repository: %w", err)
+	}
+
+	subs := make([]models.Subscription, 0, len(subsDB))
+	for i := range subsDB {
+		subs = append(subs, сonvertDBToAPIModel(subsDB[i]))
+	}
+
+	return subs, nil
+}
+
+// userError marks user errors reported by the repository with the matching service error
+func userError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, postgres.ErrUserNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, postgres.ErrUserExists), errors.Is(err, postgres.ErrUserHasSubscriptions):
+		return fmt.Errorf("%w: %s", ErrUserConflict, err.Error())
+	}
+	return err
+}
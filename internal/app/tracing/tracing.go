@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Supported span exporters
+const (
+	ExporterNone   = "none"   // Spans are not recorded, trace context is still propagated
+	ExporterStdout = "stdout" // Spans are written to standard output as JSON
+	ExporterOTLP   = "otlp"   // Spans are sent to an OpenTelemetry collector over OTLP/HTTP
+)
+
+// DefaultServiceName identifies the application in traces
+const DefaultServiceName = "subscription-aggregator"
+
+// Config holds tracing settings
+type Config struct {
+	Exporter     string  // none, stdout or otlp
+	OTLPEndpoint string  // Collector URL for the otlp exporter, e.g. http://localhost:4318
+	ServiceName  string  // service.name resource attribute
+	SampleRatio  float64 // Fraction of new traces recorded; traces started upstream follow the caller's decision
+}
+
+// ValidExporter reports whether the exporter name is supported
+func ValidExporter(exporter string) bool {
+	return exporter == ExporterNone || exporter == ExporterStdout || exporter == ExporterOTLP
+}
+
+// Setup installs the global tracer provider and the W3C trace-context propagator
+// The returned function flushes pending spans and must be called on shutdown
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	// Incoming traceparent headers are honoured and forwarded even when spans are not exported
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Periodic runs a background job at a fixed interval until its context is cancelled
+type Periodic struct {
+	name     string
+	interval time.Duration
+	job      func(ctx context.Context) error
+}
+
+// NewPeriodic creates a new periodic worker instance
+func NewPeriodic(name string, interval time.Duration, job func(ctx context.Context) error) *Periodic {
+	return &Periodic{
+		name:     name,
+		interval: interval,
+		job:      job,
+	}
+}
+
+// Run executes the job immediately and then on every tick
+// Blocks the caller, so it is expected to be started in a separate goroutine
+func (p *Periodic) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		// Job errors are logged and the job is retried on the next tick
+		if err := p.job(ctx); err != nil {
+			logrus.Errorf("worker %s: %s", p.name, err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,464 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/cache"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/ratelimit"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/server"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/tracing"
+	"github.com/joho/godotenv"
+)
+
+// DefaultFile is the configuration file read when no other file is requested
+// A missing default file is not an error, unlike an explicitly requested one
+const DefaultFile = "config.env"
+
+// Config holds all application settings
+type Config struct {
+	HTTP                server.Config        // HTTP server address, timeouts and TLS files
+	DB                  postgres.Config      // PostgreSQL connection and pool settings
+	AutoMigrate         bool                 // Apply pending migrations when the server starts
+	ConnectInBackground bool                 // Start the server not ready and connect to the database in the background
+	WebhookURL          string               // Outbox webhook endpoint, events are logged when empty
+	DuplicatePolicy     string               // Handling of overlapping subscriptions: warn or reject
+	HealthTimeout       time.Duration        // Time limit of each readiness check
+	Tracing             tracing.Config       // Span exporter and sampling
+	RateLimit           ratelimit.Config     // Request limits per client and route class
+	Cache               cache.Config         // Cache of subscriptions and summaries
+	Spend               postgres.SpendConfig // Maintenance of the monthly spend aggregate
+}
+
+// Default returns the configuration used for settings that are not set anywhere else
+func Default() Config {
+	return Config{
+		HTTP: server.Config{
+			Host:              "localhost",
+			Port:              "8080",
+			ReadTimeout:       10 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      10 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   15 * time.Second,
+			DrainDelay:        5 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			TLS: server.TLSConfig{
+				Enabled:        false,
+				CertFile:       "cert.pem",
+				KeyFile:        "key.pem",
+				ReloadInterval: time.Minute,
+			},
+		},
+		DB: postgres.Config{
+			Host:            "localhost",
+			Port:            "5432",
+			Username:        "postgres",
+			DBName:          "postgres",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+
+			ConnectTimeout:      postgres.DefaultConnectTimeout,
+			RetryInitialBackoff: postgres.DefaultRetryInitialBackoff,
+			RetryMaxBackoff:     postgres.DefaultRetryMaxBackoff,
+		},
+		AutoMigrate:     true,
+		DuplicatePolicy: "warn",
+		HealthTimeout:   2 * time.Second,
+		Tracing: tracing.Config{
+			Exporter:     tracing.ExporterNone,
+			OTLPEndpoint: "http://localhost:4318",
+			ServiceName:  tracing.DefaultServiceName,
+			SampleRatio:  1,
+		},
+		RateLimit: ratelimit.Config{
+			Enabled: true,
+			Store:   ratelimit.StoreMemory,
+			Read:    ratelimit.Limit{Rate: 10, Burst: 20},
+			Write:   ratelimit.Limit{Rate: 2, Burst: 10},
+			Summary: ratelimit.Limit{Rate: 0.2, Burst: 5},
+		},
+		Cache: cache.Config{
+			Enabled: true,
+			Size:    10000,
+			TTL:     30 * time.Second,
+			Notify:  true,
+		},
+		Spend: postgres.SpendConfig{
+			RefreshInterval: 10 * time.Second,
+			HorizonMonths:   24,
+		},
+	}
+}
+
+// setting binds one configuration value to its environment variable and command-line flag
+type setting struct {
+	env   string                            // Environment variable and config file key
+	flag  string                            // Command-line flag name
+	usage string                            // Flag description
+	set   func(cfg *Config, v string) error // Parses the value into the configuration
+}
+
+// settings lists every supported configuration value
+var settings = []setting{
+	{"HOST", "host", "HTTP listen host", stringValue(func(c *Config) *string { return &c.HTTP.Host })},
+	{"HOST_PORT", "port", "HTTP listen port", stringValue(func(c *Config) *string { return &c.HTTP.Port })},
+	{"HTTP_READ_TIMEOUT", "http-read-timeout", "maximum duration for reading a request", durationValue(func(c *Config) *time.Duration { return &c.HTTP.ReadTimeout })},
+	{"HTTP_READ_HEADER_TIMEOUT", "http-read-header-timeout", "maximum duration for reading request headers", durationValue(func(c *Config) *time.Duration { return &c.HTTP.ReadHeaderTimeout })},
+	{"HTTP_WRITE_TIMEOUT", "http-write-timeout", "maximum duration for writing a response", durationValue(func(c *Config) *time.Duration { return &c.HTTP.WriteTimeout })},
+	{"HTTP_IDLE_TIMEOUT", "http-idle-timeout", "maximum idle time of keep-alive connections", durationValue(func(c *Config) *time.Duration { return &c.HTTP.IdleTimeout })},
+	{"HTTP_SHUTDOWN_TIMEOUT", "http-shutdown-timeout", "maximum duration of graceful shutdown", durationValue(func(c *Config) *time.Duration { return &c.HTTP.ShutdownTimeout })},
+	{"HTTP_DRAIN_DELAY", "http-drain-delay", "time requests are still served after readiness fails on shutdown", durationValue(func(c *Config) *time.Duration { return &c.HTTP.DrainDelay })},
+	{"HTTP_MAX_HEADER_BYTES", "http-max-header-bytes", "maximum size of request headers in bytes", intValue(func(c *Config) *int { return &c.HTTP.MaxHeaderBytes })},
+	{"TLS_ENABLED", "tls", "serve HTTPS, disable behind a TLS-terminating proxy", boolValue(func(c *Config) *bool { return &c.HTTP.TLS.Enabled })},
+	{"TLS_CERT_FILE", "tls-cert", "TLS certificate file", stringValue(func(c *Config) *string { return &c.HTTP.TLS.CertFile })},
+	{"TLS_KEY_FILE", "tls-key", "TLS private key file", stringValue(func(c *Config) *string { return &c.HTTP.TLS.KeyFile })},
+	{"TLS_CLIENT_CA_FILE", "tls-client-ca", "CA bundle for verifying client certificates, empty disables mTLS", stringValue(func(c *Config) *string { return &c.HTTP.TLS.ClientCAFile })},
+	{"TLS_RELOAD_INTERVAL", "tls-reload-interval", "how often certificate files are checked for changes (0 disables reloading)", durationValue(func(c *Config) *time.Duration { return &c.HTTP.TLS.ReloadInterval })},
+	{"TLS_REDIRECT_PORT", "tls-redirect-port", "plain HTTP port redirecting to HTTPS, empty disables the redirect", stringValue(func(c *Config) *string { return &c.HTTP.TLS.RedirectPort })},
+	{"DB_URL", "db-url", "complete connection string (postgres:// URL or key=value DSN), replaces DB_HOST, DB_PORT, DB_USER, DB_PASSWORD, DB_NAME and DB_SSL_MODE", stringValue(func(c *Config) *string { return &c.DB.URL })},
+	{"DB_HOST", "db-host", "PostgreSQL host", stringValue(func(c *Config) *string { return &c.DB.Host })},
+	{"DB_PORT", "db-port", "PostgreSQL port", stringValue(func(c *Config) *string { return &c.DB.Port })},
+	{"DB_USER", "db-user", "PostgreSQL user", stringValue(func(c *Config) *string { return &c.DB.Username })},
+	{"DB_PASSWORD", "db-password", "PostgreSQL password", stringValue(func(c *Config) *string { return &c.DB.Password })},
+	{"DB_NAME", "db-name", "PostgreSQL database name", stringValue(func(c *Config) *string { return &c.DB.DBName })},
+	{"DB_SSL_MODE", "db-ssl-mode", "PostgreSQL SSL mode", stringValue(func(c *Config) *string { return &c.DB.SSLMode })},
+	{"DB_SSL_ROOT_CERT", "db-ssl-root-cert", "CA certificate file for verify-ca and verify-full SSL modes", stringValue(func(c *Config) *string { return &c.DB.SSLRootCert })},
+	{"DB_SSL_CERT", "db-ssl-cert", "client certificate file for certificate authentication", stringValue(func(c *Config) *string { return &c.DB.SSLCert })},
+	{"DB_SSL_KEY", "db-ssl-key", "client private key file for certificate authentication", stringValue(func(c *Config) *string { return &c.DB.SSLKey })},
+	{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "maximum number of open connections (0 is unlimited)", intValue(func(c *Config) *int { return &c.DB.MaxOpenConns })},
+	{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "maximum number of idle connections", intValue(func(c *Config) *int { return &c.DB.MaxIdleConns })},
+	{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "maximum lifetime of a connection (0 is unlimited)", durationValue(func(c *Config) *time.Duration { return &c.DB.ConnMaxLifetime })},
+	{"DB_CONN_MAX_IDLE_TIME", "db-conn-max-idle-time", "maximum idle time of a connection (0 is unlimited)", durationValue(func(c *Config) *time.Duration { return &c.DB.ConnMaxIdleTime })},
+	{"DB_CONNECT_TIMEOUT", "db-connect-timeout", "how long startup waits for the database before giving up", durationValue(func(c *Config) *time.Duration { return &c.DB.ConnectTimeout })},
+	{"DB_RETRY_INITIAL_BACKOFF", "db-retry-initial-backoff", "delay before the first connection retry, doubled on every next one", durationValue(func(c *Config) *time.Duration { return &c.DB.RetryInitialBackoff })},
+	{"DB_RETRY_MAX_BACKOFF", "db-retry-max-backoff", "longest delay between connection retries", durationValue(func(c *Config) *time.Duration { return &c.DB.RetryMaxBackoff })},
+	{"DB_CONNECT_IN_BACKGROUND", "db-connect-in-background", "serve immediately and report not ready until the database is connected and migrated", boolValue(func(c *Config) *bool { return &c.ConnectInBackground })},
+	{"DB_AUTO_MIGRATE", "db-auto-migrate", "apply pending migrations on server start, disable to run migrate separately", boolValue(func(c *Config) *bool { return &c.AutoMigrate })},
+	{"OUTBOX_WEBHOOK_URL", "outbox-webhook-url", "outbox webhook endpoint, events are logged when empty", stringValue(func(c *Config) *string { return &c.WebhookURL })},
+	{"HEALTH_CHECK_TIMEOUT", "health-check-timeout", "time limit of each readiness check", durationValue(func(c *Config) *time.Duration { return &c.HealthTimeout })},
+	{"TRACING_EXPORTER", "tracing-exporter", "span exporter: none, stdout or otlp", stringValue(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"TRACING_OTLP_ENDPOINT", "tracing-otlp-endpoint", "OpenTelemetry collector URL for the otlp exporter", stringValue(func(c *Config) *string { return &c.Tracing.OTLPEndpoint })},
+	{"TRACING_SERVICE_NAME", "tracing-service-name", "service name reported in traces", stringValue(func(c *Config) *string { return &c.Tracing.ServiceName })},
+	{"TRACING_SAMPLE_RATIO", "tracing-sample-ratio", "fraction of new traces recorded, from 0 to 1", floatValue(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+	{"RATE_LIMIT_ENABLED", "rate-limit", "limit requests per client and route class", boolValue(func(c *Config) *bool { return &c.RateLimit.Enabled })},
+	{"RATE_LIMIT_STORE", "rate-limit-store", "rate limit bucket store: memory (per replica) or postgres (shared)", stringValue(func(c *Config) *string { return &c.RateLimit.Store })},
+	{"RATE_LIMIT_READ_RPS", "rate-limit-read-rps", "sustained read requests per second per client", floatValue(func(c *Config) *float64 { return &c.RateLimit.Read.Rate })},
+	{"RATE_LIMIT_READ_BURST", "rate-limit-read-burst", "read requests allowed at once per client", intValue(func(c *Config) *int { return &c.RateLimit.Read.Burst })},
+	{"RATE_LIMIT_WRITE_RPS", "rate-limit-write-rps", "sustained write requests per second per client", floatValue(func(c *Config) *float64 { return &c.RateLimit.Write.Rate })},
+	{"RATE_LIMIT_WRITE_BURST", "rate-limit-write-burst", "write requests allowed at once per client", intValue(func(c *Config) *int { return &c.RateLimit.Write.Burst })},
+	{"RATE_LIMIT_SUMMARY_RPS", "rate-limit-summary-rps", "sustained summary and analytics requests per second per client", floatValue(func(c *Config) *float64 { return &c.RateLimit.Summary.Rate })},
+	{"RATE_LIMIT_SUMMARY_BURST", "rate-limit-summary-burst", "summary and analytics requests allowed at once per client", intValue(func(c *Config) *int { return &c.RateLimit.Summary.Burst })},
+	{"CACHE_ENABLED", "cache", "cache subscriptions and summaries in memory", boolValue(func(c *Config) *bool { return &c.Cache.Enabled })},
+	{"CACHE_SIZE", "cache-size", "maximum number of cached subscriptions and of cached summaries", intValue(func(c *Config) *int { return &c.Cache.Size })},
+	{"CACHE_TTL", "cache-ttl", "how long a cached entry is served before it is read again", durationValue(func(c *Config) *time.Duration { return &c.Cache.TTL })},
+	{"CACHE_NOTIFY", "cache-notify", "drop changed entries via PostgreSQL LISTEN/NOTIFY, required by the cache", boolValue(func(c *Config) *bool { return &c.Cache.Notify })},
+	{"SPEND_REFRESH_INTERVAL", "spend-refresh-interval", "how often changes are applied to the monthly spend aggregate (0 disables the job)", durationValue(func(c *Config) *time.Duration { return &c.Spend.RefreshInterval })},
+	{"SPEND_HORIZON_MONTHS", "spend-horizon-months", "months after the current one kept in the monthly spend aggregate", intValue(func(c *Config) *int { return &c.Spend.HorizonMonths })},
+	{"DUPLICATE_POLICY", "duplicate-policy", "handling of overlapping subscriptions: warn or reject", stringValue(func(c *Config) *string { return &c.DuplicatePolicy })},
+}
+
+// Load builds the configuration from, in increasing precedence: defaults, the config file,
+// environment variables and command-line flags. The file is DefaultFile unless another one
+// is given with the -config flag or the CONFIG_FILE variable. The result is validated
+func Load(args []string) (Config, error) {
+	cfg, _, err := Parse(args)
+	return cfg, err
+}
+
+// Parse works like Load and also returns the arguments left after the flags
+func Parse(args []string) (Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	file := fs.String("config", "", "configuration file (default "+DefaultFile+")")
+	flagValues := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flagValues[s.env] = fs.String(s.flag, "", s.usage+" ("+s.env+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	// The file is optional unless explicitly requested
+	path, required := DefaultFile, false
+	if env, ok := os.LookupEnv("CONFIG_FILE"); ok && env != "" {
+		path, required = env, true
+	}
+	if *file != "" {
+		path, required = *file, true
+	}
+	fileValues, err := godotenv.Read(path)
+	if err != nil {
+		if required || !errors.Is(err, os.ErrNotExist) {
+			return Config{}, nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+		fileValues = map[string]string{}
+	}
+
+	// Only explicitly passed flags override other sources
+	passed := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { passed[f.Name] = true })
+
+	for _, s := range settings {
+		value, ok := fileValues[s.env]
+		if env, found := os.LookupEnv(s.env); found {
+			value, ok = env, true
+		}
+		if passed[s.flag] {
+			value, ok = *flagValues[s.env], true
+		}
+		if !ok {
+			continue
+		}
+
+		if err := s.set(&cfg, value); err != nil {
+			return Config{}, nil, fmt.Errorf("invalid %s: %w", s.env, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, nil, err
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// Validate checks required settings, ports and limits
+func (c Config) Validate() error {
+	var errs []error
+
+	if err := validatePort(c.HTTP.Port); err != nil {
+		errs = append(errs, fmt.Errorf("HOST_PORT: %w", err))
+	}
+	// Separate connection fields are only used without DB_URL
+	required := map[string]string{}
+	if c.DB.URL == "" {
+		if err := validatePort(c.DB.Port); err != nil {
+			errs = append(errs, fmt.Errorf("DB_PORT: %w", err))
+		}
+		required["DB_HOST"] = c.DB.Host
+		required["DB_USER"] = c.DB.Username
+		required["DB_NAME"] = c.DB.DBName
+	} else if _, err := c.DB.DSN(); err != nil {
+		errs = append(errs, fmt.Errorf("DB_URL: %w", err))
+	}
+	if c.HTTP.TLS.Enabled {
+		required["TLS_CERT_FILE"] = c.HTTP.TLS.CertFile
+		required["TLS_KEY_FILE"] = c.HTTP.TLS.KeyFile
+	}
+	for _, s := range settings {
+		if value, ok := required[s.env]; ok && value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", s.env))
+		}
+	}
+
+	timeouts := map[string]time.Duration{
+		"HTTP_READ_TIMEOUT":        c.HTTP.ReadTimeout,
+		"HTTP_READ_HEADER_TIMEOUT": c.HTTP.ReadHeaderTimeout,
+		"HTTP_WRITE_TIMEOUT":       c.HTTP.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":        c.HTTP.IdleTimeout,
+		"HTTP_SHUTDOWN_TIMEOUT":    c.HTTP.ShutdownTimeout,
+		"HEALTH_CHECK_TIMEOUT":     c.HealthTimeout,
+		"DB_CONNECT_TIMEOUT":       c.DB.ConnectTimeout,
+		"DB_RETRY_INITIAL_BACKOFF": c.DB.RetryInitialBackoff,
+		"DB_RETRY_MAX_BACKOFF":     c.DB.RetryMaxBackoff,
+	}
+	for _, s := range settings {
+		if value, ok := timeouts[s.env]; ok && value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", s.env))
+		}
+	}
+
+	if c.HTTP.DrainDelay < 0 || c.HTTP.DrainDelay >= c.HTTP.ShutdownTimeout {
+		errs = append(errs, errors.New("HTTP_DRAIN_DELAY must not be negative and must be shorter than HTTP_SHUTDOWN_TIMEOUT"))
+	}
+
+	errs = append(errs, validateTLS(c.HTTP)...)
+
+	if !tracing.ValidExporter(c.Tracing.Exporter) {
+		errs = append(errs, fmt.Errorf("TRACING_EXPORTER: unknown exporter %q, expected none, stdout or otlp", c.Tracing.Exporter))
+	}
+	if c.Tracing.Exporter == tracing.ExporterOTLP && c.Tracing.OTLPEndpoint == "" {
+		errs = append(errs, errors.New("TRACING_OTLP_ENDPOINT is required for the otlp exporter"))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("TRACING_SAMPLE_RATIO must be between 0 and 1"))
+	}
+
+	errs = append(errs, validateRateLimit(c.RateLimit)...)
+
+	if c.Cache.Enabled {
+		if c.Cache.Size < 1 {
+			errs = append(errs, errors.New("CACHE_SIZE must be at least 1"))
+		}
+		if c.Cache.TTL <= 0 {
+			errs = append(errs, errors.New("CACHE_TTL must be positive"))
+		}
+		// Pauses, members, organizations and lifecycle jobs write past the cache, only
+		// notifications drop the entries they change
+		if !c.Cache.Notify {
+			errs = append(errs, errors.New("CACHE_NOTIFY is required while CACHE_ENABLED is set"))
+		}
+	}
+
+	if c.Spend.RefreshInterval < 0 {
+		errs = append(errs, errors.New("SPEND_REFRESH_INTERVAL must not be negative"))
+	}
+	if c.Spend.HorizonMonths < 0 {
+		errs = append(errs, errors.New("SPEND_HORIZON_MONTHS must not be negative"))
+	}
+
+	if c.HTTP.MaxHeaderBytes <= 0 {
+		errs = append(errs, errors.New("HTTP_MAX_HEADER_BYTES must be positive"))
+	}
+	if c.DB.MaxOpenConns < 0 || c.DB.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS and DB_MAX_IDLE_CONNS must not be negative"))
+	}
+	if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		errs = append(errs, errors.New("DB_MAX_IDLE_CONNS must not exceed DB_MAX_OPEN_CONNS"))
+	}
+	if c.DB.ConnMaxLifetime < 0 || c.DB.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME must not be negative"))
+	}
+	if c.DB.RetryInitialBackoff > c.DB.RetryMaxBackoff {
+		errs = append(errs, errors.New("DB_RETRY_INITIAL_BACKOFF must not exceed DB_RETRY_MAX_BACKOFF"))
+	}
+	if (c.DB.SSLCert == "") != (c.DB.SSLKey == "") {
+		errs = append(errs, errors.New("DB_SSL_CERT and DB_SSL_KEY must be set together"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateTLS checks that TLS-only settings are used with TLS enabled
+func validateTLS(c server.Config) []error {
+	var errs []error
+
+	if !c.TLS.Enabled {
+		if c.TLS.ClientCAFile != "" {
+			errs = append(errs, errors.New("TLS_CLIENT_CA_FILE requires TLS_ENABLED"))
+		}
+		if c.TLS.RedirectPort != "" {
+			errs = append(errs, errors.New("TLS_REDIRECT_PORT requires TLS_ENABLED"))
+		}
+		return errs
+	}
+
+	if c.TLS.ReloadInterval < 0 {
+		errs = append(errs, errors.New("TLS_RELOAD_INTERVAL must not be negative"))
+	}
+	if c.TLS.RedirectPort != "" {
+		if err := validatePort(c.TLS.RedirectPort); err != nil {
+			errs = append(errs, fmt.Errorf("TLS_REDIRECT_PORT: %w", err))
+		} else if c.TLS.RedirectPort == c.Port {
+			errs = append(errs, errors.New("TLS_REDIRECT_PORT must differ from HOST_PORT"))
+		}
+	}
+
+	return errs
+}
+
+// validateRateLimit checks the store and that every limit lets requests through
+func validateRateLimit(c ratelimit.Config) []error {
+	if !c.Enabled {
+		return nil
+	}
+
+	var errs []error
+	if !ratelimit.ValidStore(c.Store) {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_STORE: unknown store %q, expected memory or postgres", c.Store))
+	}
+
+	limits := []struct {
+		name  string
+		limit ratelimit.Limit
+	}{
+		{"READ", c.Read},
+		{"WRITE", c.Write},
+		{"SUMMARY", c.Summary},
+	}
+	for _, l := range limits {
+		if l.limit.Rate <= 0 {
+			errs = append(errs, fmt.Errorf("RATE_LIMIT_%s_RPS must be positive", l.name))
+		}
+		if l.limit.Burst < 1 {
+			errs = append(errs, fmt.Errorf("RATE_LIMIT_%s_BURST must be at least 1", l.name))
+		}
+	}
+
+	return errs
+}
+
+// validatePort ensures the value is a TCP port number
+func validatePort(port string) error {
+	value, err := strconv.Atoi(port)
+	if err != nil || value < 1 || value > 65535 {
+		return fmt.Errorf("invalid port %q, expected 1-65535", port)
+	}
+	return nil
+}
+
+// stringValue returns a setter for a string setting
+func stringValue(field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		*field(c) = v
+		return nil
+	}
+}
+
+// intValue returns a setter for an integer setting
+func intValue(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		value, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", v)
+		}
+		*field(c) = value
+		return nil
+	}
+}
+
+// boolValue returns a setter for a boolean setting such as "true" or "0"
+func boolValue(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		value, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %q", v)
+		}
+		*field(c) = value
+		return nil
+	}
+}
+
+// floatValue returns a setter for a decimal setting
+func floatValue(field func(*Config) *float64) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("expected a number, got %q", v)
+		}
+		*field(c) = value
+		return nil
+	}
+}
+
+// durationValue returns a setter for a duration setting such as "10s" or "5m"
+func durationValue(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		value, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("expected a duration such as 10s, got %q", v)
+		}
+		*field(c) = value
+		return nil
+	}
+}
@@ -0,0 +1,3 @@
+DROP TABLE budgets;
+
+ALTER TABLE subscriptions DROP COLUMN category;
\ No newline at end of file
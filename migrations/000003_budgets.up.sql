@@ -0,0 +1,13 @@
+ALTER TABLE subscriptions ADD COLUMN category VARCHAR(64);
+
+CREATE TABLE budgets (
+    id SERIAL PRIMARY KEY UNIQUE,
+    user_id UUID NOT NULL,
+    amount INT NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL,
+    category VARCHAR(64),
+    breached BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX budgets_user_id_idx ON budgets (user_id);
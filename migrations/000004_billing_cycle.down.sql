@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions DROP COLUMN billing_cycle;
+
+ALTER TABLE subscriptions DROP COLUMN finish_date;
+ALTER TABLE subscriptions ADD COLUMN finish_date DATE GENERATED ALWAYS AS (start_date + INTERVAL '1 month') STORED;
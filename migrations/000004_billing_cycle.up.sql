@@ -0,0 +1,6 @@
+-- finish_date becomes a real end date (exclusive, NULL for open-ended subscriptions);
+-- existing rows keep their previously generated value
+ALTER TABLE subscriptions ALTER COLUMN finish_date DROP EXPRESSION;
+
+ALTER TABLE subscriptions ADD COLUMN billing_cycle VARCHAR(16) NOT NULL DEFAULT 'monthly'
+    CHECK (billing_cycle IN ('monthly', 'quarterly', 'yearly'));
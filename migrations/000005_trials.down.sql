@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions DROP COLUMN trial_converted_at;
+ALTER TABLE subscriptions DROP COLUMN price_after_trial;
+ALTER TABLE subscriptions DROP COLUMN trial_end;
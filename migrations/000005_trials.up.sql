@@ -0,0 +1,6 @@
+-- trial_end is the last free month (inclusive); billing starts the month after it
+ALTER TABLE subscriptions ADD COLUMN trial_end DATE;
+ALTER TABLE subscriptions ADD COLUMN price_after_trial INT CHECK (price_after_trial > 0);
+ALTER TABLE subscriptions ADD COLUMN trial_converted_at TIMESTAMPTZ;
+
+CREATE INDEX subscriptions_trial_pending_idx ON subscriptions (trial_end) WHERE trial_end IS NOT NULL AND trial_converted_at IS NULL;
@@ -0,0 +1 @@
+DROP TABLE subscription_pauses;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- A pause skips billing from paused_from (inclusive) until resumed_from (exclusive);
+-- resumed_from is NULL while the subscription is still paused
+CREATE TABLE subscription_pauses (
+    id SERIAL PRIMARY KEY UNIQUE,
+    subscription_id INT NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    paused_from DATE NOT NULL,
+    resumed_from DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (resumed_from IS NULL OR resumed_from > paused_from)
+);
+
+CREATE INDEX subscription_pauses_subscription_id_idx ON subscription_pauses (subscription_id);
+
+-- At most one open pause per subscription
+CREATE UNIQUE INDEX subscription_pauses_open_idx ON subscription_pauses (subscription_id) WHERE resumed_from IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions DROP COLUMN cancellation_reason;
+ALTER TABLE subscriptions DROP COLUMN cancelled_at;
+ALTER TABLE subscriptions DROP COLUMN status;
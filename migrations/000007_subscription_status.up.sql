@@ -0,0 +1,11 @@
+ALTER TABLE subscriptions ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('trial', 'active', 'paused', 'cancelled', 'expired'));
+ALTER TABLE subscriptions ADD COLUMN cancelled_at DATE;
+ALTER TABLE subscriptions ADD COLUMN cancellation_reason TEXT;
+
+-- Derive the status of existing rows from their trial and pause data
+UPDATE subscriptions SET status = 'trial' WHERE trial_end IS NOT NULL AND trial_converted_at IS NULL;
+UPDATE subscriptions s SET status = 'paused'
+WHERE EXISTS (SELECT 1 FROM subscription_pauses p WHERE p.subscription_id = s.id AND p.resumed_from IS NULL);
+
+CREATE INDEX subscriptions_status_idx ON subscriptions (status);
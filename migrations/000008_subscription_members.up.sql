@@ -0,0 +1,15 @@
+-- Members share the cost of a subscription paid by its owner (subscriptions.user_id).
+-- Every member pays either a fixed amount or a weighted part of what is left after fixed amounts;
+-- the owner takes part with weight 1 unless listed as a member
+CREATE TABLE subscription_members (
+    id SERIAL PRIMARY KEY UNIQUE,
+    subscription_id INT NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    share_weight INT CHECK (share_weight > 0),
+    fixed_amount INT CHECK (fixed_amount > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((share_weight IS NULL) <> (fixed_amount IS NULL)),
+    UNIQUE (subscription_id, user_id)
+);
+
+CREATE INDEX subscription_members_user_id_idx ON subscription_members (user_id);
@@ -0,0 +1,6 @@
+DROP INDEX subscriptions_user_id_idx;
+ALTER TABLE subscription_members DROP CONSTRAINT subscription_members_user_id_fkey;
+ALTER TABLE budgets DROP CONSTRAINT budgets_user_id_fkey;
+ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_user_id_fkey;
+ALTER TABLE subscriptions ALTER COLUMN user_id DROP NOT NULL;
+DROP TABLE users;
@@ -0,0 +1,31 @@
+CREATE TABLE users (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    currency VARCHAR(3) NOT NULL DEFAULT 'RUB',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Register every user already referenced by existing data
+INSERT INTO users (id)
+SELECT user_id FROM subscriptions WHERE user_id IS NOT NULL
+UNION
+SELECT user_id FROM budgets
+UNION
+SELECT user_id FROM subscription_members
+ON CONFLICT DO NOTHING;
+
+-- The API always sets an owner; rows inserted without one must be fixed by hand before migrating
+ALTER TABLE subscriptions ALTER COLUMN user_id SET NOT NULL;
+
+-- Owned subscriptions block deleting a user unless they are removed explicitly;
+-- budgets and memberships belong to the user and are removed with it
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id);
+ALTER TABLE budgets ADD CONSTRAINT budgets_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE subscription_members ADD CONSTRAINT subscription_members_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+
+CREATE INDEX subscriptions_user_id_idx ON subscriptions (user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions DROP COLUMN organization_id;
+DROP TABLE organization_members;
+DROP TABLE organizations;
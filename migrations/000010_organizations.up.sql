@@ -0,0 +1,20 @@
+CREATE TABLE organizations (
+    id SERIAL PRIMARY KEY UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE organization_members (
+    organization_id INT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'admin', 'member', 'viewer')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX organization_members_user_id_idx ON organization_members (user_id);
+
+-- Subscriptions paid by an organization; they stay with their owner when the organization is deleted
+ALTER TABLE subscriptions ADD COLUMN organization_id INT REFERENCES organizations (id) ON DELETE SET NULL;
+
+CREATE INDEX subscriptions_organization_id_idx ON subscriptions (organization_id);
@@ -0,0 +1,9 @@
+-- Token buckets shared by all replicas when the Postgres rate limit store is enabled
+CREATE UNLOGGED TABLE rate_limits (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX rate_limits_updated_at_idx ON rate_limits (updated_at);
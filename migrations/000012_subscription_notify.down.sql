@@ -0,0 +1,4 @@
+DROP TRIGGER subscription_members_notify ON subscription_members;
+DROP TRIGGER subscription_pauses_notify ON subscription_pauses;
+DROP TRIGGER subscriptions_notify ON subscriptions;
+DROP FUNCTION notify_subscription_change();
@@ -0,0 +1,29 @@
+-- Every change of a subscription, its pauses or members is announced on the subscription_changes
+-- channel with the subscription ID; notifications are delivered on commit, so replicas caching
+-- subscriptions and summaries drop entries only once the change is visible
+CREATE FUNCTION notify_subscription_change() RETURNS trigger AS $$
+DECLARE
+    changed JSONB;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := to_jsonb(OLD);
+    ELSE
+        changed := to_jsonb(NEW);
+    END IF;
+    -- The first trigger argument names the column holding the subscription ID
+    PERFORM pg_notify('subscription_changes', changed ->> TG_ARGV[0]);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscriptions_notify
+    AFTER INSERT OR UPDATE OR DELETE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION notify_subscription_change('id');
+
+CREATE TRIGGER subscription_pauses_notify
+    AFTER INSERT OR UPDATE OR DELETE ON subscription_pauses
+    FOR EACH ROW EXECUTE FUNCTION notify_subscription_change('subscription_id');
+
+CREATE TRIGGER subscription_members_notify
+    AFTER INSERT OR UPDATE OR DELETE ON subscription_members
+    FOR EACH ROW EXECUTE FUNCTION notify_subscription_change('subscription_id');
@@ -0,0 +1,10 @@
+DROP TRIGGER subscription_members_monthly_spend ON subscription_members;
+DROP TRIGGER subscription_pauses_monthly_spend ON subscription_pauses;
+DROP TRIGGER subscriptions_monthly_spend ON subscriptions;
+DROP FUNCTION member_spend_changed();
+DROP FUNCTION pause_spend_changed();
+DROP FUNCTION subscription_spend_changed();
+DROP FUNCTION mark_monthly_spend_dirty(INT, UUID, VARCHAR);
+DROP TABLE monthly_spend_dirty;
+DROP TABLE monthly_spend_state;
+DROP TABLE monthly_spend;
@@ -0,0 +1,97 @@
+-- Per-month spend of every user on every service: the sum of the user's shares of all charges,
+-- as calculated from raw subscriptions by summaries. Months are filled up to covered_until;
+-- without a state row the aggregate has not been built and summaries read raw data
+CREATE TABLE monthly_spend (
+    user_id UUID NOT NULL,
+    service_name VARCHAR(255) NOT NULL,
+    month DATE NOT NULL,
+    amount BIGINT NOT NULL,
+    PRIMARY KEY (user_id, service_name, month)
+);
+
+CREATE INDEX monthly_spend_service_name_idx ON monthly_spend (service_name, month);
+CREATE INDEX monthly_spend_month_idx ON monthly_spend (month);
+
+CREATE TABLE monthly_spend_state (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    covered_until DATE NOT NULL,
+    rebuilt_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Users and services whose aggregate is stale; written by triggers in the transaction of the
+-- change and consumed by the refresh job. Keys may repeat, so writers never wait for each other
+CREATE TABLE monthly_spend_dirty (
+    user_id UUID NOT NULL,
+    service_name VARCHAR(255) NOT NULL
+);
+
+CREATE INDEX monthly_spend_dirty_key_idx ON monthly_spend_dirty (user_id, service_name);
+
+-- Marks the owner and every member of a subscription as stale for its service
+CREATE FUNCTION mark_monthly_spend_dirty(sub_id INT, owner_id UUID, service VARCHAR) RETURNS void AS $$
+    INSERT INTO monthly_spend_dirty (user_id, service_name)
+    SELECT owner_id, service
+    UNION
+    SELECT user_id, service FROM subscription_members WHERE subscription_id = sub_id;
+$$ LANGUAGE sql;
+
+-- Runs before the change so members are still present when a subscription is deleted;
+-- updates that do not change charges, like status transitions, are ignored
+CREATE FUNCTION subscription_spend_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND
+       (OLD.user_id, OLD.service_name, OLD.price, OLD.start_date, OLD.finish_date, OLD.billing_cycle, OLD.trial_end, OLD.price_after_trial) IS NOT DISTINCT FROM
+       (NEW.user_id, NEW.service_name, NEW.price, NEW.start_date, NEW.finish_date, NEW.billing_cycle, NEW.trial_end, NEW.price_after_trial) THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM mark_monthly_spend_dirty(OLD.id, OLD.user_id, OLD.service_name);
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    PERFORM mark_monthly_spend_dirty(NEW.id, NEW.user_id, NEW.service_name);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- A pause changes the charges of every participant; pauses removed together with their
+-- subscription find no row and were already marked by the subscription trigger
+CREATE FUNCTION pause_spend_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM mark_monthly_spend_dirty(s.id, s.user_id, s.service_name) FROM subscriptions s WHERE s.id = OLD.subscription_id;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        PERFORM mark_monthly_spend_dirty(s.id, s.user_id, s.service_name) FROM subscriptions s WHERE s.id = NEW.subscription_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- A member changes the shares of every participant, a removed member is marked as well
+CREATE FUNCTION member_spend_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        INSERT INTO monthly_spend_dirty (user_id, service_name)
+        SELECT OLD.user_id, s.service_name FROM subscriptions s WHERE s.id = OLD.subscription_id;
+        PERFORM mark_monthly_spend_dirty(s.id, s.user_id, s.service_name) FROM subscriptions s WHERE s.id = OLD.subscription_id;
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        PERFORM mark_monthly_spend_dirty(s.id, s.user_id, s.service_name) FROM subscriptions s WHERE s.id = NEW.subscription_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscriptions_monthly_spend
+    BEFORE INSERT OR UPDATE OR DELETE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION subscription_spend_changed();
+
+CREATE TRIGGER subscription_pauses_monthly_spend
+    AFTER INSERT OR UPDATE OR DELETE ON subscription_pauses
+    FOR EACH ROW EXECUTE FUNCTION pause_spend_changed();
+
+CREATE TRIGGER subscription_members_monthly_spend
+    AFTER INSERT OR UPDATE OR DELETE ON subscription_members
+    FOR EACH ROW EXECUTE FUNCTION member_spend_changed();
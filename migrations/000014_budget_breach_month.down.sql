@@ -0,0 +1,3 @@
+ALTER TABLE budgets ADD COLUMN breached BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE budgets SET breached = breached_month = date_trunc('month', NOW())::date WHERE breached_month IS NOT NULL;
+ALTER TABLE budgets DROP COLUMN breached_month;
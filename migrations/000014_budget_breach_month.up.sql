@@ -0,0 +1,4 @@
+-- The breach state is kept per month, so a budget exceeded again in a later month raises a new alert
+ALTER TABLE budgets ADD COLUMN breached_month DATE;
+UPDATE budgets SET breached_month = date_trunc('month', NOW())::date WHERE breached;
+ALTER TABLE budgets DROP COLUMN breached;
@@ -0,0 +1,7 @@
+DROP INDEX outbox_aggregate_pending_idx;
+DROP INDEX outbox_pending_idx;
+CREATE INDEX outbox_pending_idx ON outbox (id) WHERE delivered_at IS NULL;
+
+ALTER TABLE outbox DROP COLUMN dead_at;
+ALTER TABLE outbox DROP COLUMN claimed_until;
+ALTER TABLE outbox DROP COLUMN next_attempt_at;
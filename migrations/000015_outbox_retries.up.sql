@@ -0,0 +1,9 @@
+-- Events are claimed with a lease and published outside the claiming transaction.
+-- Failed deliveries are retried after next_attempt_at; events that keep failing are dead-lettered.
+ALTER TABLE outbox ADD COLUMN next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE outbox ADD COLUMN claimed_until TIMESTAMPTZ;
+ALTER TABLE outbox ADD COLUMN dead_at TIMESTAMPTZ;
+
+DROP INDEX outbox_pending_idx;
+CREATE INDEX outbox_pending_idx ON outbox (id) WHERE delivered_at IS NULL AND dead_at IS NULL;
+CREATE INDEX outbox_aggregate_pending_idx ON outbox (aggregate_type, aggregate_id, id) WHERE delivered_at IS NULL AND dead_at IS NULL;
@@ -0,0 +1,24 @@
+-- Summaries without an organization filter cover personal subscriptions only, so the aggregate
+-- leaves out subscriptions paid by an organization. Attaching or detaching a subscription
+-- changes the spend of its participants and marks them as stale
+CREATE OR REPLACE FUNCTION subscription_spend_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND
+       (OLD.user_id, OLD.service_name, OLD.price, OLD.start_date, OLD.finish_date, OLD.billing_cycle, OLD.trial_end, OLD.price_after_trial, OLD.organization_id) IS NOT DISTINCT FROM
+       (NEW.user_id, NEW.service_name, NEW.price, NEW.start_date, NEW.finish_date, NEW.billing_cycle, NEW.trial_end, NEW.price_after_trial, NEW.organization_id) THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM mark_monthly_spend_dirty(OLD.id, OLD.user_id, OLD.service_name);
+    END IF;
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    PERFORM mark_monthly_spend_dirty(NEW.id, NEW.user_id, NEW.service_name);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- The aggregate was built with subscriptions of organizations: without a state row summaries
+-- read raw data until the refresh job rebuilds it
+DELETE FROM monthly_spend_state;
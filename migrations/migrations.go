@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL schema migrations into the binary
+// so they are applied the same way regardless of the working directory
+package migrations
+
+import "embed"
+
+// FS holds the numbered up and down migrations of golang-migrate
+//
+//go:embed *.sql
+var FS embed.FS
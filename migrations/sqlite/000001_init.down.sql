@@ -0,0 +1,6 @@
+DROP TABLE outbox;
+DROP TABLE subscription_members;
+DROP TABLE subscription_pauses;
+DROP TABLE subscriptions;
+DROP TABLE organizations;
+DROP TABLE users;
@@ -0,0 +1,83 @@
+-- Schema of the SQLite subscription store, equivalent to the PostgreSQL schema of the tables it uses.
+-- Dates are stored as 'YYYY-MM-DD' text (the first day of the month), timestamps as 'YYYY-MM-DD HH:MM:SS' in UTC
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    display_name TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    currency TEXT NOT NULL DEFAULT 'RUB',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE organizations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- finish_date is exclusive, NULL for open-ended subscriptions; trial_end is the last free month (inclusive)
+CREATE TABLE subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    service_name TEXT NOT NULL,
+    price INTEGER NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users (id),
+    start_date DATE NOT NULL,
+    finish_date DATE,
+    category TEXT,
+    billing_cycle TEXT NOT NULL DEFAULT 'monthly'
+        CHECK (billing_cycle IN ('monthly', 'quarterly', 'yearly')),
+    trial_end DATE,
+    price_after_trial INTEGER CHECK (price_after_trial > 0),
+    trial_converted_at TIMESTAMP,
+    status TEXT NOT NULL DEFAULT 'active'
+        CHECK (status IN ('trial', 'active', 'paused', 'cancelled', 'expired')),
+    cancelled_at DATE,
+    cancellation_reason TEXT,
+    organization_id INTEGER REFERENCES organizations (id) ON DELETE SET NULL
+);
+
+CREATE INDEX subscriptions_user_id_idx ON subscriptions (user_id);
+CREATE INDEX subscriptions_status_idx ON subscriptions (status);
+CREATE INDEX subscriptions_organization_id_idx ON subscriptions (organization_id);
+CREATE INDEX subscriptions_trial_pending_idx ON subscriptions (trial_end) WHERE trial_end IS NOT NULL AND trial_converted_at IS NULL;
+
+-- A pause skips billing from paused_from (inclusive) until resumed_from (exclusive)
+CREATE TABLE subscription_pauses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    paused_from DATE NOT NULL,
+    resumed_from DATE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (resumed_from IS NULL OR resumed_from > paused_from)
+);
+
+CREATE INDEX subscription_pauses_subscription_id_idx ON subscription_pauses (subscription_id);
+CREATE UNIQUE INDEX subscription_pauses_open_idx ON subscription_pauses (subscription_id) WHERE resumed_from IS NULL;
+
+CREATE TABLE subscription_members (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    share_weight INTEGER CHECK (share_weight > 0),
+    fixed_amount INTEGER CHECK (fixed_amount > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((share_weight IS NULL) <> (fixed_amount IS NULL)),
+    UNIQUE (subscription_id, user_id)
+);
+
+CREATE INDEX subscription_members_user_id_idx ON subscription_members (user_id);
+
+-- Subscription events are recorded like in PostgreSQL, so a relay can deliver them later
+CREATE TABLE outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMP,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX outbox_pending_idx ON outbox (id) WHERE delivered_at IS NULL;
@@ -0,0 +1,9 @@
+// Package sqlite embeds the schema migrations of the SQLite subscription store
+package sqlite
+
+import "embed"
+
+// FS holds the SQL migration files embedded into the binary
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noCharges - хранилище без списаний
+type noCharges struct {
+	postgres.SubscriptionStore
+}
+
+func (noCharges) GetCharges(ctx context.Context, filter models.ChargeFilter) ([]models.ChargeDB, error) {
+	return nil, nil
+}
+
+// TestForecastValidation проверяет ответы 400 на неверный горизонт и идентификатор пользователя
+func TestForecastValidation(t *testing.T) {
+	analytics := service.NewAnalyticsService(noCharges{})
+	router := handler.NewHandler(&service.Service{AnalyticsStore: analytics}, nil, nil, nil).InitRoutes()
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+	}{
+		{name: "Default horizon", url: "/analytics/forecast", expectedStatus: http.StatusOK},
+		{name: "Single user", url: "/analytics/forecast?months=3&user_id=" + testUsers[0], expectedStatus: http.StatusOK},
+		{name: "Not a number", url: "/analytics/forecast?months=abc", expectedStatus: http.StatusBadRequest},
+		{name: "Zero months", url: "/analytics/forecast?months=0", expectedStatus: http.StatusBadRequest},
+		{name: "Too far ahead", url: "/analytics/forecast?months=121", expectedStatus: http.StatusBadRequest},
+		{name: "Malformed user ID", url: "/analytics/forecast?user_id=not-a-uuid", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+
+	// Отчет использует те же проверки периода
+	_, err := analytics.MonthlySpend(context.Background(), testUsers[0], "01-2025", "13-2025")
+	require.ErrorIs(t, err, service.ErrInvalidForecast)
+	_, err = analytics.MonthlySpend(context.Background(), testUsers[0], "06-2025", "01-2025")
+	require.ErrorIs(t, err, service.ErrInvalidForecast)
+}
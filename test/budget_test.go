@@ -0,0 +1,202 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memBudgetRepo хранит бюджеты в памяти и считает вызовы оценки
+type memBudgetRepo struct {
+	budgets     []models.BudgetDB
+	evaluations int
+}
+
+func (r *memBudgetRepo) Create(ctx context.Context, budget models.BudgetDB) (int, error) {
+	budget.Id = len(r.budgets) + 1
+	r.budgets = append(r.budgets, budget)
+	return budget.Id, nil
+}
+
+func (r *memBudgetRepo) GetByUser(ctx context.Context, userID string) ([]models.BudgetDB, error) {
+	var budgets []models.BudgetDB
+	for _, budget := range r.budgets {
+		if budget.UserID == userID {
+			budgets = append(budgets, budget)
+		}
+	}
+	return budgets, nil
+}
+
+func (r *memBudgetRepo) GetById(ctx context.Context, userID string, budgetID int) (models.BudgetDB, error) {
+	for _, budget := range r.budgets {
+		if budget.Id == budgetID && budget.UserID == userID {
+			return budget, nil
+		}
+	}
+	return models.BudgetDB{}, postgres.ErrBudgetNotFound
+}
+
+func (r *memBudgetRepo) Delete(ctx context.Context, userID string, budgetID int) error {
+	if _, err := r.GetById(ctx, userID, budgetID); err != nil {
+		return err
+	}
+	r.budgets = nil
+	return nil
+}
+
+func (r *memBudgetRepo) GetUserIDs(ctx context.Context) ([]string, error) {
+	return []string{testUsers[0]}, nil
+}
+
+func (r *memBudgetRepo) Evaluate(ctx context.Context, userID string, month time.Time) error {
+	r.evaluations++
+	return nil
+}
+
+// fixedSpend - хранилище, в котором траты любого пользователя за месяц равны used
+type fixedSpend struct {
+	postgres.SubscriptionStore
+	used int
+}
+
+func (s fixedSpend) GetSubscriptionSummary(ctx context.Context, filter models.SubscriptionFilter) (int, error) {
+	return s.used, nil
+}
+
+// TestBudgetValidation проверяет ответы 400 и 404 маршрутов бюджетов
+func TestBudgetValidation(t *testing.T) {
+	repo := &memBudgetRepo{}
+	services := &service.Service{BudgetStore: service.NewBudgetService(repo, fixedSpend{used: 150})}
+	router := handler.NewHandler(services, nil, nil, nil).InitRoutes()
+	budgetsURL := "/users/" + testUsers[0] + "/budgets/"
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		payload        interface{}
+		expectedStatus int
+	}{
+		{name: "Malformed user ID", method: http.MethodPost, url: "/users/not-a-uuid/budgets/", payload: map[string]interface{}{"amount": 100}, expectedStatus: http.StatusBadRequest},
+		{name: "Negative amount", method: http.MethodPost, url: budgetsURL, payload: map[string]interface{}{"amount": -5}, expectedStatus: http.StatusBadRequest},
+		{name: "Unsupported currency", method: http.MethodPost, url: budgetsURL, payload: map[string]interface{}{"amount": 100, "currency": "USD"}, expectedStatus: http.StatusBadRequest},
+		{name: "Successful creation", method: http.MethodPost, url: budgetsURL, payload: map[string]interface{}{"amount": 100}, expectedStatus: http.StatusOK},
+		{name: "Listing with malformed user ID", method: http.MethodGet, url: "/users/not-a-uuid/budgets/", expectedStatus: http.StatusBadRequest},
+		{name: "Unknown budget", method: http.MethodGet, url: budgetsURL + "42", expectedStatus: http.StatusNotFound},
+		{name: "Budget of another user", method: http.MethodGet, url: "/users/" + testUsers[1] + "/budgets/1", expectedStatus: http.StatusNotFound},
+		{name: "Deletion of unknown budget", method: http.MethodDelete, url: budgetsURL + "42", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveJSON(router, tt.method, tt.url, tt.payload, "")
+			assert.Equal(t, tt.expectedStatus, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	// Созданный бюджет сразу оценивается, статус считается по текущим тратам
+	assert.Equal(t, 1, repo.evaluations)
+	recorder := serveJSON(router, http.MethodGet, budgetsURL+"1", nil, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var status models.BudgetStatus
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+	assert.Equal(t, 150, status.Used)
+	assert.True(t, status.Breached)
+
+	evaluated, err := services.BudgetStore.EvaluateAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, evaluated)
+	assert.Equal(t, 2, repo.evaluations)
+}
+
+// TestBudgetAlertsIntegration проверяет, что превышение бюджета дает одно событие за месяц,
+// в том числе при параллельных оценках, и повторное событие в следующем месяце
+func TestBudgetAlertsIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := setupTestDatabase(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+
+	budgets := postgres.NewBudgetRepository(db)
+	subs := postgres.NewSubscriptionRepository(db)
+	userID := testUsers[0]
+
+	_, err = budgets.Create(ctx, models.BudgetDB{UserID: userID, Amount: 500, Currency: models.DefaultCurrency})
+	require.NoError(t, err)
+	subID, err := subs.Create(ctx, models.Subscription{
+		ServiceName: "Netflix", Price: 600, UserID: userID, StartDate: "01-2025",
+		BillingCycle: models.BillingMonthly, Status: models.StatusActive,
+	})
+	require.NoError(t, err)
+
+	alerts := func() int {
+		t.Helper()
+		var count int
+		require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM outbox WHERE event_type = $1", models.EventBudgetBreached))
+		return count
+	}
+	evaluate := func(month time.Time) {
+		t.Helper()
+		require.NoError(t, budgets.Evaluate(ctx, userID, month))
+	}
+
+	// Параллельные оценки сериализуются блокировкой бюджетов
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, budgets.Evaluate(ctx, userID, monthOf(2025, time.January)))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, alerts())
+
+	evaluate(monthOf(2025, time.January))
+	assert.Equal(t, 1, alerts())
+
+	// В новом месяце превышение снова сообщается
+	evaluate(monthOf(2025, time.February))
+	assert.Equal(t, 2, alerts())
+
+	// Траты в пределах лимита снимают превышение, следующее превышение сообщается снова
+	price := 100
+	require.NoError(t, subs.Update(ctx, subID, models.UpdateSubscription{Price: &price}))
+	evaluate(monthOf(2025, time.February))
+	evaluate(monthOf(2025, time.February))
+	assert.Equal(t, 2, alerts())
+
+	price = 700
+	require.NoError(t, subs.Update(ctx, subID, models.UpdateSubscription{Price: &price}))
+	evaluate(monthOf(2025, time.February))
+	assert.Equal(t, 3, alerts())
+
+	var payload []byte
+	require.NoError(t, db.Get(&payload, "SELECT payload FROM outbox WHERE event_type = $1 ORDER BY id DESC LIMIT 1", models.EventBudgetBreached))
+	var alert models.BudgetAlert
+	require.NoError(t, json.Unmarshal(payload, &alert))
+	assert.Equal(t, "02-2025", alert.Month)
+	assert.Equal(t, 700, alert.Used)
+}
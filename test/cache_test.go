@@ -0,0 +1,307 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/cache"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/metrics"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingSubscriptions считает обращения к хранилищу; сумма равна числу вызовов GetSubscriptionSummary
+type countingSubscriptions struct {
+	service.SubscriptionStore
+	getCalls     int
+	summaryCalls int
+	price        int
+	err          error
+}
+
+func (s *countingSubscriptions) GetById(ctx context.Context, subID int) (models.Subscription, error) {
+	s.getCalls++
+	return models.Subscription{Id: subID, Price: s.price}, s.err
+}
+
+func (s *countingSubscriptions) GetSubscriptionSummary(ctx context.Context, filter models.SubscriptionFilter) (int, error) {
+	s.summaryCalls++
+	return s.summaryCalls, s.err
+}
+
+func (s *countingSubscriptions) Create(ctx context.Context, sub models.Subscription) (int, error) {
+	return 10, nil
+}
+
+func (s *countingSubscriptions) Update(ctx context.Context, subID int, input models.UpdateSubscription) error {
+	return nil
+}
+
+func (s *countingSubscriptions) Delete(ctx context.Context, subID int) error {
+	return s.err
+}
+
+// recordedCache запоминает попадания, промахи и инвалидации
+type recordedCache struct {
+	hits, misses  map[string]int
+	invalidations map[string]int
+}
+
+func newRecordedCache() *recordedCache {
+	return &recordedCache{hits: map[string]int{}, misses: map[string]int{}, invalidations: map[string]int{}}
+}
+
+func (r *recordedCache) CacheLookup(name string, hit bool) {
+	if hit {
+		r.hits[name]++
+	} else {
+		r.misses[name]++
+	}
+}
+
+func (r *recordedCache) CacheInvalidation(source string) {
+	r.invalidations[source]++
+}
+
+// summaryFilter возвращает фильтр сводки по пользователю за период
+func summaryFilter(userID, from, to string) models.SubscriptionFilter {
+	return models.SubscriptionFilter{
+		Period:  models.Period{StartDate: from, FinishDate: to},
+		Filters: models.Filters{UserID: &userID},
+	}
+}
+
+// TestLRU проверяет вытеснение давно не использованных записей и истечение TTL
+func TestLRU(t *testing.T) {
+	lru := cache.NewLRU[string, int](2, time.Hour)
+	lru.Add("a", 1)
+	lru.Add("b", 2)
+
+	// Чтение делает "a" недавно использованной, поэтому вытесняется "b"
+	_, ok := lru.Get("a")
+	require.True(t, ok)
+	lru.Add("c", 3)
+	assert.Equal(t, 2, lru.Len())
+	_, ok = lru.Get("b")
+	assert.False(t, ok)
+
+	// Повторное добавление заменяет значение без роста размера
+	lru.Add("a", 10)
+	value, ok := lru.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, 10, value)
+	assert.Equal(t, 2, lru.Len())
+
+	lru.Remove("a")
+	_, ok = lru.Get("a")
+	assert.False(t, ok)
+	lru.Purge()
+	assert.Zero(t, lru.Len())
+
+	short := cache.NewLRU[string, int](10, 10*time.Millisecond)
+	short.Add("a", 1)
+	time.Sleep(20 * time.Millisecond)
+	_, ok = short.Get("a")
+	assert.False(t, ok)
+	assert.Zero(t, short.Len())
+}
+
+// TestSubscriptionCache проверяет кэширование GetById и сводок и их инвалидацию
+func TestSubscriptionCache(t *testing.T) {
+	ctx := context.Background()
+	next := &countingSubscriptions{price: 100}
+	recorder := newRecordedCache()
+	subs := cache.NewSubscriptionCache(next, cache.Config{Size: 100, TTL: time.Hour}, recorder)
+
+	// Повторное чтение обслуживается из кэша
+	for range 3 {
+		sub, err := subs.GetById(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 100, sub.Price)
+	}
+	assert.Equal(t, 1, next.getCalls)
+	assert.Equal(t, 2, recorder.hits[cache.CacheSubscription])
+	assert.Equal(t, 1, recorder.misses[cache.CacheSubscription])
+
+	// Сводки различаются периодом и фильтрами
+	total, err := subs.GetSubscriptionSummary(ctx, summaryFilter(testUsers[0], "01-2025", "12-2025"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	total, err = subs.GetSubscriptionSummary(ctx, summaryFilter(testUsers[0], "01-2025", "12-2025"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	total, err = subs.GetSubscriptionSummary(ctx, summaryFilter(testUsers[1], "01-2025", "12-2025"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, 2, next.summaryCalls)
+
+	// Изменение подписки сбрасывает ее запись и все сводки, другие подписки остаются в кэше
+	_, err = subs.GetById(ctx, 2)
+	require.NoError(t, err)
+	price := 200
+	require.NoError(t, subs.Update(ctx, 1, models.UpdateSubscription{Price: &price}))
+	next.price = 200
+
+	sub, err := subs.GetById(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 200, sub.Price)
+	sub, err = subs.GetById(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 100, sub.Price)
+	total, err = subs.GetSubscriptionSummary(ctx, summaryFilter(testUsers[0], "01-2025", "12-2025"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	// Создание и удаление тоже сбрасывают сводки
+	_, err = subs.Create(ctx, models.Subscription{})
+	require.NoError(t, err)
+	require.NoError(t, subs.Delete(ctx, 2))
+	total, err = subs.GetSubscriptionSummary(ctx, summaryFilter(testUsers[0], "01-2025", "12-2025"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	assert.Equal(t, 3, recorder.invalidations[cache.InvalidationWrite])
+
+	// Уведомление об изменении на другой реплике
+	subs.Invalidate(1)
+	_, err = subs.GetById(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 4, next.getCalls)
+	assert.Equal(t, 1, recorder.invalidations[cache.InvalidationNotify])
+
+	subs.Reset()
+	_, err = subs.GetSubscriptionSummary(ctx, summaryFilter(testUsers[0], "01-2025", "12-2025"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, next.summaryCalls)
+	assert.Equal(t, 1, recorder.invalidations[cache.InvalidationReset])
+}
+
+// TestSubscriptionCacheErrors проверяет, что ошибки не кэшируются, а неудачная запись все равно сбрасывает кэш
+func TestSubscriptionCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	next := &countingSubscriptions{err: errors.New("database is down")}
+	subs := cache.NewSubscriptionCache(next, cache.Config{Size: 10, TTL: time.Hour}, nil)
+
+	for range 2 {
+		_, err := subs.GetById(ctx, 1)
+		assert.Error(t, err)
+		_, err = subs.GetSubscriptionSummary(ctx, summaryFilter(testUsers[0], "01-2025", "12-2025"))
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 2, next.getCalls)
+	assert.Equal(t, 2, next.summaryCalls)
+
+	next.err = nil
+	_, err := subs.GetById(ctx, 1)
+	require.NoError(t, err)
+
+	next.err = errors.New("commit result unknown")
+	assert.Error(t, subs.Delete(ctx, 1))
+	next.err = nil
+	_, err = subs.GetById(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 4, next.getCalls)
+}
+
+// TestSubscriptionCacheMetrics проверяет экспорт попаданий, промахов и инвалидаций
+func TestSubscriptionCacheMetrics(t *testing.T) {
+	m := metrics.New()
+	subs := cache.NewSubscriptionCache(&countingSubscriptions{}, cache.Config{Size: 10, TTL: time.Hour}, m)
+
+	for range 3 {
+		_, err := subs.GetById(context.Background(), 1)
+		require.NoError(t, err)
+	}
+	subs.Invalidate(1)
+
+	router := handler.NewHandler(&service.Service{}, nil, m, nil).InitRoutes()
+	body := scrape(t, router)
+	assert.Contains(t, body, `cache_requests_total{cache="subscription",result="hit"} 2`)
+	assert.Contains(t, body, `cache_requests_total{cache="subscription",result="miss"} 1`)
+	assert.Contains(t, body, `cache_invalidations_total{source="notify"} 1`)
+}
+
+// TestChangeListenerIntegration проверяет уведомления триггеров об изменениях подписок, пауз и участников
+func TestChangeListenerIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := postgres.NewPostgresDB(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, postgres.RunMigrations(db))
+	_, err = db.Exec("INSERT INTO users (id) VALUES ($1), ($2)", testUsers[0], testUsers[1])
+	require.NoError(t, err)
+
+	listener, err := postgres.NewChangeListener(dbConfig)
+	require.NoError(t, err)
+
+	changed := make(chan int, 16)
+	listening := make(chan struct{}, 1)
+	go listener.Run(ctx, func(subID int) { changed <- subID }, func() { listening <- struct{}{} })
+
+	select {
+	case <-listening:
+	case <-time.After(10 * time.Second):
+		t.Fatal("listener did not start")
+	}
+
+	expect := func(subID int) {
+		t.Helper()
+		select {
+		case got := <-changed:
+			assert.Equal(t, subID, got)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no notification for subscription %d", subID)
+		}
+	}
+
+	repo := postgres.NewSubscriptionRepository(db)
+	subID, err := repo.Create(ctx, models.Subscription{
+		ServiceName: "Okko", Price: 200, UserID: testUsers[0], StartDate: "01-2025",
+		BillingCycle: models.BillingMonthly, Status: models.StatusActive,
+	})
+	require.NoError(t, err)
+	expect(subID)
+
+	_, err = db.Exec("INSERT INTO subscription_pauses (subscription_id, paused_from) VALUES ($1, $2)", subID, "2025-03-01")
+	require.NoError(t, err)
+	expect(subID)
+
+	_, err = db.Exec("INSERT INTO subscription_members (subscription_id, user_id, share_weight) VALUES ($1, $2, 1)", subID, testUsers[1])
+	require.NoError(t, err)
+	expect(subID)
+
+	require.NoError(t, repo.Delete(ctx, subID))
+	expect(subID)
+}
+
+// TestSubscriptionCacheHandler проверяет, что обработчик читает подписку через кэш
+func TestSubscriptionCacheHandler(t *testing.T) {
+	next := &countingSubscriptions{price: 100}
+	subs := cache.NewSubscriptionCache(next, cache.Config{Size: 10, TTL: time.Hour}, nil)
+	router := handler.NewHandler(&service.Service{SubscriptionStore: subs, OrganizationStore: personalSubscriptions{}}, nil, nil, nil).InitRoutes()
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/subscriptions/1", nil))
+		assert.Equal(t, 200, w.Code)
+	}
+	assert.Equal(t, 1, next.getCalls)
+}
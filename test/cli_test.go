@@ -0,0 +1,287 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/cli"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memSubscriptionRepo хранит подписки в памяти; пересечением считается тот же сервис у того же пользователя
+type memSubscriptionRepo struct {
+	postgres.SubscriptionStore
+	subs    []models.SubscriptionDB
+	charges []models.ChargeDB
+}
+
+func (r *memSubscriptionRepo) Create(ctx context.Context, sub models.Subscription) (int, error) {
+	if sub.RejectOverlaps {
+		if overlaps, _ := r.FindOverlaps(ctx, models.OverlapFilter{UserID: sub.UserID, ServiceName: sub.ServiceName}); len(overlaps) > 0 {
+			return 0, postgres.ErrOverlap
+		}
+	}
+	subDB := models.SubscriptionDB{
+		Id:           len(r.subs) + 1,
+		ServiceName:  sub.ServiceName,
+		Price:        sub.Price,
+		UserID:       uuid.MustParse(sub.UserID),
+		BillingCycle: sub.BillingCycle,
+		Status:       sub.Status,
+	}
+	subDB.StartDate, _ = time.Parse("01-2006", sub.StartDate)
+	if sub.Category != "" {
+		subDB.Category = &sub.Category
+	}
+	if sub.FinishDate != "" {
+		finish, _ := time.Parse("01-2006", sub.FinishDate)
+		subDB.FinishDate = &finish
+	}
+	if sub.TrialEnd != "" {
+		trialEnd, _ := time.Parse("01-2006", sub.TrialEnd)
+		subDB.TrialEnd = &trialEnd
+		subDB.PriceAfterTrial = sub.PriceAfterTrial
+	}
+
+	r.subs = append(r.subs, subDB)
+	return subDB.Id, nil
+}
+
+func (r *memSubscriptionRepo) GetAll(ctx context.Context, filter models.SubscriptionListFilter) ([]models.SubscriptionDB, error) {
+	return r.subs, nil
+}
+
+func (r *memSubscriptionRepo) FindOverlaps(ctx context.Context, filter models.OverlapFilter) ([]models.SubscriptionDB, error) {
+	var overlaps []models.SubscriptionDB
+	for _, sub := range r.subs {
+		if sub.UserID.String() == filter.UserID && sub.ServiceName == filter.ServiceName {
+			overlaps = append(overlaps, sub)
+		}
+	}
+	return overlaps, nil
+}
+
+func (r *memSubscriptionRepo) GetCharges(ctx context.Context, filter models.ChargeFilter) ([]models.ChargeDB, error) {
+	return r.charges, nil
+}
+
+// memUserRepo хранит пользователей в памяти
+type memUserRepo struct {
+	postgres.UserStore
+	users map[uuid.UUID]models.UserDB
+}
+
+func (r *memUserRepo) Create(ctx context.Context, user models.UserDB) (models.UserDB, error) {
+	if user.Id == uuid.Nil {
+		user.Id = uuid.New()
+	}
+	r.users[user.Id] = user
+	return user, nil
+}
+
+func (r *memUserRepo) GetById(ctx context.Context, userID string) (models.UserDB, error) {
+	user, ok := r.users[uuid.MustParse(userID)]
+	if !ok {
+		return models.UserDB{}, postgres.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// noBudgets не проверяет бюджеты
+type noBudgets struct{}
+
+func (noBudgets) EvaluateBudgets(ctx context.Context, userID string) error { return nil }
+
+// newMemServices собирает слой сервисов поверх хранилищ в памяти с политикой reject для дубликатов
+func newMemServices() (*service.Service, *memSubscriptionRepo, *memUserRepo) {
+	subs := &memSubscriptionRepo{}
+	users := &memUserRepo{users: make(map[uuid.UUID]models.UserDB)}
+
+	return &service.Service{
+		SubscriptionStore: service.NewSubscriptionService(subs, noBudgets{}, service.NewDuplicateDetector(subs, service.DuplicatePolicyReject)),
+		UserStore:         service.NewUserService(users, subs),
+		AnalyticsStore:    service.NewAnalyticsService(subs),
+	}, subs, users
+}
+
+// TestFormatFromPath проверяет выбор формата по расширению файла
+func TestFormatFromPath(t *testing.T) {
+	for path, expected := range map[string]cli.Format{
+		"subs.csv":    cli.FormatCSV,
+		"SUBS.CSV":    cli.FormatCSV,
+		"subs.ndjson": cli.FormatNDJSON,
+		"subs.jsonl":  cli.FormatNDJSON,
+		"-":           cli.FormatNDJSON,
+	} {
+		format, err := cli.FormatFromPath(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, expected, format, path)
+	}
+
+	_, err := cli.FormatFromPath("subs.xlsx")
+	assert.Error(t, err)
+}
+
+// TestExportImportRoundTrip проверяет, что экспортированные подписки импортируются без изменений
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []cli.Format{cli.FormatCSV, cli.FormatNDJSON} {
+		t.Run(string(format), func(t *testing.T) {
+			source, _, _ := newMemServices()
+			ctx := context.Background()
+			priceAfterTrial := 599
+
+			// Подписки создаются через сервис: пользователи, на которых они ссылаются, еще не существуют
+			input := []models.Subscription{
+				{ServiceName: "Netflix", Price: 799, UserID: testUsers[0], StartDate: "01-2025", Category: "entertainment"},
+				{ServiceName: "Google One", Price: 1390, UserID: testUsers[0], StartDate: "03-2024", FinishDate: "03-2026", BillingCycle: models.BillingYearly},
+				{ServiceName: "Spotify", Price: 0, UserID: testUsers[1], StartDate: "02-2025", TrialEnd: "02-2025", PriceAfterTrial: &priceAfterTrial},
+			}
+			for _, sub := range input {
+				_, err := source.SubscriptionStore.Create(ctx, sub)
+				require.NoError(t, err)
+			}
+
+			var buf bytes.Buffer
+			exported, err := cli.Export(ctx, source.SubscriptionStore, &buf, format, nil)
+			require.NoError(t, err)
+			assert.Equal(t, 3, exported)
+
+			target, _, users := newMemServices()
+			result, err := cli.Import(ctx, target, &buf, format)
+			require.NoError(t, err)
+			assert.Empty(t, result.Errors)
+			assert.Equal(t, 3, result.Imported)
+			assert.Equal(t, 2, result.CreatedUsers)
+			assert.Len(t, users.users, 2)
+
+			before, err := source.SubscriptionStore.GetAll(ctx, models.SubscriptionListFilter{})
+			require.NoError(t, err)
+			after, err := target.SubscriptionStore.GetAll(ctx, models.SubscriptionListFilter{})
+			require.NoError(t, err)
+			assert.Equal(t, before, after)
+		})
+	}
+}
+
+// TestImportRowErrors проверяет, что ошибочные строки пропускаются с номером строки
+func TestImportRowErrors(t *testing.T) {
+	services, subs, _ := newMemServices()
+	ctx := context.Background()
+
+	csvData := strings.Join([]string{
+		"user_id,service_name,price,start_date,category",
+		testUsers[0] + ",Netflix,799,01-2025,entertainment",
+		testUsers[0] + ",Spotify,cheap,01-2025,music",
+		testUsers[0] + ",Kinopoisk,299",
+		testUsers[0] + ",Netflix,799,06-2025,entertainment",
+		"not-a-uuid,Netflix,799,01-2025,",
+		testUsers[1] + ",Apple Music,169,13-2025,music",
+		testUsers[1] + ",Apple Music,169,12-2025,music",
+	}, "\n")
+
+	result, err := cli.Import(ctx, services, strings.NewReader(csvData), cli.FormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Imported)
+	assert.Len(t, subs.subs, 2)
+
+	require.Len(t, result.Errors, 5)
+	assert.Contains(t, result.Errors[0].Error(), "line 3: invalid price")
+	assert.Contains(t, result.Errors[1].Error(), "line 4:")
+	assert.ErrorIs(t, result.Errors[2], service.ErrDuplicateSubscription)
+	assert.Contains(t, result.Errors[2].Error(), "line 5:")
+	assert.ErrorIs(t, result.Errors[3], service.ErrInvalidUser)
+	assert.Contains(t, result.Errors[3].Error(), "line 6:")
+	assert.Contains(t, result.Errors[3].Error(), "invalid user ID format")
+	assert.Contains(t, result.Errors[4].Error(), "line 7: invalid start date")
+
+	// Некорректная строка NDJSON не прерывает импорт
+	ndjson := "{\"service_name\":\"iCloud+\",\"price\":149,\"user_id\":\"" + testUsers[1] + "\",\"start_date\":\"01-2025\"}\n\n{broken\n"
+	result, err = cli.Import(ctx, services, strings.NewReader(ndjson), cli.FormatNDJSON)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Imported)
+	require.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Error(), "line 3: invalid JSON")
+
+	// Без обязательной колонки импорт не начинается
+	_, err = cli.Import(ctx, services, strings.NewReader("user_id,service_name,start_date\n"), cli.FormatCSV)
+	assert.ErrorContains(t, err, "price")
+}
+
+// TestSeed проверяет, что сгенерированные данные проходят валидацию сервиса и воспроизводимы
+func TestSeed(t *testing.T) {
+	now := time.Date(2025, time.June, 15, 0, 0, 0, 0, time.UTC)
+
+	generate := func() (cli.SeedResult, *memSubscriptionRepo) {
+		services, subs, users := newMemServices()
+		result, err := cli.Seed(context.Background(), services, rand.New(rand.NewPCG(42, 0)), 20, now)
+		require.NoError(t, err)
+		assert.Len(t, users.users, 20)
+		return result, subs
+	}
+
+	result, subs := generate()
+	assert.Equal(t, 20, result.Users)
+	assert.Equal(t, len(subs.subs), result.Subscriptions)
+	assert.GreaterOrEqual(t, result.Subscriptions, 20)
+	assert.LessOrEqual(t, result.Subscriptions, 100)
+
+	var finished, trials int
+	for _, sub := range subs.subs {
+		assert.False(t, sub.StartDate.After(now), sub.ServiceName)
+		if sub.FinishDate != nil {
+			finished++
+		}
+		if sub.TrialEnd != nil {
+			trials++
+			assert.Equal(t, models.StatusTrial, sub.Status)
+		}
+	}
+	assert.Positive(t, finished)
+	assert.Positive(t, trials)
+
+	// Тот же seed генерирует те же подписки
+	again, subsAgain := generate()
+	assert.Equal(t, result, again)
+	for i := range subs.subs {
+		assert.Equal(t, subs.subs[i].ServiceName, subsAgain.subs[i].ServiceName)
+		assert.Equal(t, subs.subs[i].StartDate, subsAgain.subs[i].StartDate)
+	}
+}
+
+// TestReport проверяет таблицу расходов по месяцам
+func TestReport(t *testing.T) {
+	services, subs, _ := newMemServices()
+	month := func(m time.Month) time.Time { return time.Date(2025, m, 1, 0, 0, 0, 0, time.UTC) }
+	subs.charges = []models.ChargeDB{
+		{SubscriptionID: 1, ServiceName: "Netflix", Month: month(time.January), Amount: 799},
+		{SubscriptionID: 2, ServiceName: "Google One", Month: month(time.January), Amount: 1390},
+		{SubscriptionID: 1, ServiceName: "Netflix", Month: month(time.March), Amount: 799},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, cli.Report(context.Background(), &buf, services.AnalyticsStore, testUsers[0], "01-2025", "03-2025"))
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 7)
+	assert.Equal(t, "Spend of user "+testUsers[0]+" from 01-2025 to 03-2025, RUB", lines[0])
+
+	fields := func(line string) []string { return strings.Fields(line) }
+	assert.Equal(t, []string{"MONTH", "Google", "One", "Netflix", "TOTAL"}, fields(lines[2]))
+	assert.Equal(t, []string{"01-2025", "1390", "799", "2189"}, fields(lines[3]))
+	assert.Equal(t, []string{"02-2025", "-", "-", "0"}, fields(lines[4]))
+	assert.Equal(t, []string{"03-2025", "-", "799", "799"}, fields(lines[5]))
+	assert.Equal(t, []string{"TOTAL", "1390", "1598", "2988"}, fields(lines[6]))
+
+	// Период проверяется сервисом
+	assert.Error(t, cli.Report(context.Background(), &buf, services.AnalyticsStore, testUsers[0], "03-2025", "01-2025"))
+	assert.Error(t, cli.Report(context.Background(), &buf, services.AnalyticsStore, "nobody", "01-2025", "03-2025"))
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfigPrecedence проверяет порядок источников: значения по умолчанию < файл < окружение < флаги
+func TestConfigPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.env")
+	require.NoError(t, os.WriteFile(file, []byte(
+		"HOST_PORT=9000\nDB_HOST=file-host\nDB_NAME=filedb\nHTTP_READ_TIMEOUT=20s\n"), 0o600))
+
+	t.Setenv("DB_HOST", "env-host")
+	t.Setenv("HTTP_READ_TIMEOUT", "30s")
+
+	cfg, err := config.Load([]string{"-config", file, "-http-read-timeout", "40s"})
+	require.NoError(t, err)
+
+	// Значение по умолчанию
+	assert.Equal(t, "5432", cfg.DB.Port)
+	assert.Equal(t, 10*time.Second, cfg.HTTP.WriteTimeout)
+	// Значения из файла
+	assert.Equal(t, "9000", cfg.HTTP.Port)
+	assert.Equal(t, "filedb", cfg.DB.DBName)
+	// Окружение важнее файла
+	assert.Equal(t, "env-host", cfg.DB.Host)
+	// Флаг важнее окружения
+	assert.Equal(t, 40*time.Second, cfg.HTTP.ReadTimeout)
+}
+
+// TestConfigParseArgs проверяет, что аргументы после флагов возвращаются команде
+func TestConfigParseArgs(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Chdir(t.TempDir())
+
+	cfg, args, err := config.Parse([]string{"-db-auto-migrate=false", "force", "-1"})
+	require.NoError(t, err)
+	assert.False(t, cfg.AutoMigrate)
+	assert.Equal(t, []string{"force", "-1"}, args)
+}
+
+// TestConfigValidation проверяет отклонение некорректной конфигурации при запуске
+func TestConfigValidation(t *testing.T) {
+	// Явно указанный файл должен существовать
+	_, err := config.Load([]string{"-config", filepath.Join(t.TempDir(), "missing.env")})
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	t.Setenv("CONFIG_FILE", "")
+	t.Chdir(dir)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"некорректный порт", []string{"-port", "70000"}},
+		{"нечисловой порт БД", []string{"-db-port", "abc"}},
+		{"пустой хост БД", []string{"-db-host", ""}},
+		{"нулевой таймаут", []string{"-http-write-timeout", "0s"}},
+		{"некорректная длительность", []string{"-http-idle-timeout", "soon"}},
+		{"idle больше open", []string{"-db-max-open-conns", "5", "-db-max-idle-conns", "10"}},
+		{"неизвестное хранилище лимитов", []string{"-rate-limit-store", "redis"}},
+		{"нулевой burst", []string{"-rate-limit-summary-burst", "0"}},
+		{"сертификат без ключа", []string{"-db-ssl-cert", "client.pem"}},
+		{"начальная задержка больше максимальной", []string{"-db-retry-initial-backoff", "1m", "-db-retry-max-backoff", "10s"}},
+		{"некорректный URL БД", []string{"-db-url", "postgres://[broken"}},
+		{"пустой кэш", []string{"-cache-size", "0"}},
+		{"нулевой TTL кэша", []string{"-cache-ttl", "0s"}},
+		{"кэш без уведомлений", []string{"-cache-notify=false"}},
+		{"отрицательный интервал обновления расходов", []string{"-spend-refresh-interval", "-1s"}},
+		{"отрицательный горизонт расходов", []string{"-spend-horizon-months", "-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.Load(tt.args)
+			assert.Error(t, err)
+		})
+	}
+
+	// DB_URL заменяет отдельные параметры подключения
+	cfg, err := config.Load([]string{"-db-url", "postgres://app@db/app", "-db-host", "", "-db-port", "none"})
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://app@db/app", cfg.DB.URL)
+
+	// Настройки выключенного кэша не проверяются
+	_, err = config.Load([]string{"-cache=false", "-cache-size", "0", "-cache-notify=false"})
+	require.NoError(t, err)
+
+	// Без файла config.env используются значения по умолчанию
+	cfg, err = config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, config.Default(), cfg)
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDSN проверяет экранирование значений и поддержку URL с файлами сертификатов
+func TestDSN(t *testing.T) {
+	cfg := postgres.Config{
+		Host:     "db.internal",
+		Port:     "5432",
+		Username: "app",
+		Password: `p@ss w'rd\`,
+		DBName:   "subscriptions",
+		SSLMode:  "disable",
+	}
+
+	dsn, err := cfg.DSN()
+	require.NoError(t, err)
+	assert.Equal(t, `host='db.internal' port='5432' user='app' password='p@ss w\'rd\\' dbname='subscriptions' sslmode='disable'`, dsn)
+
+	// Строка разбирается драйвером без подключения к базе
+	_, err = pq.NewConnector(dsn)
+	require.NoError(t, err)
+
+	// URL заменяет отдельные поля, файлы сертификатов добавляются к нему
+	cfg.URL = "postgres://u:p%20w@db:6543/app?sslmode=verify-full"
+	cfg.SSLRootCert = "/certs/ca.pem"
+	cfg.SSLCert = "/certs/client.pem"
+	cfg.SSLKey = "/certs/client.key"
+	dsn, err = cfg.DSN()
+	require.NoError(t, err)
+	assert.Contains(t, dsn, "host='db'")
+	assert.Contains(t, dsn, "password='p w'")
+	assert.Contains(t, dsn, "sslrootcert='/certs/ca.pem' sslcert='/certs/client.pem' sslkey='/certs/client.key'")
+	assert.NotContains(t, dsn, "db.internal")
+	_, err = pq.NewConnector(dsn)
+	require.NoError(t, err)
+
+	// Строка вида key=value передается как есть
+	cfg = postgres.Config{URL: "host=localhost dbname=app"}
+	dsn, err = cfg.DSN()
+	require.NoError(t, err)
+	assert.Equal(t, "host=localhost dbname=app", dsn)
+
+	cfg.URL = "postgres://[broken"
+	_, err = cfg.DSN()
+	assert.Error(t, err)
+}
+
+// TestWaitReadyBackoff проверяет повторные попытки с растущей задержкой до истечения контекста
+func TestWaitReadyBackoff(t *testing.T) {
+	logs := captureLogs(t)
+
+	// На свободном порту никто не слушает, подключение сразу отклоняется
+	cfg := postgres.Config{
+		Host:                "127.0.0.1",
+		Port:                freePort(t),
+		Username:            "postgres",
+		DBName:              "postgres",
+		SSLMode:             "disable",
+		RetryInitialBackoff: 100 * time.Millisecond,
+		RetryMaxBackoff:     400 * time.Millisecond,
+	}
+	db, err := postgres.Open(cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = postgres.WaitReady(ctx, db, cfg)
+	elapsed := time.Since(start)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database is not available")
+	assert.GreaterOrEqual(t, elapsed, 1200*time.Millisecond)
+	assert.Less(t, elapsed, 2*time.Second)
+
+	// Задержки 50-100, 100-200, 200-400, 200-400 мс...: за 1.2 с от 4 до 10 попыток
+	attempts := strings.Count(logs.String(), "database is not available")
+	assert.GreaterOrEqual(t, attempts, 4)
+	assert.LessOrEqual(t, attempts, 10)
+
+	// Без ожидания NewPostgresDB возвращает ошибку и закрывает пул
+	cfg.ConnectTimeout = 300 * time.Millisecond
+	_, err = postgres.NewPostgresDB(cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,182 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// duplicateStore: любая новая подписка пересекается с подпиской 1; запросы пересечений считаются
+type duplicateStore struct {
+	postgres.SubscriptionStore
+	duplicates []models.SubscriptionDB
+	overlaps   int
+}
+
+func (s *duplicateStore) Create(ctx context.Context, sub models.Subscription) (int, error) {
+	if sub.RejectOverlaps {
+		return 0, fmt.Errorf("%w 1", postgres.ErrOverlap)
+	}
+	return 2, nil
+}
+
+func (s *duplicateStore) GetById(ctx context.Context, subID int) (models.SubscriptionDB, error) {
+	return models.SubscriptionDB{Id: subID, ServiceName: "Netflix", UserID: uuid.MustParse(testUsers[0]), StartDate: monthOf(2025, time.January)}, nil
+}
+
+func (s *duplicateStore) FindOverlaps(ctx context.Context, filter models.OverlapFilter) ([]models.SubscriptionDB, error) {
+	s.overlaps++
+	return []models.SubscriptionDB{{Id: 1, ServiceName: "Netflix", UserID: uuid.MustParse(testUsers[0]), StartDate: monthOf(2025, time.January)}}, nil
+}
+
+func (s *duplicateStore) GetDuplicates(ctx context.Context) ([]models.SubscriptionDB, error) {
+	return s.duplicates, nil
+}
+
+// TestDuplicatePolicy проверяет ответы создания при политиках warn и reject
+func TestDuplicatePolicy(t *testing.T) {
+	payload := map[string]interface{}{"service_name": "Netflix", "price": 100, "user_id": testUsers[0], "start_date": "03-2025"}
+
+	t.Run("warn", func(t *testing.T) {
+		store := &duplicateStore{}
+		services := &service.Service{SubscriptionStore: service.NewSubscriptionService(store, noBudgets{}, service.NewDuplicateDetector(store, service.DuplicatePolicyWarn))}
+		router := handler.NewHandler(services, nil, nil, nil).InitRoutes()
+
+		recorder := serveJSON(router, http.MethodPost, "/subscriptions/", payload, "")
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+		assert.Contains(t, response, "warning")
+		assert.Len(t, response["duplicates"], 1)
+		assert.Equal(t, 1, store.overlaps)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		store := &duplicateStore{}
+		services := &service.Service{SubscriptionStore: service.NewSubscriptionService(store, noBudgets{}, service.NewDuplicateDetector(store, service.DuplicatePolicyReject))}
+		router := handler.NewHandler(services, nil, nil, nil).InitRoutes()
+
+		recorder := serveJSON(router, http.MethodPost, "/subscriptions/", payload, "")
+		assert.Equal(t, http.StatusConflict, recorder.Code, recorder.Body.String())
+
+		// Сохраненная подписка не может пересекаться с другими, предупреждение не запрашивается
+		duplicates, err := services.SubscriptionStore.FindDuplicates(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Empty(t, duplicates)
+		assert.Zero(t, store.overlaps)
+	})
+}
+
+// TestDuplicateReport проверяет, что отчет разбивает подписки одного сервиса на непересекающиеся группы
+func TestDuplicateReport(t *testing.T) {
+	month := func(m time.Month) *time.Time {
+		date := monthOf(2025, m)
+		return &date
+	}
+	sub := func(id int, user, service string, start time.Month, finish *time.Time) models.SubscriptionDB {
+		return models.SubscriptionDB{Id: id, UserID: uuid.MustParse(user), ServiceName: service, StartDate: monthOf(2025, start), FinishDate: finish}
+	}
+
+	// Строки в порядке GetDuplicates: пользователь, сервис без учета регистра, дата начала
+	store := &duplicateStore{duplicates: []models.SubscriptionDB{
+		// Январь-март и февраль-апрель пересекаются
+		sub(1, testUsers[0], "Netflix", time.January, month(time.April)),
+		sub(2, testUsers[0], "netflix", time.February, month(time.May)),
+		// Июнь-август и июль-... пересекаются друг с другом, но не с первой парой
+		sub(3, testUsers[0], "Netflix", time.June, month(time.September)),
+		sub(4, testUsers[0], "Netflix", time.July, nil),
+		// Группа с бессрочной подпиской не заканчивается
+		sub(5, testUsers[0], "Netflix", time.December, nil),
+		sub(6, testUsers[0], "Spotify", time.January, nil),
+		sub(7, testUsers[0], "Spotify", time.March, month(time.April)),
+		sub(8, testUsers[1], "Spotify", time.January, nil),
+		sub(9, testUsers[1], "Spotify", time.January, nil),
+	}}
+	report, err := service.NewDuplicateDetector(store, service.DuplicatePolicyWarn).Report(context.Background())
+	require.NoError(t, err)
+
+	groups := make([][]int, 0, len(report))
+	for _, group := range report {
+		ids := make([]int, 0, len(group.Subscriptions))
+		for _, sub := range group.Subscriptions {
+			ids = append(ids, sub.Id)
+		}
+		groups = append(groups, ids)
+	}
+	assert.Equal(t, [][]int{{1, 2}, {3, 4, 5}, {6, 7}, {8, 9}}, groups)
+	assert.Equal(t, testUsers[1], report[3].UserID)
+}
+
+// TestDuplicateRejectIntegration проверяет, что параллельные пересекающиеся подписки
+// при политике reject не сохраняются обе
+func TestDuplicateRejectIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := setupTestDatabase(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := postgres.NewSubscriptionRepository(db)
+	subs := service.NewSubscriptionService(repo, noBudgets{}, service.NewDuplicateDetector(repo, service.DuplicatePolicyReject))
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		created  int
+		rejected int
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Названия различаются регистром, периоды попарно пересекаются
+			name := "Netflix"
+			if i%2 == 1 {
+				name = "NETFLIX"
+			}
+			_, err := subs.Create(ctx, models.Subscription{ServiceName: name, Price: 100, UserID: testUsers[0], StartDate: "01-2025"})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, service.ErrDuplicateSubscription):
+				rejected++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 7, rejected)
+
+	duplicates, err := repo.GetDuplicates(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, duplicates)
+}
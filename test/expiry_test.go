@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expiryStore запоминает месяц, с которым вызвана задача истечения, и истекает две подписки
+type expiryStore struct {
+	postgres.SubscriptionStore
+	month time.Time
+}
+
+func (s *expiryStore) ExpireEnded(ctx context.Context, month time.Time) ([]models.SubscriptionDB, error) {
+	s.month = month
+	return []models.SubscriptionDB{{Id: 1, Status: models.StatusExpired}, {Id: 2, Status: models.StatusExpired}}, nil
+}
+
+// TestExpireEnded проверяет, что задача истекает подписки, закончившиеся к началу текущего месяца
+func TestExpireEnded(t *testing.T) {
+	store := &expiryStore{}
+	expired, err := service.NewLifecycleService(store, noBudgets{}).ExpireEnded(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, expired)
+
+	now := time.Now().UTC()
+	assert.Equal(t, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), store.month)
+}
+
+// TestExpiryLockIntegration проверяет, что вторая реплика пропускает истечение, пока первая держит advisory lock
+func TestExpiryLockIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := setupTestDatabase(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := postgres.NewSubscriptionRepository(db)
+	subID, err := repo.Create(ctx, models.Subscription{
+		ServiceName: "Netflix", Price: 500, UserID: testUsers[0], StartDate: "01-2025", FinishDate: "03-2025",
+		BillingCycle: models.BillingMonthly, Status: models.StatusActive,
+	})
+	require.NoError(t, err)
+
+	events := func() int {
+		t.Helper()
+		var count int
+		require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM outbox WHERE event_type = $1", models.EventSubscriptionExpired))
+		return count
+	}
+
+	// Первая реплика держит блокировку в своей транзакции
+	holder, err := db.BeginTxx(ctx, nil)
+	require.NoError(t, err)
+	_, err = holder.Exec("SELECT pg_advisory_xact_lock(hashtext('subscription-expiry'))")
+	require.NoError(t, err)
+
+	// Вторая реплика не ждет блокировку и ничего не меняет
+	expired, err := repo.ExpireEnded(ctx, monthOf(2025, time.April))
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+	sub, err := repo.GetById(ctx, subID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusActive, sub.Status)
+	assert.Zero(t, events())
+
+	// После освобождения блокировки подписка истекает, событие пишется один раз
+	require.NoError(t, holder.Rollback())
+	expired, err = repo.ExpireEnded(ctx, monthOf(2025, time.April))
+	require.NoError(t, err)
+	require.Equal(t, []int{subID}, subscriptionIDs(expired))
+	assert.Equal(t, models.StatusExpired, expired[0].Status)
+	assert.Equal(t, 1, events())
+
+	expired, err = repo.ExpireEnded(ctx, monthOf(2025, time.April))
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+	assert.Equal(t, 1, events())
+}
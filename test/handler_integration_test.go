@@ -17,6 +17,7 @@ import (
 	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
 	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
 	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -83,42 +84,75 @@ func setupTestContainer(ctx context.Context) (postgres.Config, func(), error) {
 	return postgresCfg, cleanup, nil
 }
 
+// testUsers - пользователи, существующие в базе каждого теста
+var testUsers = []string{
+	"60601fee-2bf1-4721-ae6f-7636e79a0cba",
+	"60691fee-2bf1-4721-ae6f-7036e79a0cba",
+}
+
 // setupTestServer создает и настраивает тестовый сервер
 func setupTestServer(postgresCfg postgres.Config) (*gin.Engine, error) {
+	db, err := setupTestDatabase(postgresCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTestRouter(db), nil
+}
+
+// setupTestDatabase подключается к PostgreSQL, применяет миграции и создает testUsers
+func setupTestDatabase(postgresCfg postgres.Config) (*sqlx.DB, error) {
 	// Инициализация PostgreSQL
 	db, err := postgres.NewPostgresDB(postgresCfg)
 	if err != nil {
 		return nil, err
 	}
 
-	// Добавьте создание таблицы
-	_, err = db.Exec(`
-        CREATE TABLE IF NOT EXISTS subscriptions (
-    		id SERIAL PRIMARY KEY UNIQUE,
-    		service_name VARCHAR(255) NOT NULL,
-    		price INT NOT NULL,
-   			user_id UUID ,
-    		start_date DATE NOT NULL,
-   			finish_date DATE GENERATED ALWAYS AS (start_date + INTERVAL '1 month') STORED
-        )
-    `)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create table: %w", err)
+	// Применяем встроенные миграции так же, как при запуске сервиса
+	if err := postgres.RunMigrations(db); err != nil {
+		return nil, fmt.Errorf("failed to apply migrations: %w", err)
 	}
 
+	// Создаем пользователей, на которых ссылаются подписки в тестах
+	for _, userID := range testUsers {
+		if _, err := db.Exec("INSERT INTO users (id) VALUES ($1)", userID); err != nil {
+			return nil, fmt.Errorf("failed to create test user: %w", err)
+		}
+	}
+
+	return db, nil
+}
+
+// newTestRouter собирает репозитории, сервисы и маршруты поверх базы
+func newTestRouter(db *sqlx.DB) *gin.Engine {
 	// Инициализация репозиториев
 	repos := postgres.NewRepository(db)
 
 	// Инициализация сервисов
-	services := service.NewService(repos)
+	services := service.NewService(repos, service.Config{})
 
 	// Инициализация обработчиков
-	handler := handler.NewHandler(services)
+	handler := handler.NewHandler(services, nil, nil, nil)
 
 	// Настройка маршрутов
-	router := handler.InitRoutes()
+	return handler.InitRoutes()
+}
+
+// serveJSON выполняет запрос с JSON-телом от имени caller (пустая строка - без заголовка X-User-ID)
+func serveJSON(router http.Handler, method, url string, payload interface{}, caller string) *httptest.ResponseRecorder {
+	var body []byte
+	if payload != nil {
+		body, _ = json.Marshal(payload)
+	}
+	req := httptest.NewRequest(method, url, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	if caller != "" {
+		req.Header.Set("X-User-ID", caller)
+	}
 
-	return router, nil
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
 }
 
 // TestSignUpIntegration is testing the endpoint of user registration
@@ -657,3 +691,329 @@ func TestUpdateSubscriptionIntegration(t *testing.T) {
 		}
 	}
 }
+
+func TestCancelSubscriptionIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	router, err := setupTestServer(dbConfig)
+	if err != nil {
+		t.Fatalf("Failed to set up test server: %v", err)
+	}
+
+	// Создание подписки, которую будем отменять
+	body, _ := json.Marshal(map[string]interface{}{
+		"service_name": "TEST1",
+		"price":        200,
+		"user_id":      "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		"start_date":   "06-2025",
+	})
+	req, _ := http.NewRequest("POST", "/subscriptions/", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	tests := []struct {
+		name           string
+		expectedStatus int
+		checkResponse  func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "Successful cancellation",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Contains(t, recorder.Body.String(), "Operation completed successfully")
+			},
+		},
+		{
+			name:           "Repeated cancellation is rejected",
+			expectedStatus: http.StatusConflict,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Contains(t, recorder.Body.String(), "invalid status transition")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Request preparation
+			body, _ := json.Marshal(map[string]interface{}{"reason": "too expensive"})
+			req, _ := http.NewRequest("POST", "/subscriptions/1/cancel", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			// Request execution
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			// Checking the response status
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			// Checking the response body
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, recorder)
+			}
+		})
+	}
+
+	// Отмененная подписка попадает в выборку по статусу
+	req, _ = http.NewRequest("GET", "/subscriptions/?status=cancelled", nil)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "too expensive")
+}
+
+func TestUsersIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	router, err := setupTestServer(dbConfig)
+	if err != nil {
+		t.Fatalf("Failed to set up test server: %v", err)
+	}
+
+	userID := "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		payload        interface{}
+		expectedStatus int
+		checkResponse  func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "Successful user creation",
+			method:         "POST",
+			url:            "/users/",
+			payload:        map[string]interface{}{"id": userID, "display_name": "Alice", "timezone": "Europe/Moscow"},
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Contains(t, recorder.Body.String(), userID)
+			},
+		},
+		{
+			name:           "Invalid user ID on creation",
+			method:         "POST",
+			url:            "/users/",
+			payload:        map[string]interface{}{"id": "not-a-uuid", "display_name": "Bob"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid timezone on creation",
+			method:         "POST",
+			url:            "/users/",
+			payload:        map[string]interface{}{"display_name": "Bob", "timezone": "Mars/Olympus"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Malformed user ID on lookup",
+			method:         "GET",
+			url:            "/users/not-a-uuid",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Malformed user ID on update",
+			method:         "PUT",
+			url:            "/users/not-a-uuid",
+			payload:        map[string]interface{}{"display_name": "Bob"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Malformed user ID on deletion",
+			method:         "DELETE",
+			url:            "/users/not-a-uuid",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Subscription of unknown user is rejected",
+			method:         "POST",
+			url:            "/subscriptions/",
+			payload:        map[string]interface{}{"service_name": "TEST1", "price": 200, "user_id": "0b4e7a0e-5fd9-4c5e-9b4e-1f5b0c1d2e3f", "start_date": "06-2025"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Successful subscription creation",
+			method:         "POST",
+			url:            "/subscriptions/",
+			payload:        map[string]interface{}{"service_name": "TEST1", "price": 200, "user_id": userID, "start_date": "06-2025"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Nested subscriptions listing",
+			method:         "GET",
+			url:            "/users/" + userID + "/subscriptions",
+			expectedStatus: http.StatusOK,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Contains(t, recorder.Body.String(), "TEST1")
+			},
+		},
+		{
+			name:           "Deletion is blocked by owned subscriptions",
+			method:         "DELETE",
+			url:            "/users/" + userID,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Cascade deletion",
+			method:         "DELETE",
+			url:            "/users/" + userID + "?cascade=true",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Deleted user is not found",
+			method:         "GET",
+			url:            "/users/" + userID,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Request preparation
+			var body []byte
+			if tt.payload != nil {
+				body, _ = json.Marshal(tt.payload)
+			}
+			req, _ := http.NewRequest(tt.method, tt.url, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			// Request execution
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			// Checking the response status
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+
+			// Checking the response body
+			if tt.checkResponse != nil {
+				tt.checkResponse(t, recorder)
+			}
+		})
+	}
+}
+
+func TestOrganizationRolesIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	router, err := setupTestServer(dbConfig)
+	if err != nil {
+		t.Fatalf("Failed to set up test server: %v", err)
+	}
+
+	// Первый тестовый пользователь - владелец организации, второй - наблюдатель
+	owner, viewer := testUsers[0], testUsers[1]
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		caller         string
+		payload        interface{}
+		expectedStatus int
+	}{
+		{
+			name:           "Anonymous request is rejected",
+			method:         "POST",
+			url:            "/organizations/",
+			payload:        map[string]interface{}{"name": "ACME"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "Successful organization creation",
+			method:         "POST",
+			url:            "/organizations/",
+			caller:         owner,
+			payload:        map[string]interface{}{"name": "ACME"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Outsider cannot read the organization",
+			method:         "GET",
+			url:            "/organizations/1",
+			caller:         viewer,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Owner adds a viewer",
+			method:         "PUT",
+			url:            "/organizations/1/members/" + viewer,
+			caller:         owner,
+			payload:        map[string]interface{}{"role": "viewer"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Viewer reads the organization",
+			method:         "GET",
+			url:            "/organizations/1",
+			caller:         viewer,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Viewer cannot rename the organization",
+			method:         "PUT",
+			url:            "/organizations/1",
+			caller:         viewer,
+			payload:        map[string]interface{}{"name": "Evil Corp"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Last owner cannot be removed",
+			method:         "DELETE",
+			url:            "/organizations/1/members/" + owner,
+			caller:         owner,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Request preparation
+			var body []byte
+			if tt.payload != nil {
+				body, _ = json.Marshal(tt.payload)
+			}
+			req, _ := http.NewRequest(tt.method, tt.url, bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.caller != "" {
+				req.Header.Set("X-User-ID", tt.caller)
+			}
+
+			// Request execution
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			// Checking the response status
+			assert.Equal(t, tt.expectedStatus, recorder.Code)
+		})
+	}
+}
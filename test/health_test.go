@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/health"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/lifecycle"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHealthStore подменяет проверки базы данных
+type fakeHealthStore struct {
+	mu        sync.Mutex
+	pingErr   error
+	pingDelay time.Duration
+	version   uint
+	dirty     bool
+}
+
+// set изменяет состояние под блокировкой: проверки, прерванные по таймауту, могут еще выполняться
+func (s *fakeHealthStore) set(change func(s *fakeHealthStore)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change(s)
+}
+
+func (s *fakeHealthStore) Ping(ctx context.Context) error {
+	s.mu.Lock()
+	delay, err := s.pingDelay, s.pingErr
+	s.mu.Unlock()
+
+	select {
+	case <-time.After(delay):
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *fakeHealthStore) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.version, s.dirty, nil
+}
+
+// probe выполняет запрос к пробе и разбирает отчет
+func probe(t *testing.T, router http.Handler, path string) (int, models.HealthReport) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var report models.HealthReport
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+// TestHealthEndpoints проверяет /healthz и разбивку /readyz по проверкам
+func TestHealthEndpoints(t *testing.T) {
+	app := lifecycle.NewManager()
+	store := &fakeHealthStore{version: 10}
+
+	readiness := health.NewChecker(100 * time.Millisecond)
+	readiness.Add("lifecycle", health.Accepting(app.Ready))
+	readiness.Add("database", health.Database(store))
+	readiness.Add("migrations", health.Migrations(store, 10))
+	readiness.Add("workers", health.Workers(app.Workers))
+	router := handler.NewHandler(&service.Service{}, readiness, nil, nil).InitRoutes()
+
+	stop := make(chan struct{})
+	app.Go("test-worker", func(ctx context.Context) { <-stop })
+
+	// Процесс жив, даже когда не готов принимать трафик
+	code, report := probe(t, router, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.HealthOK, report.Status)
+
+	// Во время запуска готовность не подтверждена
+	code, report = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, models.HealthFail, report.Checks["lifecycle"].Status)
+	assert.Equal(t, models.HealthOK, report.Checks["database"].Status)
+
+	app.SetReady(true)
+	code, report = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, models.HealthReady, report.Status)
+	assert.Len(t, report.Checks, 4)
+
+	// Недоступная база данных
+	store.set(func(s *fakeHealthStore) { s.pingErr = errors.New("connection refused") })
+	code, report = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "connection refused", report.Checks["database"].Error)
+	store.set(func(s *fakeHealthStore) { s.pingErr = nil })
+
+	// Ping, не уложившийся в таймаут
+	store.set(func(s *fakeHealthStore) { s.pingDelay = time.Second })
+	code, report = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["database"].Error)
+	store.set(func(s *fakeHealthStore) { s.pingDelay = 0 })
+
+	// Схема не на ожидаемой версии
+	store.set(func(s *fakeHealthStore) { s.version = 9 })
+	code, report = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, models.HealthFail, report.Checks["migrations"].Status)
+	store.set(func(s *fakeHealthStore) { s.version, s.dirty = 10, true })
+	code, _ = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	store.set(func(s *fakeHealthStore) { s.dirty = false })
+
+	// Остановившийся фоновый воркер
+	close(stop)
+	require.Eventually(t, func() bool { return !app.Workers()["test-worker"] }, time.Second, 10*time.Millisecond)
+	code, report = probe(t, router, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "workers not running: test-worker", report.Checks["workers"].Error)
+}
@@ -0,0 +1,111 @@
+package test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/lifecycle"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runManagedServer запускает сервер, обработчик которого отвечает через delay
+func runManagedServer(t *testing.T, app *lifecycle.Manager, delay time.Duration) (*server.Server, string, <-chan error) {
+	t.Helper()
+
+	cfg := testServerConfig(t)
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv, err := server.NewServer(cfg, app.Track(slow))
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- srv.Run() }()
+
+	addr := net.JoinHostPort(cfg.Host, cfg.Port)
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	return srv, "http://" + addr + "/", done
+}
+
+// TestLifecycleGracefulShutdown проверяет порядок остановки: готовность, запросы, воркеры, ресурсы
+func TestLifecycleGracefulShutdown(t *testing.T) {
+	app := lifecycle.NewManager()
+	app.SetReady(true)
+
+	var order []string
+	app.OnClose("first", func() error { order = append(order, "first"); return nil })
+	app.OnClose("second", func() error { order = append(order, "second"); return nil })
+
+	var workerStopped atomic.Bool
+	app.Go("test-worker", func(ctx context.Context) {
+		<-ctx.Done()
+		workerStopped.Store(true)
+	})
+
+	srv, url, done := runManagedServer(t, app, 300*time.Millisecond)
+
+	// Запрос, который выполняется во время остановки
+	result := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			result <- 0
+			return
+		}
+		resp.Body.Close()
+		result <- resp.StatusCode
+	}()
+	require.Eventually(t, func() bool { return app.InFlight() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- app.Shutdown(ctx, 50*time.Millisecond, srv.Shutdown) }()
+
+	// Готовность снимается сразу, до остановки сервера
+	assert.Eventually(t, func() bool { return !app.Ready() }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, <-shutdown)
+	require.NoError(t, <-done)
+	assert.Equal(t, http.StatusNoContent, <-result)
+	assert.True(t, workerStopped.Load())
+	assert.Equal(t, []string{"second", "first"}, order)
+}
+
+// TestLifecycleShutdownDeadline проверяет, что остановка не зависает на долгих запросах
+func TestLifecycleShutdownDeadline(t *testing.T) {
+	app := lifecycle.NewManager()
+	closed := false
+	app.OnClose("database", func() error { closed = true; return nil })
+
+	srv, url, done := runManagedServer(t, app, 5*time.Second)
+	go func() {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	require.Eventually(t, func() bool { return app.InFlight() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := app.Shutdown(ctx, 0, srv.Shutdown)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	assert.True(t, closed)
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// otherMember - второй участник общей подписки помимо testUsers[1]
+const otherMember = "7c1d2e3f-4a5b-4c6d-8e7f-9a0b1c2d3e4f"
+
+// fixedAmountRepo принимает участников, пока их фиксированные суммы не превышают цену 500
+type fixedAmountRepo struct {
+	postgres.MemberStore
+	fixedTotal int
+}
+
+func (r *fixedAmountRepo) Add(ctx context.Context, member models.MemberDB) (models.MemberDB, error) {
+	if member.FixedAmount != nil {
+		if r.fixedTotal+*member.FixedAmount > 500 {
+			return models.MemberDB{}, fmt.Errorf("%w: %d > 500", postgres.ErrFixedAmounts, r.fixedTotal+*member.FixedAmount)
+		}
+		r.fixedTotal += *member.FixedAmount
+	}
+	return member, nil
+}
+
+// lowPriceStore отклоняет снижение цены подписки 1 ниже 300
+type lowPriceStore struct {
+	pausedSubStore
+}
+
+func (lowPriceStore) Update(ctx context.Context, subID int, input models.UpdateSubscription) error {
+	if input.Price != nil && *input.Price < 300 {
+		return fmt.Errorf("%w: 300 > %d", postgres.ErrFixedAmounts, *input.Price)
+	}
+	return nil
+}
+
+// TestMemberFixedAmounts проверяет ответ 409, когда фиксированные суммы участников превышают цену
+func TestMemberFixedAmounts(t *testing.T) {
+	services := &service.Service{
+		MemberStore:       service.NewMemberService(&fixedAmountRepo{}, pausedSubStore{}, noBudgets{}),
+		SubscriptionStore: service.NewSubscriptionService(lowPriceStore{}, noBudgets{}, service.NewDuplicateDetector(lowPriceStore{}, service.DuplicatePolicyReject)),
+		OrganizationStore: personalSubscriptions{},
+	}
+	router := handler.NewHandler(services, nil, nil, nil).InitRoutes()
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		payload        interface{}
+		expectedStatus int
+	}{
+		{name: "Fixed amount within the price", method: http.MethodPost, url: "/subscriptions/1/members", payload: map[string]interface{}{"user_id": testUsers[1], "fixed_amount": 300}, expectedStatus: http.StatusOK},
+		{name: "Fixed amounts above the price", method: http.MethodPost, url: "/subscriptions/1/members", payload: map[string]interface{}{"user_id": otherMember, "fixed_amount": 300}, expectedStatus: http.StatusConflict},
+		{name: "Weighted member", method: http.MethodPost, url: "/subscriptions/1/members", payload: map[string]interface{}{"user_id": otherMember, "share_weight": 1}, expectedStatus: http.StatusOK},
+		{name: "Price below the fixed amounts", method: http.MethodPut, url: "/subscriptions/1", payload: map[string]interface{}{"price": 200}, expectedStatus: http.StatusConflict},
+		{name: "Price covering the fixed amounts", method: http.MethodPut, url: "/subscriptions/1", payload: map[string]interface{}{"price": 300}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveJSON(router, tt.method, tt.url, tt.payload, "")
+			assert.Equal(t, tt.expectedStatus, recorder.Code, recorder.Body.String())
+		})
+	}
+}
+
+// TestMemberSharesIntegration проверяет фиксированные доли участников на PostgreSQL
+func TestMemberSharesIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	dbConfig, cleanup, err := setupTestContainer(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := setupTestDatabase(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+	router := newTestRouter(db)
+	_, err = db.Exec("INSERT INTO users (id) VALUES ($1)", otherMember)
+	require.NoError(t, err)
+
+	recorder := serveJSON(router, http.MethodPost, "/subscriptions/", map[string]interface{}{
+		"service_name": "Netflix", "price": 500, "user_id": testUsers[0], "start_date": "01-2025",
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	subID := int(created["subId"].(float64))
+	subURL := "/subscriptions/" + strconv.Itoa(subID)
+
+	addMember := func(userID string, fixed int) int {
+		t.Helper()
+		return serveJSON(router, http.MethodPost, subURL+"/members", map[string]interface{}{"user_id": userID, "fixed_amount": fixed}, "").Code
+	}
+	assert.Equal(t, http.StatusOK, addMember(testUsers[1], 300))
+	assert.Equal(t, http.StatusConflict, addMember(otherMember, 300))
+
+	// Снижение цены ниже фиксированных сумм отклоняется, цена не меняется
+	assert.Equal(t, http.StatusConflict, serveJSON(router, http.MethodPut, subURL, map[string]interface{}{"price": 200}, "").Code)
+	var price int
+	require.NoError(t, db.Get(&price, "SELECT price FROM subscriptions WHERE id = $1", subID))
+	assert.Equal(t, 500, price)
+
+	// Суммы, сохраненные до изменения цены в обход API, уменьшаются до списания
+	_, err = db.Exec("UPDATE subscriptions SET price = 200 WHERE id = $1", subID)
+	require.NoError(t, err)
+	repo := postgres.NewSubscriptionRepository(db)
+	for user, expected := range map[string]int{testUsers[0]: 0, testUsers[1]: 200} {
+		userID := user
+		total, err := repo.GetSubscriptionSummary(context.Background(), models.SubscriptionFilter{
+			Period:  models.Period{StartDate: "01-2025", FinishDate: "01-2025"},
+			Filters: models.Filters{UserID: &userID},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, expected, total, user)
+	}
+}
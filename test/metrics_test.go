@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/metrics"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUserStore возвращает ошибку для неизвестного пользователя
+type fakeUserStore struct {
+	postgres.UserStore
+}
+
+func (fakeUserStore) GetById(ctx context.Context, userID string) (models.UserDB, error) {
+	if userID == testUsers[0] {
+		return models.UserDB{}, nil
+	}
+	return models.UserDB{}, postgres.ErrUserNotFound
+}
+
+// scrape возвращает метрики в формате Prometheus
+func scrape(t *testing.T, router http.Handler) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+// TestMetricsEndpoint проверяет метрики HTTP, пула соединений, репозиториев и бизнес-показателей
+func TestMetricsEndpoint(t *testing.T) {
+	m := metrics.New()
+
+	// sql.Open не устанавливает соединение, статистика пула доступна сразу
+	db, err := sql.Open("postgres", "host=localhost")
+	require.NoError(t, err)
+	defer db.Close()
+	m.RegisterDB(db, "postgres")
+
+	repos := m.InstrumentRepository(&postgres.Repository{UserStore: fakeUserStore{}})
+	_, err = repos.UserStore.GetById(context.Background(), testUsers[0])
+	require.NoError(t, err)
+	_, err = repos.UserStore.GetById(context.Background(), testUsers[1])
+	assert.True(t, errors.Is(err, postgres.ErrUserNotFound))
+
+	m.SetStats(models.SubscriptionStats{
+		ByStatus:     map[string]int{models.StatusActive: 3, models.StatusCancelled: 1},
+		MonthlySpend: 1200,
+	})
+
+	router := handler.NewHandler(&service.Service{}, nil, m, nil).InitRoutes()
+	for _, path := range []string{"/healthz", "/healthz", "/unknown"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	body := scrape(t, router)
+
+	// Запросы учитываются по шаблону маршрута
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/healthz",status="200"} 2`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`)
+
+	// Статистика пула соединений
+	assert.Contains(t, body, `go_sql_max_open_connections{db_name="postgres"}`)
+
+	// Длительность вызовов репозитория с результатом
+	assert.Contains(t, body, `db_query_duration_seconds_count{method="GetById",outcome="ok",store="user"} 1`)
+	assert.Contains(t, body, `db_query_duration_seconds_count{method="GetById",outcome="error",store="user"} 1`)
+
+	// Бизнес-показатели
+	assert.Contains(t, body, `subscription_aggregator_subscriptions{status="active"} 3`)
+	assert.Contains(t, body, `subscription_aggregator_subscriptions{status="cancelled"} 1`)
+	assert.Contains(t, body, `subscription_aggregator_monthly_spend 1200`)
+}
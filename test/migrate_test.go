@@ -0,0 +1,91 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmbeddedMigrations проверяет, что в бинарник встроены все миграции каталога migrations
+func TestEmbeddedMigrations(t *testing.T) {
+	ups, err := filepath.Glob("../migrations/*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, ups)
+
+	// У каждой миграции есть откат
+	for _, up := range ups {
+		_, err := os.Stat(strings.TrimSuffix(up, ".up.sql") + ".down.sql")
+		assert.NoError(t, err, up)
+	}
+
+	// Версия не зависит от рабочего каталога: тесты запускаются из test/
+	version, err := postgres.LatestMigrationVersion()
+	require.NoError(t, err)
+	assert.Equal(t, uint(len(ups)), version)
+}
+
+// TestMigratorIntegration проверяет команды up, down, version и force
+func TestMigratorIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := postgres.NewPostgresDB(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+
+	latest, err := postgres.LatestMigrationVersion()
+	require.NoError(t, err)
+
+	m, err := postgres.NewMigrator(ctx, db)
+	require.NoError(t, err)
+
+	// Пустая база имеет версию 0
+	version, dirty, err := m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), version)
+	assert.False(t, dirty)
+
+	// Повторный up не является ошибкой
+	require.NoError(t, m.Up())
+	require.NoError(t, m.Up())
+	version, _, err = m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+
+	require.NoError(t, m.Down(2))
+	version, _, err = m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, latest-2, version)
+	assert.Error(t, m.Down(0))
+
+	require.NoError(t, m.Force(int(latest-1)))
+	version, dirty, err = m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, latest-1, version)
+	assert.False(t, dirty)
+
+	// Закрытие мигратора возвращает соединение, но не закрывает пул
+	require.NoError(t, m.Close())
+	require.NoError(t, db.Ping())
+
+	// Сервер применяет недостающие миграции при старте
+	require.NoError(t, postgres.RunMigrations(db))
+	var tables int
+	require.NoError(t, db.Get(&tables, "SELECT COUNT(*) FROM information_schema.tables WHERE table_name = 'rate_limits'"))
+	assert.Equal(t, 1, tables)
+}
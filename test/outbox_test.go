@@ -0,0 +1,229 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/outbox"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outboxFailure - результат неудачной публикации, записанный relay
+type outboxFailure struct {
+	retryAt *time.Time
+}
+
+// memOutbox выдает каждое событие один раз и запоминает итог публикации; аренда событий
+// из lost потеряна, их итог не записывается
+type memOutbox struct {
+	mu        sync.Mutex
+	events    []models.OutboxEvent
+	claims    int
+	lost      map[int64]bool
+	delivered []int64
+	failed    map[int64]outboxFailure
+}
+
+func (s *memOutbox) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claims++
+	if limit > len(s.events) {
+		limit = len(s.events)
+	}
+	claimed := s.events[:limit]
+	s.events = s.events[limit:]
+	return claimed, nil
+}
+
+func (s *memOutbox) MarkDelivered(ctx context.Context, event models.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lost[event.Id] {
+		return postgres.ErrLeaseLost
+	}
+	s.delivered = append(s.delivered, event.Id)
+	return nil
+}
+
+func (s *memOutbox) MarkFailed(ctx context.Context, event models.OutboxEvent, cause string, retryAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lost[event.Id] {
+		return postgres.ErrLeaseLost
+	}
+	s.failed[event.Id] = outboxFailure{retryAt: retryAt}
+	return nil
+}
+
+func (s *memOutbox) done() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.delivered) + len(s.failed) + len(s.lost)
+}
+
+// failingPublisher отклоняет события из failures и запоминает порядок публикации
+type failingPublisher struct {
+	mu        sync.Mutex
+	failures  map[int64]bool
+	published []int64
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, event models.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.published = append(p.published, event.Id)
+	if p.failures[event.Id] {
+		return errors.New("webhook responded with status 503")
+	}
+	return nil
+}
+
+// TestOutboxRelay проверяет доставку, повтор с задержкой, перевод в dead letter и потерю аренды
+func TestOutboxRelay(t *testing.T) {
+	store := &memOutbox{
+		events: []models.OutboxEvent{
+			{Id: 1, AggregateID: "1"},
+			{Id: 2, AggregateID: "2"},
+			{Id: 3, AggregateID: "3", Attempts: 2},
+			{Id: 4, AggregateID: "4", Attempts: outbox.DefaultMaxAttempts - 1},
+			{Id: 5, AggregateID: "5", Attempts: outbox.DefaultMaxAttempts + 5},
+			{Id: 6, AggregateID: "6"},
+			{Id: 7, AggregateID: "7"},
+		},
+		lost:   map[int64]bool{6: true},
+		failed: map[int64]outboxFailure{},
+	}
+	publisher := &failingPublisher{failures: map[int64]bool{2: true, 3: true, 4: true, 5: true}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started := time.Now()
+	go outbox.NewRelay(store, publisher, time.Hour, 2).Run(ctx)
+	require.Eventually(t, func() bool { return store.done() == 7 }, 5*time.Second, 5*time.Millisecond)
+	cancel()
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7}, publisher.published)
+	// Потерянная аренда не останавливает проход: итог события 6 записывает другой relay
+	assert.Equal(t, []int64{1, 7}, store.delivered)
+	assert.NotContains(t, store.failed, int64(6))
+
+	// Задержка удваивается с каждой неудачей и не превышает верхней границы
+	require.NotNil(t, store.failed[2].retryAt)
+	assert.WithinDuration(t, started.Add(outbox.DefaultBackoff), *store.failed[2].retryAt, time.Second)
+	require.NotNil(t, store.failed[3].retryAt)
+	assert.WithinDuration(t, started.Add(4*outbox.DefaultBackoff), *store.failed[3].retryAt, time.Second)
+
+	// Исчерпавшие попытки события уходят в dead letter
+	assert.Nil(t, store.failed[4].retryAt)
+	assert.Nil(t, store.failed[5].retryAt)
+
+	// Пустая выборка завершает проход до следующего тика
+	assert.Equal(t, 5, store.claims)
+}
+
+// TestOutboxClaimIntegration проверяет аренду событий, порядок внутри агрегата, повтор и dead letter
+func TestOutboxClaimIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := setupTestDatabase(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Два события подписки 1 и одно событие подписки 2
+	for _, aggregateID := range []string{"1", "2", "1"} {
+		_, err := db.Exec("INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload) VALUES ('subscription', $1, 'subscription.updated', '{}')", aggregateID)
+		require.NoError(t, err)
+	}
+	store := postgres.NewOutboxRepository(db)
+
+	claimFor := func(lease time.Duration) map[int64]models.OutboxEvent {
+		t.Helper()
+		events, err := store.Claim(ctx, 10, lease)
+		require.NoError(t, err)
+		claimed := make(map[int64]models.OutboxEvent, len(events))
+		for _, event := range events {
+			claimed[event.Id] = event
+		}
+		return claimed
+	}
+	claim := func() map[int64]models.OutboxEvent {
+		t.Helper()
+		return claimFor(time.Minute)
+	}
+	ids := func(claimed map[int64]models.OutboxEvent) []int64 {
+		ids := make([]int64, 0, len(claimed))
+		for _, id := range []int64{1, 2, 3} {
+			if _, ok := claimed[id]; ok {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+	const cause = "webhook responded with status 503"
+
+	// Выдается только самое старое событие агрегата, арендованные события не выдаются повторно
+	claimed := claim()
+	assert.Equal(t, []int64{1, 2}, ids(claimed))
+	assert.Empty(t, claim())
+
+	// Неудачное событие держит агрегат до повтора
+	past := time.Now().Add(-time.Second)
+	require.NoError(t, store.MarkFailed(ctx, claimed[1], cause, &past))
+	require.NoError(t, store.MarkDelivered(ctx, claimed[2]))
+	// Итог записывается один раз
+	assert.ErrorIs(t, store.MarkDelivered(ctx, claimed[2]), postgres.ErrLeaseLost)
+	claimed = claim()
+	assert.Equal(t, []int64{1}, ids(claimed))
+
+	var attempts int
+	require.NoError(t, db.Get(&attempts, "SELECT attempts FROM outbox WHERE id = 1"))
+	assert.Equal(t, 1, attempts)
+
+	// Повтор в будущем не выдается раньше срока
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, store.MarkFailed(ctx, claimed[1], cause, &future))
+	assert.Empty(t, claim())
+
+	// После истечения аренды событие получает другой relay; итог прежней аренды не записывается
+	_, err = db.Exec("UPDATE outbox SET next_attempt_at = NOW() WHERE id = 1")
+	require.NoError(t, err)
+	expired := claimFor(time.Millisecond)
+	require.Equal(t, []int64{1}, ids(expired))
+	time.Sleep(10 * time.Millisecond)
+	claimed = claim()
+	require.Equal(t, []int64{1}, ids(claimed))
+	assert.ErrorIs(t, store.MarkDelivered(ctx, expired[1]), postgres.ErrLeaseLost)
+	assert.ErrorIs(t, store.MarkFailed(ctx, expired[1], cause, &past), postgres.ErrLeaseLost)
+	require.NoError(t, db.Get(&attempts, "SELECT attempts FROM outbox WHERE id = 1"))
+	assert.Equal(t, 2, attempts)
+
+	// Событие в dead letter больше не выдается и не задерживает следующее событие агрегата
+	require.NoError(t, store.MarkFailed(ctx, claimed[1], cause, nil))
+	claimed = claim()
+	assert.Equal(t, []int64{3}, ids(claimed))
+	require.NoError(t, store.MarkDelivered(ctx, claimed[3]))
+	assert.Empty(t, claim())
+
+	var dead int
+	require.NoError(t, db.Get(&dead, "SELECT COUNT(*) FROM outbox WHERE dead_at IS NOT NULL"))
+	assert.Equal(t, 1, dead)
+}
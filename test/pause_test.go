@@ -0,0 +1,149 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pausedSubStore возвращает подписку 1 (март-август 2025), остальных подписок нет
+type pausedSubStore struct {
+	postgres.SubscriptionStore
+}
+
+func (pausedSubStore) GetById(ctx context.Context, subID int) (models.SubscriptionDB, error) {
+	if subID != 1 {
+		return models.SubscriptionDB{}, sql.ErrNoRows
+	}
+	finish := monthOf(2025, time.September)
+	return models.SubscriptionDB{
+		Id: 1, ServiceName: "Netflix", Price: 500, UserID: uuid.MustParse(testUsers[0]),
+		StartDate: monthOf(2025, time.March), FinishDate: &finish, Status: models.StatusActive,
+	}, nil
+}
+
+// fakePauseRepo запоминает месяц паузы и новый статус; возобновлять нечего
+type fakePauseRepo struct {
+	pausedFrom time.Time
+	status     string
+}
+
+func (r *fakePauseRepo) Pause(ctx context.Context, subID int, from time.Time, expectedStatus, newStatus string) (models.PauseDB, error) {
+	r.pausedFrom, r.status = from, newStatus
+	return models.PauseDB{SubscriptionID: subID, PausedFrom: from}, nil
+}
+
+func (r *fakePauseRepo) SyncStatuses(ctx context.Context, month time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *fakePauseRepo) Resume(ctx context.Context, subID int, from time.Time, expectedStatus, newStatus string) (models.PauseDB, error) {
+	if subID != 1 {
+		return models.PauseDB{}, postgres.ErrSubscriptionNotFound
+	}
+	return models.PauseDB{}, postgres.ErrNotPaused
+}
+
+// personalSubscriptions считает все подписки личными, проверка ролей организации их пропускает
+type personalSubscriptions struct {
+	service.OrganizationStore
+}
+
+func (personalSubscriptions) SubscriptionOrganization(ctx context.Context, subID int) (*int, error) {
+	return nil, nil
+}
+
+// TestPauseValidation проверяет коды ответов паузы и возобновления
+func TestPauseValidation(t *testing.T) {
+	repo := &fakePauseRepo{}
+	services := &service.Service{
+		PauseStore:        service.NewPauseService(repo, pausedSubStore{}, noBudgets{}),
+		OrganizationStore: personalSubscriptions{},
+	}
+	router := handler.NewHandler(services, nil, nil, nil).InitRoutes()
+
+	tests := []struct {
+		name           string
+		url            string
+		payload        interface{}
+		expectedStatus int
+	}{
+		{name: "Pause before the start date", url: "/subscriptions/1/pause", payload: map[string]string{"from": "02-2025"}, expectedStatus: http.StatusBadRequest},
+		{name: "Pause at the finish date", url: "/subscriptions/1/pause", payload: map[string]string{"from": "09-2025"}, expectedStatus: http.StatusBadRequest},
+		{name: "Malformed month", url: "/subscriptions/1/pause", payload: map[string]string{"from": "2025-05"}, expectedStatus: http.StatusBadRequest},
+		{name: "Unknown subscription", url: "/subscriptions/2/pause", payload: map[string]string{"from": "05-2025"}, expectedStatus: http.StatusNotFound},
+		{name: "Resume of unknown subscription", url: "/subscriptions/2/resume", expectedStatus: http.StatusNotFound},
+		{name: "Resume without pause", url: "/subscriptions/1/resume", payload: map[string]string{"from": "06-2025"}, expectedStatus: http.StatusConflict},
+		{name: "Successful pause", url: "/subscriptions/1/pause", payload: map[string]string{"from": "05-2025"}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveJSON(router, http.MethodPost, tt.url, tt.payload, "")
+			assert.Equal(t, tt.expectedStatus, recorder.Code, recorder.Body.String())
+		})
+	}
+
+	assert.Equal(t, monthOf(2025, time.May), repo.pausedFrom)
+	assert.Equal(t, models.StatusPaused, repo.status)
+}
+
+// TestPauseChargesIntegration проверяет, что месяцы паузы не попадают в итоговую стоимость
+func TestPauseChargesIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	dbConfig, cleanup, err := setupTestContainer(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	router, err := setupTestServer(dbConfig)
+	if err != nil {
+		t.Fatalf("Failed to set up test server: %v", err)
+	}
+
+	recorder := serveJSON(router, http.MethodPost, "/subscriptions/", map[string]interface{}{
+		"service_name": "Netflix", "price": 100, "user_id": testUsers[0], "start_date": "01-2025", "finish_date": "07-2025",
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+	subURL := "/subscriptions/" + strconv.Itoa(int(created["subId"].(float64)))
+
+	totalCost := func(from, to string) int {
+		t.Helper()
+		recorder := serveJSON(router, http.MethodGet, "/subscriptions/total-cost", map[string]interface{}{
+			"period":  map[string]string{"start_date": from, "finish_date": to},
+			"filters": map[string]string{"user_id": testUsers[0]},
+		}, "")
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		var summary models.SubscriptionFilter
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &summary))
+		return summary.TotalCost
+	}
+
+	// Пауза с марта, возобновление с мая: март и апрель не начисляются
+	require.Equal(t, http.StatusOK, serveJSON(router, http.MethodPost, subURL+"/pause", map[string]string{"from": "03-2025"}, "").Code)
+	assert.Equal(t, 2*100, totalCost("01-2025", "06-2025"))
+	require.Equal(t, http.StatusOK, serveJSON(router, http.MethodPost, subURL+"/resume", map[string]string{"from": "05-2025"}, "").Code)
+	assert.Equal(t, 4*100, totalCost("01-2025", "06-2025"))
+	assert.Zero(t, totalCost("03-2025", "04-2025"))
+
+	// Новая пауза не может перекрывать прошедшую
+	assert.Equal(t, http.StatusConflict, serveJSON(router, http.MethodPost, subURL+"/pause", map[string]string{"from": "04-2025"}, "").Code)
+}
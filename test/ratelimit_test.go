@@ -0,0 +1,237 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/ratelimit"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRateLimits задает лимиты, которые не успевают восстановиться за время теста
+func testRateLimits(burst int) ratelimit.Config {
+	limit := ratelimit.Limit{Rate: 0.001, Burst: burst}
+	return ratelimit.Config{Enabled: true, Store: ratelimit.StoreMemory, Read: limit, Write: limit, Summary: limit}
+}
+
+// recordingStore запоминает ключи бакетов и отклоняет все запросы
+type recordingStore struct {
+	mu   sync.Mutex
+	keys []string
+	err  error
+}
+
+func (s *recordingStore) Take(_ context.Context, key string, _ float64, _ int) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, key)
+	return 0, false, s.err
+}
+
+// TestLimiterTokenBucket проверяет расход токенов и раздельные бакеты
+func TestLimiterTokenBucket(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), testRateLimits(2))
+	ctx := context.Background()
+
+	first, err := limiter.Allow(ctx, ratelimit.ClassRead, "ip:1")
+	require.NoError(t, err)
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 2, first.Limit)
+	assert.Equal(t, 1, first.Remaining)
+
+	second, err := limiter.Allow(ctx, ratelimit.ClassRead, "ip:1")
+	require.NoError(t, err)
+	assert.True(t, second.Allowed)
+	assert.Equal(t, 0, second.Remaining)
+
+	// Пустой бакет: запрос отклоняется, следующий токен появится примерно через 1000 секунд
+	third, err := limiter.Allow(ctx, ratelimit.ClassRead, "ip:1")
+	require.NoError(t, err)
+	assert.False(t, third.Allowed)
+	assert.InDelta(t, 1000, third.RetryAfter.Seconds(), 1)
+	assert.InDelta(t, 2000, third.Reset.Seconds(), 1)
+
+	// Другой клиент и другой класс маршрутов расходуют свои бакеты
+	other, err := limiter.Allow(ctx, ratelimit.ClassRead, "ip:2")
+	require.NoError(t, err)
+	assert.True(t, other.Allowed)
+
+	write, err := limiter.Allow(ctx, ratelimit.ClassWrite, "ip:1")
+	require.NoError(t, err)
+	assert.True(t, write.Allowed)
+}
+
+// TestRateLimitMiddleware проверяет заголовки RateLimit-* и ответ 429
+func TestRateLimitMiddleware(t *testing.T) {
+	captureLogs(t)
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), testRateLimits(2))
+	router := handler.NewHandler(&service.Service{UserStore: knownUsers{}}, nil, nil, limiter).InitRoutes()
+
+	get := func(path, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if userID != "" {
+			req.Header.Set("X-User-ID", userID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/unknown", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1000", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=2000", w.Header().Get("RateLimit-Policy"))
+
+	get("/unknown", "")
+	w = get("/unknown", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1000", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "rate limit exceeded for read requests", body["message"])
+	assert.NotEmpty(t, body["request_id"])
+
+	// Неизвестный или некорректный ID не дает нового бакета
+	w = get("/unknown", "user-1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	w = get("/unknown", "00000000-0000-0000-0000-000000000001")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// Существующий пользователь с того же IP получает свой бакет
+	w = get("/unknown", testUsers[0])
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Пробы не ограничиваются
+	for i := 0; i < 5; i++ {
+		w = get("/healthz", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+// TestRateLimitClasses проверяет выбор класса лимита и ключа клиента
+func TestRateLimitClasses(t *testing.T) {
+	captureLogs(t)
+	store := &recordingStore{}
+	limiter := ratelimit.NewLimiter(store, testRateLimits(1))
+	router := handler.NewHandler(&service.Service{UserStore: knownUsers{}}, nil, nil, limiter).InitRoutes()
+
+	requests := []struct {
+		method, path, userID string
+	}{
+		{http.MethodGet, "/subscriptions/1", ""},
+		{http.MethodPost, "/subscriptions/", testUsers[0]},
+		{http.MethodGet, "/subscriptions/total-cost", testUsers[0]},
+		{http.MethodGet, "/analytics/forecast", ""},
+		{http.MethodGet, "/subscriptions/1", strings.Repeat("x", 1000)},
+	}
+	for _, r := range requests {
+		req := httptest.NewRequest(r.method, r.path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if r.userID != "" {
+			req.Header.Set("X-User-ID", r.userID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, r.path)
+	}
+
+	assert.Equal(t, []string{
+		"read:ip:192.0.2.1",
+		"write:user:" + testUsers[0],
+		"summary:user:" + testUsers[0],
+		"summary:ip:192.0.2.1",
+		"read:ip:192.0.2.1",
+	}, store.keys)
+
+	// Слишком длинный ключ заменяется хешем и помещается в rate_limits.key
+	_, err := limiter.Allow(context.Background(), ratelimit.ClassRead, "user:"+strings.Repeat("x", 300))
+	require.NoError(t, err)
+	key := store.keys[len(store.keys)-1]
+	assert.True(t, strings.HasPrefix(key, "read:sha256:"), key)
+	assert.LessOrEqual(t, len(key), 255)
+}
+
+// TestRateLimitStoreFailure проверяет, что сбой хранилища не блокирует запросы
+func TestRateLimitStoreFailure(t *testing.T) {
+	logs := captureLogs(t)
+	store := &recordingStore{err: errors.New("connection refused")}
+	router := handler.NewHandler(&service.Service{}, nil, nil, ratelimit.NewLimiter(store, testRateLimits(1))).InitRoutes()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	assert.True(t, strings.Contains(logs.String(), "rate limit check failed"))
+}
+
+// TestRateLimitPostgresIntegration проверяет общий бакет в PostgreSQL при конкурентных запросах
+func TestRateLimitPostgresIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := postgres.NewPostgresDB(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, postgres.RunMigrations(db))
+
+	limiter := ratelimit.NewLimiter(postgres.NewRateLimitRepository(db), testRateLimits(5))
+
+	// Из 20 одновременных запросов проходят ровно burst
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := limiter.Allow(ctx, ratelimit.ClassSummary, "user:shared")
+			assert.NoError(t, err)
+			if result.Allowed {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(5), allowed.Load())
+
+	result, err := limiter.Allow(ctx, ratelimit.ClassSummary, "user:shared")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// Недавно использованные бакеты не удаляются, простаивающие удаляются
+	repo := postgres.NewRateLimitRepository(db)
+	deleted, err := repo.DeleteIdle(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	deleted, err = repo.DeleteIdle(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
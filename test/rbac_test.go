@@ -0,0 +1,342 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/sqlite"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orgSubStore: подписка 1 оплачивается организацией 7, подписка 2 личная, чтение подписки 3
+// завершается ошибкой БД, остальных подписок нет; фильтры списка и сводки запоминаются
+type orgSubStore struct {
+	postgres.SubscriptionStore
+	listFilter    models.SubscriptionListFilter
+	summaryFilter models.SubscriptionFilter
+}
+
+func (s *orgSubStore) GetById(ctx context.Context, subID int) (models.SubscriptionDB, error) {
+	orgID := 7
+	sub := models.SubscriptionDB{Id: subID, ServiceName: "Netflix", Price: 500, UserID: uuid.MustParse(testUsers[1]), StartDate: monthOf(2025, time.January), Status: models.StatusActive}
+	switch subID {
+	case 1:
+		sub.OrganizationID = &orgID
+		return sub, nil
+	case 2:
+		return sub, nil
+	case 3:
+		return models.SubscriptionDB{}, errors.New("connection refused")
+	}
+	return models.SubscriptionDB{}, sql.ErrNoRows
+}
+
+func (s *orgSubStore) Update(ctx context.Context, subID int, input models.UpdateSubscription) error {
+	return nil
+}
+
+func (s *orgSubStore) FindOverlaps(ctx context.Context, filter models.OverlapFilter) ([]models.SubscriptionDB, error) {
+	return nil, nil
+}
+
+func (s *orgSubStore) GetAll(ctx context.Context, filter models.SubscriptionListFilter) ([]models.SubscriptionDB, error) {
+	s.listFilter = filter
+	return nil, nil
+}
+
+func (s *orgSubStore) GetSubscriptionSummary(ctx context.Context, filter models.SubscriptionFilter) (int, error) {
+	s.summaryFilter = filter
+	return 500, nil
+}
+
+// orgRoles: в организации 7 первый тестовый пользователь - viewer, второй - member
+type orgRoles struct {
+	postgres.OrganizationStore
+}
+
+func (orgRoles) GetRole(ctx context.Context, orgID int, userID string) (string, error) {
+	if orgID == 7 {
+		switch userID {
+		case testUsers[0]:
+			return models.RoleViewer, nil
+		case testUsers[1]:
+			return models.RoleMember, nil
+		}
+	}
+	return "", postgres.ErrNotOrganizationMember
+}
+
+// brokenUser - пользователь, поиск которого завершается ошибкой БД
+const brokenUser = "0f0e0d0c-0b0a-4908-8706-050403020100"
+
+// knownUsers знает тестовых пользователей и otherMember
+type knownUsers struct {
+	service.UserStore
+}
+
+func (knownUsers) GetById(ctx context.Context, userID string) (models.User, error) {
+	switch userID {
+	case testUsers[0], testUsers[1], otherMember:
+		return models.User{Id: userID}, nil
+	case brokenUser:
+		return models.User{}, errors.New("pq: connection refused")
+	}
+	return models.User{}, service.ErrUserNotFound
+}
+
+func newRBACRouter(store *orgSubStore) http.Handler {
+	services := &service.Service{
+		SubscriptionStore: service.NewSubscriptionService(store, noBudgets{}, service.NewDuplicateDetector(store, service.DuplicatePolicyWarn)),
+		OrganizationStore: service.NewOrganizationService(orgRoles{}, store),
+		UserStore:         knownUsers{},
+	}
+	return handler.NewHandler(services, nil, nil, nil).InitRoutes()
+}
+
+// TestSubscriptionOrgAccess проверяет доступ к подпискам организации через маршруты /subscriptions
+func TestSubscriptionOrgAccess(t *testing.T) {
+	router := newRBACRouter(&orgSubStore{})
+	viewer, member, outsider := testUsers[0], testUsers[1], otherMember
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		caller         string
+		payload        interface{}
+		expectedStatus int
+	}{
+		{name: "Viewer reads an organization subscription", method: http.MethodGet, url: "/subscriptions/1", caller: viewer, expectedStatus: http.StatusOK},
+		{name: "Anonymous read of an organization subscription", method: http.MethodGet, url: "/subscriptions/1", expectedStatus: http.StatusUnauthorized},
+		{name: "Unknown caller", method: http.MethodGet, url: "/subscriptions/1", caller: uuid.NewString(), expectedStatus: http.StatusUnauthorized},
+		{name: "Failed caller lookup", method: http.MethodGet, url: "/subscriptions/1", caller: brokenUser, expectedStatus: http.StatusInternalServerError},
+		{name: "Outsider reads an organization subscription", method: http.MethodGet, url: "/subscriptions/1", caller: outsider, expectedStatus: http.StatusForbidden},
+		{name: "Outsider lists members", method: http.MethodGet, url: "/subscriptions/1/members", caller: outsider, expectedStatus: http.StatusForbidden},
+		{name: "Viewer updates", method: http.MethodPut, url: "/subscriptions/1", caller: viewer, payload: map[string]int{"price": 600}, expectedStatus: http.StatusForbidden},
+		{name: "Viewer deletes", method: http.MethodDelete, url: "/subscriptions/1", caller: viewer, expectedStatus: http.StatusForbidden},
+		{name: "Viewer pauses", method: http.MethodPost, url: "/subscriptions/1/pause", caller: viewer, expectedStatus: http.StatusForbidden},
+		{name: "Viewer resumes", method: http.MethodPost, url: "/subscriptions/1/resume", caller: viewer, expectedStatus: http.StatusForbidden},
+		{name: "Viewer cancels", method: http.MethodPost, url: "/subscriptions/1/cancel", caller: viewer, expectedStatus: http.StatusForbidden},
+		{name: "Viewer adds a member", method: http.MethodPost, url: "/subscriptions/1/members", caller: viewer, payload: map[string]string{"user_id": outsider}, expectedStatus: http.StatusForbidden},
+		{name: "Viewer removes a member", method: http.MethodDelete, url: "/subscriptions/1/members/" + member, caller: viewer, expectedStatus: http.StatusForbidden},
+		{name: "Member updates", method: http.MethodPut, url: "/subscriptions/1", caller: member, payload: map[string]int{"price": 600}, expectedStatus: http.StatusOK},
+		{name: "Personal subscription is not restricted", method: http.MethodGet, url: "/subscriptions/2", expectedStatus: http.StatusOK},
+		{name: "Missing subscription", method: http.MethodGet, url: "/subscriptions/404", expectedStatus: http.StatusNotFound},
+		{name: "Update of a missing subscription", method: http.MethodPut, url: "/subscriptions/404", payload: map[string]int{"price": 600}, expectedStatus: http.StatusNotFound},
+		{name: "Failed lookup on read", method: http.MethodGet, url: "/subscriptions/3", expectedStatus: http.StatusInternalServerError},
+		{name: "Failed lookup on update", method: http.MethodPut, url: "/subscriptions/3", payload: map[string]int{"price": 600}, expectedStatus: http.StatusInternalServerError},
+		{name: "Failed lookup on delete", method: http.MethodDelete, url: "/subscriptions/3", expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveJSON(router, tt.method, tt.url, tt.payload, tt.caller)
+			assert.Equal(t, tt.expectedStatus, recorder.Code, recorder.Body.String())
+			// Ошибки БД не раскрываются клиенту
+			assert.NotContains(t, recorder.Body.String(), "pq:")
+		})
+	}
+}
+
+// TestPublicSubscriptionQueries проверяет, что список и сводка /subscriptions не раскрывают подписки организаций
+func TestPublicSubscriptionQueries(t *testing.T) {
+	store := &orgSubStore{}
+	router := newRBACRouter(store)
+
+	recorder := serveJSON(router, http.MethodGet, "/subscriptions/", nil, "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.True(t, store.listFilter.Personal)
+	assert.Nil(t, store.listFilter.OrganizationID)
+
+	// Фильтр организации из запроса игнорируется и не попадает в ответ
+	recorder = serveJSON(router, http.MethodGet, "/subscriptions/total-cost", map[string]interface{}{
+		"period":  map[string]string{"start_date": "01-2025", "finish_date": "01-2025"},
+		"filters": map[string]interface{}{"organization_id": 7},
+	}, "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Nil(t, store.summaryFilter.Filters.OrganizationID)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.NotContains(t, response["filters"], "organization_id")
+}
+
+// contractUsers находит пользователей контрактных тестов
+type contractUsers struct {
+	postgres.UserStore
+}
+
+func (contractUsers) GetById(ctx context.Context, userID string) (models.UserDB, error) {
+	if userID == contractOwner || userID == contractMember {
+		return models.UserDB{Id: uuid.MustParse(userID)}, nil
+	}
+	return models.UserDB{}, postgres.ErrUserNotFound
+}
+
+// TestPublicQueriesLeaveOutOrganizations проверяет, что открытые эндпоинты не раскрывают
+// подписки организации пользователю, который в ней не состоит
+func TestPublicQueriesLeaveOutOrganizations(t *testing.T) {
+	f := newSQLiteFixture(t, sqlite.MemoryPath)
+	store := f.store
+	router := handler.NewHandler(&service.Service{
+		SubscriptionStore: service.NewSubscriptionService(store, noBudgets{}, service.NewDuplicateDetector(store, service.DuplicatePolicyWarn)),
+		AnalyticsStore:    service.NewAnalyticsService(store),
+		TrialStore:        service.NewTrialService(store),
+		UserStore:         service.NewUserService(contractUsers{}, store),
+		OrganizationStore: service.NewOrganizationService(orgRoles{}, store),
+	}, nil, nil, nil).InitRoutes()
+
+	// Личная и оплачиваемая организацией подписки владельца на один сервис с общим участником;
+	// пробный период заканчивается в текущем месяце, списания начинаются со следующего.
+	// Расчеты между участниками считает только PostgreSQL, см. TestSettlementOrganizationIntegration
+	now := time.Now().UTC()
+	month, next := now.Format("01-2006"), now.AddDate(0, 1, 0).Format("01-2006")
+	for _, sub := range []models.Subscription{
+		{ServiceName: "Netflix", Price: 500, UserID: contractOwner, StartDate: month, TrialEnd: month, Status: models.StatusTrial},
+		{ServiceName: "NETFLIX", Price: 700, UserID: contractOwner, StartDate: month, TrialEnd: month, Status: models.StatusTrial},
+	} {
+		f.create(t, sub)
+	}
+	f.exec(t, "INSERT INTO organizations (id, name) VALUES ($1, $2)", 7, "ACME")
+	f.exec(t, "UPDATE subscriptions SET organization_id = $1 WHERE service_name = $2", 7, "NETFLIX")
+	f.exec(t, "INSERT INTO subscription_members (subscription_id, user_id, share_weight) SELECT id, $1, 1 FROM subscriptions", contractMember)
+
+	// Подписка организации узнается по названию NETFLIX и доле участника 350
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		payload  interface{}
+		contains string
+	}{
+		{name: "Duplicates", method: http.MethodGet, url: "/subscriptions/duplicates", contains: `"data":[]`},
+		{name: "Ending trials", method: http.MethodGet, url: "/subscriptions/trials/ending?months=1", contains: `"service_name":"Netflix"`},
+		{name: "Forecast", method: http.MethodGet, url: "/analytics/forecast?months=2", contains: `"service_name":"Netflix"`},
+		{name: "Total cost", method: http.MethodGet, url: "/subscriptions/total-cost", payload: map[string]interface{}{
+			"period": map[string]string{"start_date": next, "finish_date": next},
+		}, contains: `"total_cost":500`},
+		{name: "User subscriptions", method: http.MethodGet, url: "/users/" + contractOwner + "/subscriptions", contains: `"service_name":"Netflix"`},
+		{name: "Duplicate warning on create", method: http.MethodPost, url: "/subscriptions/", payload: map[string]interface{}{
+			"service_name": "netflix", "price": 500, "user_id": contractOwner, "start_date": month,
+		}, contains: `"service_name":"Netflix"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := serveJSON(router, tt.method, tt.url, tt.payload, "")
+			require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+			assert.Contains(t, recorder.Body.String(), tt.contains)
+			assert.NotContains(t, recorder.Body.String(), "NETFLIX")
+			assert.NotContains(t, recorder.Body.String(), "350")
+		})
+	}
+}
+
+// TestUserCascadeOrganizationIntegration проверяет, что каскадное удаление пользователя
+// не удаляет подписки, оплачиваемые организацией
+func TestUserCascadeOrganizationIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	dbConfig, cleanup, err := setupTestContainer(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := setupTestDatabase(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+	router := newTestRouter(db)
+
+	owner, member := testUsers[0], testUsers[1]
+	require.Equal(t, http.StatusOK, serveJSON(router, http.MethodPost, "/organizations/", map[string]string{"name": "ACME"}, owner).Code)
+	require.Equal(t, http.StatusOK, serveJSON(router, http.MethodPut, "/organizations/1/members/"+member, map[string]string{"role": "member"}, owner).Code)
+
+	create := func() int {
+		t.Helper()
+		recorder := serveJSON(router, http.MethodPost, "/subscriptions/", map[string]interface{}{
+			"service_name": "Netflix", "price": 500, "user_id": member, "start_date": "01-2025",
+		}, "")
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+		var created map[string]interface{}
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &created))
+		return int(created["subId"].(float64))
+	}
+	create()
+	paid := create()
+	require.Equal(t, http.StatusOK, serveJSON(router, http.MethodPut, "/organizations/1/subscriptions/"+strconv.Itoa(paid), nil, member).Code)
+
+	count := func() int {
+		t.Helper()
+		var count int
+		require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM subscriptions WHERE user_id = $1", member))
+		return count
+	}
+
+	// Подписка организации блокирует удаление, личная подписка остается на месте
+	recorder := serveJSON(router, http.MethodDelete, "/users/"+member+"?cascade=true", nil, "")
+	assert.Equal(t, http.StatusConflict, recorder.Code, recorder.Body.String())
+	assert.Equal(t, 2, count())
+
+	// После отвязки администратором удаляются обе подписки
+	require.Equal(t, http.StatusOK, serveJSON(router, http.MethodDelete, "/organizations/1/subscriptions/"+strconv.Itoa(paid), nil, owner).Code)
+	assert.Equal(t, http.StatusOK, serveJSON(router, http.MethodDelete, "/users/"+member+"?cascade=true", nil, "").Code)
+	assert.Zero(t, count())
+}
+
+// TestSettlementOrganizationIntegration проверяет, что расчеты между участниками не учитывают
+// подписки, оплачиваемые организацией
+func TestSettlementOrganizationIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	dbConfig, cleanup, err := setupTestContainer(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := setupTestDatabase(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+	router := newTestRouter(db)
+
+	// Обе подписки владельца поровну делятся с участником, вторую оплачивает организация
+	owner, member := testUsers[0], testUsers[1]
+	for _, price := range []int{500, 700} {
+		recorder := serveJSON(router, http.MethodPost, "/subscriptions/", map[string]interface{}{
+			"service_name": "Netflix", "price": price, "user_id": owner, "start_date": "01-2025",
+		}, "")
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+	_, err = db.Exec("INSERT INTO subscription_members (subscription_id, user_id, share_weight) SELECT id, $1, 1 FROM subscriptions", member)
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO organizations (id, name) VALUES (7, 'ACME')")
+	require.NoError(t, err)
+	_, err = db.Exec("UPDATE subscriptions SET organization_id = 7 WHERE price = 700")
+	require.NoError(t, err)
+
+	recorder := serveJSON(router, http.MethodGet, "/analytics/settlement?from=01-2025&to=01-2025", nil, "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var response struct {
+		Data []models.Settlement `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	assert.Equal(t, []models.Settlement{
+		{Month: "01-2025", FromUserID: member, ToUserID: owner, Amount: 250, Currency: models.DefaultCurrency},
+	}, response.Data)
+}
@@ -0,0 +1,134 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/logging"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogs перенаправляет логи в буфер в формате JSON до конца теста
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger := logrus.StandardLogger()
+	output, formatter, hooks := logger.Out, logger.Formatter, logger.ReplaceHooks(make(logrus.LevelHooks))
+
+	logger.SetOutput(&buf)
+	logger.SetFormatter(new(logrus.JSONFormatter))
+	logger.AddHook(logging.ContextHook{})
+	t.Cleanup(func() {
+		logger.SetOutput(output)
+		logger.SetFormatter(formatter)
+		logger.ReplaceHooks(hooks)
+	})
+
+	return &buf
+}
+
+// logLines разбирает строки лога
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+// TestRequestID проверяет передачу и генерацию X-Request-ID
+func TestRequestID(t *testing.T) {
+	captureLogs(t)
+	router := handler.NewHandler(&service.Service{}, nil, nil, nil).InitRoutes()
+
+	// Переданный ID возвращается в ответе
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "req-42", w.Header().Get("X-Request-ID"))
+
+	// Без заголовка ID генерируется
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	_, err := uuid.Parse(w.Header().Get("X-Request-ID"))
+	assert.NoError(t, err)
+
+	// Слишком длинный ID заменяется сгенерированным
+	req = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	req.Header.Set("X-Request-ID", strings.Repeat("a", 200))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	_, err = uuid.Parse(w.Header().Get("X-Request-ID"))
+	assert.NoError(t, err)
+}
+
+// TestPanicRecoveryAndAccessLog проверяет ответ 500 при панике и строки лога с ID запроса
+func TestPanicRecoveryAndAccessLog(t *testing.T) {
+	logs := captureLogs(t)
+
+	// Сервис без хранилища подписок вызывает панику в обработчике
+	router := handler.NewHandler(&service.Service{}, nil, nil, nil).InitRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/subscriptions/1", nil)
+	req.Header.Set("X-Request-ID", "req-panic")
+	req.Header.Set("X-User-ID", testUsers[0])
+	w := httptest.NewRecorder()
+	require.NotPanics(t, func() { router.ServeHTTP(w, req) })
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "internal server error", body["message"])
+	assert.Equal(t, "req-panic", body["request_id"])
+
+	lines := logLines(t, logs)
+	require.Len(t, lines, 2)
+
+	// Паника записывается со стеком и ID запроса
+	assert.Contains(t, lines[0]["msg"], "panic recovered")
+	assert.Equal(t, "req-panic", lines[0]["request_id"])
+	assert.NotEmpty(t, lines[0]["stack"])
+
+	// Строка журнала доступа
+	access := lines[1]
+	assert.Equal(t, "request completed", access["msg"])
+	assert.Equal(t, "error", access["level"])
+	assert.Equal(t, "GET", access["method"])
+	assert.Equal(t, "/subscriptions/:subscription_id", access["route"])
+	assert.Equal(t, float64(http.StatusInternalServerError), access["status"])
+	assert.Equal(t, testUsers[0], access["user"])
+	assert.Equal(t, "req-panic", access["request_id"])
+	assert.Contains(t, access, "latency_ms")
+}
+
+// TestErrorResponseRequestID проверяет ID запроса в ответе об ошибке и в логе обработчика
+func TestErrorResponseRequestID(t *testing.T) {
+	logs := captureLogs(t)
+	router := handler.NewHandler(&service.Service{}, nil, nil, nil).InitRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/subscriptions/abc", nil)
+	req.Header.Set("X-Request-ID", "req-bad")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"request_id":"req-bad"`)
+	for _, line := range logLines(t, logs) {
+		assert.Equal(t, "req-bad", line["request_id"])
+	}
+}
@@ -0,0 +1,260 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/cli"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSpendStore возвращает заданные расхождения и запоминает проверенный период
+type fakeSpendStore struct {
+	postgres.SpendStore
+	mismatches []models.SpendMismatchDB
+	err        error
+	from, to   time.Time
+}
+
+func (s *fakeSpendStore) Check(ctx context.Context, from, to time.Time) ([]models.SpendMismatchDB, error) {
+	s.from, s.to = from, to
+	return s.mismatches, s.err
+}
+
+// spendMonth возвращает первое число месяца 2025 года
+func spendMonth(m time.Month) time.Time {
+	return time.Date(2025, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// TestSpendConfigUntil проверяет горизонт агрегата относительно текущего месяца
+func TestSpendConfigUntil(t *testing.T) {
+	now := time.Date(2025, time.November, 17, 15, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2027, time.November, 1, 0, 0, 0, 0, time.UTC), postgres.SpendConfig{HorizonMonths: 24}.Until(now))
+	assert.Equal(t, spendMonth(time.November), postgres.SpendConfig{}.Until(now))
+}
+
+// TestCheckSpend проверяет вывод расхождений агрегата с исходными данными
+func TestCheckSpend(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeSpendStore{}
+
+	var buf bytes.Buffer
+	differences, err := cli.CheckSpend(ctx, &buf, store, "01-2025", "03-2025")
+	require.NoError(t, err)
+	assert.Zero(t, differences)
+	assert.Equal(t, spendMonth(time.January), store.from)
+	assert.Equal(t, spendMonth(time.March), store.to)
+	assert.Equal(t, "Monthly spend from 01-2025 to 03-2025 matches subscriptions\n", buf.String())
+
+	store.mismatches = []models.SpendMismatchDB{
+		{UserID: testUsers[0], ServiceName: "Netflix", Month: spendMonth(time.February), Aggregated: 401, Actual: 400},
+		{UserID: testUsers[1], ServiceName: "Okko", Month: spendMonth(time.March), Aggregated: 0, Actual: 300},
+	}
+	buf.Reset()
+	differences, err = cli.CheckSpend(ctx, &buf, store, "01-2025", "03-2025")
+	require.NoError(t, err)
+	assert.Equal(t, 2, differences)
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "Monthly spend from 01-2025 to 03-2025 differs from subscriptions in 2 rows", lines[0])
+	assert.Equal(t, []string{"MONTH", "USER", "SERVICE", "AGGREGATED", "ACTUAL"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"02-2025", testUsers[0], "Netflix", "401", "400"}, strings.Fields(lines[3]))
+	assert.Equal(t, []string{"03-2025", testUsers[1], "Okko", "0", "300"}, strings.Fields(lines[4]))
+
+	// Период проверяется до обращения к базе, ошибки хранилища возвращаются
+	_, err = cli.CheckSpend(ctx, &buf, store, "03-2025", "01-2025")
+	assert.Error(t, err)
+	_, err = cli.CheckSpend(ctx, &buf, store, "2025-01", "03-2025")
+	assert.Error(t, err)
+	store.err = postgres.ErrSpendNotBuilt
+	_, err = cli.CheckSpend(ctx, &buf, store, "01-2025", "03-2025")
+	assert.True(t, errors.Is(err, postgres.ErrSpendNotBuilt))
+}
+
+// TestMonthlySpendIntegration проверяет построение агрегата, чтение сводок из него,
+// инкрементальное обновление после изменений и проверку согласованности
+func TestMonthlySpendIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := postgres.NewPostgresDB(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, postgres.RunMigrations(db))
+	_, err = db.Exec("INSERT INTO users (id) VALUES ($1), ($2)", testUsers[0], testUsers[1])
+	require.NoError(t, err)
+
+	repo := postgres.NewSubscriptionRepository(db)
+	spend := postgres.NewSpendRepository(db)
+	until := time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+	summary := func(userID, serviceName *string, from, to string) int {
+		t.Helper()
+		total, err := repo.GetSubscriptionSummary(ctx, models.SubscriptionFilter{
+			Period:  models.Period{StartDate: from, FinishDate: to},
+			Filters: models.Filters{UserID: userID, ServiceName: serviceName},
+		})
+		require.NoError(t, err)
+		return total
+	}
+	owner, member := &testUsers[0], &testUsers[1]
+	netflix := "Netflix"
+
+	expectConsistent := func() {
+		t.Helper()
+		mismatches, err := spend.Check(ctx, spendMonth(time.January), until)
+		require.NoError(t, err)
+		assert.Empty(t, mismatches)
+	}
+	pending := func() int {
+		t.Helper()
+		var count int
+		require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM monthly_spend_dirty"))
+		return count
+	}
+
+	// Netflix за 800 делится поровну с участником, Okko стоит 300 в марте и мае, апрель на паузе
+	netflixID, err := repo.Create(ctx, models.Subscription{
+		ServiceName: "Netflix", Price: 800, UserID: testUsers[0], StartDate: "01-2025",
+		BillingCycle: models.BillingMonthly, Status: models.StatusActive,
+	})
+	require.NoError(t, err)
+	okkoID, err := repo.Create(ctx, models.Subscription{
+		ServiceName: "Okko", Price: 300, UserID: testUsers[0], StartDate: "03-2025", FinishDate: "06-2025",
+		BillingCycle: models.BillingMonthly, Status: models.StatusActive,
+	})
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO subscription_members (subscription_id, user_id, share_weight) VALUES ($1, $2, 1)", netflixID, testUsers[1])
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO subscription_pauses (subscription_id, paused_from, resumed_from) VALUES ($1, '2025-04-01', '2025-05-01')", okkoID)
+	require.NoError(t, err)
+
+	// До построения агрегата сводки считаются по подпискам, а проверка невозможна
+	assert.Equal(t, 5400, summary(owner, nil, "01-2025", "12-2025"))
+	_, err = spend.Check(ctx, spendMonth(time.January), until)
+	assert.True(t, errors.Is(err, postgres.ErrSpendNotBuilt))
+
+	// Первое обновление строит агрегат
+	refresh, err := spend.Refresh(ctx, until)
+	require.NoError(t, err)
+	assert.True(t, refresh.Rebuilt)
+	assert.Zero(t, pending())
+	assert.Equal(t, 5400, summary(owner, nil, "01-2025", "12-2025"))
+	assert.Equal(t, 4800, summary(member, nil, "01-2025", "12-2025"))
+	assert.Equal(t, 10200, summary(nil, nil, "01-2025", "12-2025"))
+	assert.Equal(t, 9600, summary(nil, &netflix, "01-2025", "12-2025"))
+	expectConsistent()
+
+	// Сводка читается из агрегата: искаженная строка видна в сводке и в проверке
+	_, err = db.Exec("UPDATE monthly_spend SET amount = amount + 1 WHERE user_id = $1 AND service_name = 'Netflix' AND month = '2025-02-01'", testUsers[0])
+	require.NoError(t, err)
+	assert.Equal(t, 5401, summary(owner, nil, "01-2025", "12-2025"))
+	mismatches, err := spend.Check(ctx, spendMonth(time.January), until)
+	require.NoError(t, err)
+	require.Len(t, mismatches, 1)
+	assert.Equal(t, testUsers[0], mismatches[0].UserID)
+	assert.Equal(t, "Netflix", mismatches[0].ServiceName)
+	assert.Equal(t, "02-2025", mismatches[0].Month.Format("01-2006"))
+	assert.Equal(t, 401, mismatches[0].Aggregated)
+	assert.Equal(t, 400, mismatches[0].Actual)
+
+	// Месяцы за горизонтом и фильтры без измерения в агрегате считаются по подпискам
+	assert.Equal(t, 400, summary(owner, nil, "01-2027", "01-2027"))
+	category := "video"
+	total, err := repo.GetSubscriptionSummary(ctx, models.SubscriptionFilter{
+		Period:  models.Period{StartDate: "01-2025", FinishDate: "12-2025"},
+		Filters: models.Filters{UserID: owner, Category: &category},
+	})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	// Перестроение исправляет расхождение
+	rows, err := spend.Rebuild(ctx, until)
+	require.NoError(t, err)
+	assert.Positive(t, rows)
+	assert.Equal(t, 5400, summary(owner, nil, "01-2025", "12-2025"))
+	expectConsistent()
+
+	// Изменения участников и цены отмечают затронутых пользователей; до обновления
+	// их сводки считаются по подпискам и сразу отражают изменения
+	_, err = db.Exec("INSERT INTO subscription_members (subscription_id, user_id, share_weight) VALUES ($1, $2, 1)", okkoID, testUsers[1])
+	require.NoError(t, err)
+	price := 1000
+	require.NoError(t, repo.Update(ctx, netflixID, models.UpdateSubscription{Price: &price}))
+	assert.Positive(t, pending())
+	assert.Equal(t, 6300, summary(owner, nil, "01-2025", "12-2025"))
+	assert.Equal(t, 6300, summary(member, nil, "01-2025", "12-2025"))
+
+	refresh, err = spend.Refresh(ctx, until)
+	require.NoError(t, err)
+	assert.False(t, refresh.Rebuilt)
+	assert.Equal(t, 4, refresh.Refreshed)
+	assert.Zero(t, pending())
+	assert.Equal(t, 6300, summary(owner, nil, "01-2025", "12-2025"))
+	assert.Equal(t, 6300, summary(member, nil, "01-2025", "12-2025"))
+	expectConsistent()
+
+	// Смена статуса не влияет на списания и не отмечает пользователей
+	_, err = db.Exec("UPDATE subscriptions SET status = 'paused' WHERE id = $1", netflixID)
+	require.NoError(t, err)
+	assert.Zero(t, pending())
+
+	// Удаление подписки удаляет ее строки вместе с участниками и паузами
+	require.NoError(t, repo.Delete(ctx, okkoID))
+	_, err = spend.Refresh(ctx, until)
+	require.NoError(t, err)
+	assert.Equal(t, 6000, summary(member, nil, "01-2025", "12-2025"))
+	var okkoRows int
+	require.NoError(t, db.Get(&okkoRows, "SELECT COUNT(*) FROM monthly_spend WHERE service_name = 'Okko'"))
+	assert.Zero(t, okkoRows)
+	expectConsistent()
+
+	// Сдвиг горизонта дописывает новые месяцы
+	later := until.AddDate(0, 6, 0)
+	refresh, err = spend.Refresh(ctx, later)
+	require.NoError(t, err)
+	assert.Equal(t, 6, refresh.Months)
+	_, err = db.Exec("UPDATE monthly_spend SET amount = 0 WHERE month = '2027-06-01'")
+	require.NoError(t, err)
+	assert.Equal(t, 2500, summary(owner, nil, "01-2027", "06-2027"))
+	mismatches, err = spend.Check(ctx, spendMonth(time.January), later)
+	require.NoError(t, err)
+	assert.Len(t, mismatches, 2)
+
+	// Оплата организацией отмечает участников: подписка уходит из агрегата и из сводок без
+	// организации, но остается в сводке организации
+	_, err = db.Exec("INSERT INTO organizations (id, name) VALUES (1, 'ACME')")
+	require.NoError(t, err)
+	_, err = db.Exec("UPDATE subscriptions SET organization_id = 1 WHERE id = $1", netflixID)
+	require.NoError(t, err)
+	assert.Positive(t, pending())
+	assert.Zero(t, summary(owner, nil, "01-2025", "12-2025"))
+	_, err = spend.Refresh(ctx, later)
+	require.NoError(t, err)
+	assert.Zero(t, summary(owner, nil, "01-2025", "12-2025"))
+	assert.Zero(t, summary(member, nil, "01-2025", "12-2025"))
+	expectConsistent()
+	orgID := 1
+	total, err = repo.GetSubscriptionSummary(ctx, models.SubscriptionFilter{
+		Period:  models.Period{StartDate: "01-2025", FinishDate: "12-2025"},
+		Filters: models.Filters{OrganizationID: &orgID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 12*1000, total)
+}
@@ -0,0 +1,245 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusSubStore возвращает бессрочную подписку 1 с января 2025 года в заданном статусе
+// и, как хранилище, не дает менять даты отмененных и истекших подписок
+type statusSubStore struct {
+	postgres.SubscriptionStore
+	status string
+}
+
+func (s statusSubStore) GetById(ctx context.Context, subID int) (models.SubscriptionDB, error) {
+	return models.SubscriptionDB{
+		Id: subID, ServiceName: "Netflix", Price: 500, UserID: uuid.MustParse(testUsers[0]),
+		StartDate: monthOf(2025, time.January), Status: s.status,
+	}, nil
+}
+
+func (s statusSubStore) Update(ctx context.Context, subID int, input models.UpdateSubscription) error {
+	terminal := s.status == models.StatusCancelled || s.status == models.StatusExpired
+	if terminal && (input.StartDate != nil || input.FinishDate != nil || input.TrialEnd != nil) {
+		return fmt.Errorf("%w: dates of a %s subscription cannot be changed", postgres.ErrStatusConflict, s.status)
+	}
+	return nil
+}
+
+// statusPauseRepo запоминает новый статус паузы или возобновления
+type statusPauseRepo struct {
+	status string
+}
+
+func (r *statusPauseRepo) Pause(ctx context.Context, subID int, from time.Time, expectedStatus, newStatus string) (models.PauseDB, error) {
+	r.status = newStatus
+	return models.PauseDB{SubscriptionID: subID, PausedFrom: from}, nil
+}
+
+func (r *statusPauseRepo) Resume(ctx context.Context, subID int, from time.Time, expectedStatus, newStatus string) (models.PauseDB, error) {
+	r.status = newStatus
+	return models.PauseDB{SubscriptionID: subID, ResumedFrom: &from}, nil
+}
+
+func (r *statusPauseRepo) SyncStatuses(ctx context.Context, month time.Time) (int, error) {
+	return 0, nil
+}
+
+// TestPauseStatus проверяет, что статус paused ставится только с началом паузы
+func TestPauseStatus(t *testing.T) {
+	now := time.Now()
+	current := now.Format("01-2006")
+	next := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Format("01-2006")
+
+	tests := []struct {
+		name           string
+		status         string
+		action         string
+		from           string
+		expectedStatus int
+		newStatus      string
+	}{
+		{name: "Pause from the current month", status: models.StatusActive, action: "pause", from: current, expectedStatus: http.StatusOK, newStatus: models.StatusPaused},
+		{name: "Pause from a later month", status: models.StatusActive, action: "pause", from: next, expectedStatus: http.StatusOK, newStatus: models.StatusActive},
+		{name: "Trial paused from a later month", status: models.StatusTrial, action: "pause", from: next, expectedStatus: http.StatusOK, newStatus: models.StatusTrial},
+		{name: "Resume from the current month", status: models.StatusPaused, action: "resume", from: current, expectedStatus: http.StatusOK, newStatus: models.StatusActive},
+		{name: "Resume from a later month", status: models.StatusPaused, action: "resume", from: next, expectedStatus: http.StatusOK, newStatus: models.StatusPaused},
+		{name: "Resume of a pause not started yet", status: models.StatusActive, action: "resume", from: current, expectedStatus: http.StatusOK, newStatus: models.StatusActive},
+		{name: "Pause of a cancelled subscription", status: models.StatusCancelled, action: "pause", from: next, expectedStatus: http.StatusConflict},
+		{name: "Resume of an expired subscription", status: models.StatusExpired, action: "resume", from: current, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &statusPauseRepo{}
+			services := &service.Service{
+				PauseStore:        service.NewPauseService(repo, statusSubStore{status: tt.status}, noBudgets{}),
+				OrganizationStore: personalSubscriptions{},
+			}
+			router := handler.NewHandler(services, nil, nil, nil).InitRoutes()
+
+			recorder := serveJSON(router, http.MethodPost, "/subscriptions/1/"+tt.action, map[string]string{"from": tt.from}, "")
+			require.Equal(t, tt.expectedStatus, recorder.Code, recorder.Body.String())
+			assert.Equal(t, tt.newStatus, repo.status)
+		})
+	}
+}
+
+// TestTerminalSubscriptionUpdate проверяет ответ 409 на изменение дат отмененной или истекшей подписки
+func TestTerminalSubscriptionUpdate(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         string
+		payload        interface{}
+		expectedStatus int
+	}{
+		{name: "Finish date of a cancelled subscription", status: models.StatusCancelled, payload: map[string]string{"finish_date": "12-2030"}, expectedStatus: http.StatusConflict},
+		{name: "Trial of an expired subscription", status: models.StatusExpired, payload: map[string]string{"trial_end": "02-2025"}, expectedStatus: http.StatusConflict},
+		{name: "Price of a cancelled subscription", status: models.StatusCancelled, payload: map[string]int{"price": 600}, expectedStatus: http.StatusOK},
+		{name: "Finish date of an active subscription", status: models.StatusActive, payload: map[string]string{"finish_date": "12-2030"}, expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := statusSubStore{status: tt.status}
+			services := &service.Service{
+				SubscriptionStore: service.NewSubscriptionService(store, noBudgets{}, service.NewDuplicateDetector(store, service.DuplicatePolicyReject)),
+				OrganizationStore: personalSubscriptions{},
+			}
+			router := handler.NewHandler(services, nil, nil, nil).InitRoutes()
+
+			recorder := serveJSON(router, http.MethodPut, "/subscriptions/1", tt.payload, "")
+			assert.Equal(t, tt.expectedStatus, recorder.Code, recorder.Body.String())
+		})
+	}
+}
+
+// cancelSubStore возвращает подписку с заданными датами и запоминает переданную смену статуса
+type cancelSubStore struct {
+	postgres.SubscriptionStore
+	start  time.Time
+	finish *time.Time
+	change models.StatusChange
+}
+
+func (s *cancelSubStore) GetById(ctx context.Context, subID int) (models.SubscriptionDB, error) {
+	return models.SubscriptionDB{
+		Id: subID, ServiceName: "Netflix", Price: 500, UserID: uuid.MustParse(testUsers[0]),
+		StartDate: s.start, FinishDate: s.finish, Status: models.StatusActive,
+	}, nil
+}
+
+func (s *cancelSubStore) ChangeStatus(ctx context.Context, subID int, change models.StatusChange) (models.SubscriptionDB, error) {
+	s.change = change
+	return models.SubscriptionDB{Id: subID, Status: change.To}, nil
+}
+
+// TestCancelFinishDate проверяет дату окончания отмененной подписки
+func TestCancelFinishDate(t *testing.T) {
+	now := time.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	next, later := current.AddDate(0, 1, 0), current.AddDate(0, 3, 0)
+
+	tests := []struct {
+		name           string
+		start          time.Time
+		finish         *time.Time
+		expectedFinish *time.Time
+	}{
+		{name: "Started subscription ends after the current month", start: monthOf(2025, time.January), expectedFinish: &next},
+		{name: "Later finish date is moved", start: monthOf(2025, time.January), finish: &later, expectedFinish: &next},
+		{name: "Earlier finish date is kept", start: monthOf(2025, time.January), finish: &current},
+		{name: "Subscription starting next month ends at its start", start: next, expectedFinish: &next},
+		{name: "Future subscription ends at its start", start: later, expectedFinish: &later},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &cancelSubStore{start: tt.start, finish: tt.finish}
+			require.NoError(t, service.NewLifecycleService(store, noBudgets{}).Cancel(context.Background(), 1, models.CancelInput{}))
+			assert.Equal(t, models.StatusCancelled, store.change.To)
+			assert.Equal(t, tt.expectedFinish, store.change.FinishDate)
+			// Дата окончания никогда не раньше даты начала
+			if store.change.FinishDate != nil {
+				assert.False(t, store.change.FinishDate.Before(tt.start))
+			}
+		})
+	}
+}
+
+// TestPauseStatusSyncIntegration проверяет смену статуса, когда запланированная пауза начинается и заканчивается
+func TestPauseStatusSyncIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := setupTestDatabase(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+
+	subs := postgres.NewSubscriptionRepository(db)
+	pauses := postgres.NewPauseRepository(db)
+
+	subID, err := subs.Create(ctx, models.Subscription{
+		ServiceName: "Netflix", Price: 500, UserID: testUsers[0], StartDate: "01-2025",
+		BillingCycle: models.BillingMonthly, Status: models.StatusActive,
+	})
+	require.NoError(t, err)
+
+	status := func() string {
+		t.Helper()
+		sub, err := subs.GetById(ctx, subID)
+		require.NoError(t, err)
+		return sub.Status
+	}
+	sync := func(month time.Time) int {
+		t.Helper()
+		changed, err := pauses.SyncStatuses(ctx, month)
+		require.NoError(t, err)
+		return changed
+	}
+
+	// Пауза с мая запланирована в апреле: статус не меняется до мая
+	_, err = pauses.Pause(ctx, subID, monthOf(2025, time.May), models.StatusActive, models.StatusActive)
+	require.NoError(t, err)
+	assert.Zero(t, sync(monthOf(2025, time.April)))
+	assert.Equal(t, models.StatusActive, status())
+
+	assert.Equal(t, 1, sync(monthOf(2025, time.May)))
+	assert.Equal(t, models.StatusPaused, status())
+	assert.Zero(t, sync(monthOf(2025, time.May)))
+
+	// Возобновление с июля: в июне подписка еще на паузе
+	_, err = pauses.Resume(ctx, subID, monthOf(2025, time.July), models.StatusPaused, models.StatusPaused)
+	require.NoError(t, err)
+	assert.Zero(t, sync(monthOf(2025, time.June)))
+	assert.Equal(t, 1, sync(monthOf(2025, time.July)))
+	assert.Equal(t, models.StatusActive, status())
+
+	// Отмененная подписка не меняет статус и не дает менять даты
+	_, err = subs.ChangeStatus(ctx, subID, models.StatusChange{From: models.StatusActive, To: models.StatusCancelled})
+	require.NoError(t, err)
+	assert.Zero(t, sync(monthOf(2025, time.June)))
+	finish := "08-2025"
+	assert.ErrorIs(t, subs.Update(ctx, subID, models.UpdateSubscription{FinishDate: &finish}), postgres.ErrStatusConflict)
+}
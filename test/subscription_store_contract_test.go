@@ -0,0 +1,636 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/sqlite"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contractOwner и contractMember - пользователи, существующие в каждом хранилище контрактных тестов
+const (
+	contractOwner  = "0b0b3c8e-7a51-4d0c-9a55-1f3e2d4c5b6a"
+	contractMember = "5e6f7a8b-9c0d-4e1f-8a2b-3c4d5e6f7a8b"
+)
+
+// storeFixture - хранилище подписок под контрактным тестом и база для подготовки данных,
+// которые хранилище не создает само (паузы, участники)
+type storeFixture struct {
+	store postgres.SubscriptionStore
+	db    *sqlx.DB
+}
+
+// exec выполняет запрос подготовки данных; плейсхолдеры $N понимают и PostgreSQL, и SQLite
+func (f storeFixture) exec(t *testing.T, query string, args ...any) {
+	t.Helper()
+	_, err := f.db.Exec(query, args...)
+	require.NoError(t, err)
+}
+
+// create создает подписку и возвращает ее ID
+func (f storeFixture) create(t *testing.T, sub models.Subscription) int {
+	t.Helper()
+	if sub.BillingCycle == "" {
+		sub.BillingCycle = models.BillingMonthly
+	}
+	if sub.Status == "" {
+		sub.Status = models.StatusActive
+	}
+	id, err := f.store.Create(context.Background(), sub)
+	require.NoError(t, err)
+	return id
+}
+
+// dateOf форматирует необязательную дату для сравнения, пустая строка для nil
+func dateOf(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
+
+// monthOf возвращает первое число месяца в UTC
+func monthOf(year int, month time.Month) time.Time {
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// subscriptionIDs возвращает ID подписок в исходном порядке
+func subscriptionIDs(subs []models.SubscriptionDB) []int {
+	ids := make([]int, 0, len(subs))
+	for _, sub := range subs {
+		ids = append(ids, sub.Id)
+	}
+	return ids
+}
+
+// chargeKey описывает начисление для сравнения: подписка, месяц и сумма
+type chargeKey struct {
+	SubscriptionID int
+	Month          string
+	Amount         int
+}
+
+// chargeKeys приводит начисления к сравнимому виду
+func chargeKeys(charges []models.ChargeDB) []chargeKey {
+	keys := make([]chargeKey, 0, len(charges))
+	for _, charge := range charges {
+		keys = append(keys, chargeKey{charge.SubscriptionID, charge.Month.Format(time.DateOnly), charge.Amount})
+	}
+	return keys
+}
+
+// runSubscriptionStoreContract проверяет поведение, обязательное для любой реализации SubscriptionStore
+// newFixture вызывается в каждом подтесте и возвращает пустое хранилище с пользователями
+// contractOwner и contractMember
+func runSubscriptionStoreContract(t *testing.T, newFixture func(t *testing.T) storeFixture) {
+	ctx := context.Background()
+
+	t.Run("create and get", func(t *testing.T) {
+		f := newFixture(t)
+
+		// Даты MM-YYYY хранятся как первое число месяца, пустые необязательные поля - как NULL
+		id := f.create(t, models.Subscription{
+			ServiceName: "Yandex Plus", Price: 400, UserID: contractOwner,
+			StartDate: "07-2025", FinishDate: "01-2026", Category: "music",
+		})
+		assert.Positive(t, id)
+
+		sub, err := f.store.GetById(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "Yandex Plus", sub.ServiceName)
+		assert.Equal(t, 400, sub.Price)
+		assert.Equal(t, contractOwner, sub.UserID.String())
+		assert.Equal(t, "2025-07-01", sub.StartDate.Format(time.DateOnly))
+		assert.Equal(t, "2026-01-01", dateOf(sub.FinishDate))
+		require.NotNil(t, sub.Category)
+		assert.Equal(t, "music", *sub.Category)
+		assert.Equal(t, models.BillingMonthly, sub.BillingCycle)
+		assert.Equal(t, models.StatusActive, sub.Status)
+		assert.Nil(t, sub.TrialEnd)
+		assert.Nil(t, sub.PausedFrom)
+
+		// Месяц без ведущего нуля разбирается так же, как TO_DATE
+		id = f.create(t, models.Subscription{ServiceName: "Okko", Price: 200, UserID: contractOwner, StartDate: "3-2025"})
+		sub, err = f.store.GetById(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "2025-03-01", sub.StartDate.Format(time.DateOnly))
+		assert.Nil(t, sub.FinishDate)
+		assert.Nil(t, sub.Category)
+
+		_, err = f.store.GetById(ctx, id+100)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("create errors", func(t *testing.T) {
+		f := newFixture(t)
+
+		_, err := f.store.Create(ctx, models.Subscription{
+			ServiceName: "Okko", Price: 200, UserID: "11111111-1111-1111-1111-111111111111",
+			StartDate: "07-2025", BillingCycle: models.BillingMonthly, Status: models.StatusActive,
+		})
+		assert.ErrorIs(t, err, postgres.ErrUserNotFound)
+
+		_, err = f.store.Create(ctx, models.Subscription{
+			ServiceName: "Okko", Price: 200, UserID: contractOwner,
+			StartDate: "13-2025", BillingCycle: models.BillingMonthly, Status: models.StatusActive,
+		})
+		assert.Error(t, err)
+
+		all, err := f.store.GetAll(ctx, models.SubscriptionListFilter{})
+		require.NoError(t, err)
+		assert.Empty(t, all)
+	})
+
+	t.Run("get all filters", func(t *testing.T) {
+		f := newFixture(t)
+
+		shared := f.create(t, models.Subscription{ServiceName: "Netflix", Price: 900, UserID: contractOwner, StartDate: "01-2025"})
+		trial := f.create(t, models.Subscription{
+			ServiceName: "Spotify", Price: 300, UserID: contractOwner, StartDate: "01-2025",
+			TrialEnd: "02-2025", Status: models.StatusTrial,
+		})
+		own := f.create(t, models.Subscription{ServiceName: "Okko", Price: 200, UserID: contractMember, StartDate: "01-2025"})
+		f.exec(t, "INSERT INTO subscription_members (subscription_id, user_id, share_weight) VALUES ($1, $2, $3)", shared, contractMember, 1)
+		f.exec(t, "INSERT INTO subscription_pauses (subscription_id, paused_from) VALUES ($1, $2)", shared, "2025-09-01")
+
+		all, err := f.store.GetAll(ctx, models.SubscriptionListFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []int{shared, trial, own}, subscriptionIDs(all))
+		assert.Equal(t, "2025-09-01", dateOf(all[0].PausedFrom))
+
+		// Участник видит общие подписки; регистр UUID не важен
+		member := strings.ToUpper(contractMember)
+		byUser, err := f.store.GetAll(ctx, models.SubscriptionListFilter{UserID: &member})
+		require.NoError(t, err)
+		assert.Equal(t, []int{shared, own}, subscriptionIDs(byUser))
+
+		status := models.StatusTrial
+		byStatus, err := f.store.GetAll(ctx, models.SubscriptionListFilter{Status: &status})
+		require.NoError(t, err)
+		assert.Equal(t, []int{trial}, subscriptionIDs(byStatus))
+
+		// Личный список не содержит подписок, оплачиваемых организацией
+		f.exec(t, "INSERT INTO organizations (id, name) VALUES ($1, $2)", 1, "ACME")
+		f.exec(t, "UPDATE subscriptions SET organization_id = $1 WHERE id = $2", 1, trial)
+		personal, err := f.store.GetAll(ctx, models.SubscriptionListFilter{Personal: true})
+		require.NoError(t, err)
+		assert.Equal(t, []int{shared, own}, subscriptionIDs(personal))
+		orgID := 1
+		byOrg, err := f.store.GetAll(ctx, models.SubscriptionListFilter{OrganizationID: &orgID})
+		require.NoError(t, err)
+		assert.Equal(t, []int{trial}, subscriptionIDs(byOrg))
+	})
+
+	t.Run("update", func(t *testing.T) {
+		f := newFixture(t)
+
+		afterTrial := 500
+		id := f.create(t, models.Subscription{
+			ServiceName: "Spotify", Price: 300, UserID: contractOwner, StartDate: "01-2025", FinishDate: "01-2026",
+			Category: "music", TrialEnd: "02-2025", PriceAfterTrial: &afterTrial, Status: models.StatusTrial,
+		})
+
+		price, empty, start, cycle := 350, "", "02-2025", models.BillingYearly
+		require.NoError(t, f.store.Update(ctx, id, models.UpdateSubscription{
+			Price: &price, StartDate: &start, Category: &empty, FinishDate: &empty, BillingCycle: &cycle, TrialEnd: &empty,
+		}))
+
+		sub, err := f.store.GetById(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, 350, sub.Price)
+		assert.Equal(t, "2025-02-01", sub.StartDate.Format(time.DateOnly))
+		assert.Equal(t, models.BillingYearly, sub.BillingCycle)
+		assert.Nil(t, sub.Category)
+		assert.Nil(t, sub.FinishDate)
+		// Удаление пробного периода сбрасывает цену после него
+		assert.Nil(t, sub.TrialEnd)
+		assert.Nil(t, sub.PriceAfterTrial)
+
+		assert.EqualError(t, f.store.Update(ctx, id+100, models.UpdateSubscription{Price: &price}), "card not found")
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		f := newFixture(t)
+
+		id := f.create(t, models.Subscription{ServiceName: "Okko", Price: 200, UserID: contractOwner, StartDate: "01-2025"})
+		f.exec(t, "INSERT INTO subscription_pauses (subscription_id, paused_from) VALUES ($1, $2)", id, "2025-03-01")
+
+		require.NoError(t, f.store.Delete(ctx, id))
+		_, err := f.store.GetById(ctx, id)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.EqualError(t, f.store.Delete(ctx, id), "card not found")
+	})
+
+	t.Run("overlaps and duplicates", func(t *testing.T) {
+		f := newFixture(t)
+
+		// Названия сравниваются без учета регистра, в том числе кириллица
+		first := f.create(t, models.Subscription{ServiceName: "Кинопоиск", Price: 300, UserID: contractOwner, StartDate: "01-2025", FinishDate: "07-2025"})
+		second := f.create(t, models.Subscription{ServiceName: "КИНОПОИСК", Price: 300, UserID: contractOwner, StartDate: "09-2025"})
+		f.create(t, models.Subscription{ServiceName: "Кинопоиск", Price: 300, UserID: contractMember, StartDate: "01-2025"})
+
+		overlaps, err := f.store.FindOverlaps(ctx, models.OverlapFilter{UserID: contractOwner, ServiceName: "кинопоиск", StartDate: monthOf(2025, time.June)})
+		require.NoError(t, err)
+		assert.Equal(t, []int{first, second}, subscriptionIDs(overlaps))
+
+		// Дата окончания не входит в период
+		finish := monthOf(2025, time.September)
+		overlaps, err = f.store.FindOverlaps(ctx, models.OverlapFilter{UserID: contractOwner, ServiceName: "кинопоиск", StartDate: monthOf(2025, time.June), FinishDate: &finish})
+		require.NoError(t, err)
+		assert.Equal(t, []int{first}, subscriptionIDs(overlaps))
+
+		overlaps, err = f.store.FindOverlaps(ctx, models.OverlapFilter{UserID: contractOwner, ServiceName: "кинопоиск", StartDate: monthOf(2025, time.June), FinishDate: &finish, ExcludeID: first})
+		require.NoError(t, err)
+		assert.Empty(t, overlaps)
+
+		duplicates, err := f.store.GetDuplicates(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, duplicates)
+
+		third := f.create(t, models.Subscription{ServiceName: "кинопоиск", Price: 300, UserID: contractOwner, StartDate: "10-2025"})
+		duplicates, err = f.store.GetDuplicates(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []int{second, third}, subscriptionIDs(duplicates))
+	})
+
+	t.Run("overlap rejection", func(t *testing.T) {
+		f := newFixture(t)
+
+		first := f.create(t, models.Subscription{ServiceName: "Okko", Price: 300, UserID: contractOwner, StartDate: "01-2025", FinishDate: "07-2025"})
+		second := f.create(t, models.Subscription{ServiceName: "okko", Price: 300, UserID: contractOwner, StartDate: "07-2025", FinishDate: "10-2025", RejectOverlaps: true})
+
+		// Пересекающаяся подписка не сохраняется, событие не пишется
+		_, err := f.store.Create(ctx, models.Subscription{
+			ServiceName: "OKKO", Price: 300, UserID: contractOwner, StartDate: "03-2025",
+			BillingCycle: models.BillingMonthly, Status: models.StatusActive, RejectOverlaps: true,
+		})
+		require.ErrorIs(t, err, postgres.ErrOverlap)
+		assert.Contains(t, err.Error(), fmt.Sprintf("%d, %d", first, second))
+		subs, err := f.store.GetAll(ctx, models.SubscriptionListFilter{})
+		require.NoError(t, err)
+		assert.Len(t, subs, 2)
+		var events int
+		require.NoError(t, f.db.Get(&events, "SELECT COUNT(*) FROM outbox"))
+		assert.Equal(t, 2, events)
+
+		// Изменение, создающее пересечение, откатывается целиком
+		start, price := "06-2025", 500
+		err = f.store.Update(ctx, second, models.UpdateSubscription{StartDate: &start, Price: &price, RejectOverlaps: true})
+		require.ErrorIs(t, err, postgres.ErrOverlap)
+		stored, err := f.store.GetById(ctx, second)
+		require.NoError(t, err)
+		assert.Equal(t, "2025-07-01", dateOf(&stored.StartDate))
+		assert.Equal(t, 300, stored.Price)
+
+		// Без проверки пересечение сохраняется (политика warn)
+		require.NoError(t, f.store.Update(ctx, second, models.UpdateSubscription{StartDate: &start}))
+	})
+
+	t.Run("terminal status", func(t *testing.T) {
+		f := newFixture(t)
+
+		id := f.create(t, models.Subscription{ServiceName: "Netflix", Price: 500, UserID: contractOwner, StartDate: "01-2025", FinishDate: "07-2025", Status: models.StatusCancelled})
+
+		// Даты и пробный период отмененной подписки не меняются, цена меняется
+		finish, trialEnd, price := "12-2025", "02-2025", 600
+		require.ErrorIs(t, f.store.Update(ctx, id, models.UpdateSubscription{FinishDate: &finish}), postgres.ErrStatusConflict)
+		require.ErrorIs(t, f.store.Update(ctx, id, models.UpdateSubscription{TrialEnd: &trialEnd, Price: &price}), postgres.ErrStatusConflict)
+		require.NoError(t, f.store.Update(ctx, id, models.UpdateSubscription{Price: &price}))
+
+		stored, err := f.store.GetById(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "2025-07-01", dateOf(stored.FinishDate))
+		assert.Nil(t, stored.TrialEnd)
+		assert.Equal(t, 600, stored.Price)
+
+		_, err = f.store.ChangeStatus(ctx, id, models.StatusChange{From: models.StatusActive, To: models.StatusPaused})
+		assert.ErrorIs(t, err, postgres.ErrStatusConflict)
+		assert.ErrorIs(t, f.store.Update(ctx, id+1, models.UpdateSubscription{FinishDate: &finish}), postgres.ErrSubscriptionNotFound)
+	})
+
+	t.Run("fixed amounts", func(t *testing.T) {
+		f := newFixture(t)
+
+		id := f.create(t, models.Subscription{ServiceName: "Netflix", Price: 500, UserID: contractOwner, StartDate: "01-2025"})
+		f.exec(t, "INSERT INTO subscription_members (subscription_id, user_id, fixed_amount) VALUES ($1, $2, $3)", id, contractMember, 300)
+
+		// Цена ниже фиксированных сумм участников не сохраняется
+		price := 200
+		require.ErrorIs(t, f.store.Update(ctx, id, models.UpdateSubscription{Price: &price}), postgres.ErrFixedAmounts)
+		trialEnd, afterTrial := "01-2025", 250
+		require.ErrorIs(t, f.store.Update(ctx, id, models.UpdateSubscription{TrialEnd: &trialEnd, PriceAfterTrial: &afterTrial}), postgres.ErrFixedAmounts)
+		stored, err := f.store.GetById(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, 500, stored.Price)
+		assert.Nil(t, stored.TrialEnd)
+
+		price = 300
+		require.NoError(t, f.store.Update(ctx, id, models.UpdateSubscription{Price: &price}))
+
+		// Сохраненные ранее суммы больше списания уменьшаются, доля владельца не отрицательна
+		f.exec(t, "UPDATE subscriptions SET price = $1 WHERE id = $2", 200, id)
+		owner, member := contractOwner, contractMember
+		for user, expected := range map[*string]int{nil: 200, &owner: 0, &member: 200} {
+			total, err := f.store.GetSubscriptionSummary(ctx, models.SubscriptionFilter{
+				Period:  models.Period{StartDate: "01-2025", FinishDate: "01-2025"},
+				Filters: models.Filters{UserID: user},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, expected, total)
+		}
+	})
+
+	t.Run("summary and charges", func(t *testing.T) {
+		f := newFixture(t)
+
+		// Ежемесячная с датой окончания: январь-март
+		monthly := f.create(t, models.Subscription{ServiceName: "Okko", Price: 300, UserID: contractOwner, StartDate: "01-2025", FinishDate: "04-2025", Category: "video"})
+		// Ежеквартальная, общая с участником с весом 2: февраль и май
+		quarterly := f.create(t, models.Subscription{ServiceName: "Yandex Plus", Price: 1000, UserID: contractOwner, StartDate: "02-2025", BillingCycle: models.BillingQuarterly})
+		f.exec(t, "INSERT INTO subscription_members (subscription_id, user_id, share_weight) VALUES ($1, $2, $3)", quarterly, contractMember, 2)
+		// Пробный период до февраля, затем 200 в месяц
+		afterTrial := 200
+		trial := f.create(t, models.Subscription{
+			ServiceName: "Spotify", Price: 100, UserID: contractOwner, StartDate: "01-2025",
+			TrialEnd: "02-2025", PriceAfterTrial: &afterTrial, Status: models.StatusTrial,
+		})
+		// Пауза с марта по апрель включительно
+		paused := f.create(t, models.Subscription{ServiceName: "Netflix", Price: 500, UserID: contractMember, StartDate: "01-2025"})
+		f.exec(t, "INSERT INTO subscription_pauses (subscription_id, paused_from, resumed_from) VALUES ($1, $2, $3)", paused, "2025-03-01", "2025-05-01")
+		// Ежегодная, списанная в декабре, в период не попадает
+		f.create(t, models.Subscription{ServiceName: "iCloud", Price: 1200, UserID: contractOwner, StartDate: "12-2024", BillingCycle: models.BillingYearly})
+
+		summary := func(filters models.Filters, from, to string) int {
+			t.Helper()
+			total, err := f.store.GetSubscriptionSummary(ctx, models.SubscriptionFilter{Period: models.Period{StartDate: from, FinishDate: to}, Filters: filters})
+			require.NoError(t, err)
+			return total
+		}
+
+		assert.Equal(t, 900+2000+800+2000, summary(models.Filters{}, "01-2025", "06-2025"))
+
+		// Доли: участник платит 1000*2/3 = 666, владельцу остается 334 с остатком от округления
+		owner, member := contractOwner, contractMember
+		assert.Equal(t, 900+2*334+800, summary(models.Filters{UserID: &owner}, "01-2025", "06-2025"))
+		assert.Equal(t, 2*666+2000, summary(models.Filters{UserID: &member}, "01-2025", "06-2025"))
+
+		service, category, status := "Netflix", "video", models.StatusTrial
+		assert.Equal(t, 2000, summary(models.Filters{ServiceName: &service}, "01-2025", "06-2025"))
+		assert.Equal(t, 900, summary(models.Filters{Category: &category}, "01-2025", "06-2025"))
+		assert.Equal(t, 800, summary(models.Filters{Status: &status}, "01-2025", "06-2025"))
+		assert.Equal(t, 1200, summary(models.Filters{}, "12-2024", "12-2024"))
+		assert.Zero(t, summary(models.Filters{}, "06-2025", "01-2025"))
+
+		_, err := f.store.GetSubscriptionSummary(ctx, models.SubscriptionFilter{Period: models.Period{StartDate: "13-2025", FinishDate: "06-2025"}})
+		assert.Error(t, err)
+
+		charges, err := f.store.GetCharges(ctx, models.ChargeFilter{From: monthOf(2025, time.January), To: monthOf(2025, time.March)})
+		require.NoError(t, err)
+		assert.Equal(t, []chargeKey{
+			{monthly, "2025-01-01", 300}, {paused, "2025-01-01", 500},
+			{monthly, "2025-02-01", 300}, {quarterly, "2025-02-01", 1000}, {paused, "2025-02-01", 500},
+			{monthly, "2025-03-01", 300}, {trial, "2025-03-01", 200},
+		}, chargeKeys(charges))
+
+		charges, err = f.store.GetCharges(ctx, models.ChargeFilter{UserID: &member, From: monthOf(2025, time.January), To: monthOf(2025, time.May)})
+		require.NoError(t, err)
+		assert.Equal(t, []chargeKey{
+			{paused, "2025-01-01", 500},
+			{quarterly, "2025-02-01", 666}, {paused, "2025-02-01", 500},
+			{quarterly, "2025-05-01", 666}, {paused, "2025-05-01", 500},
+		}, chargeKeys(charges))
+		assert.Equal(t, contractMember, charges[0].UserID)
+	})
+
+	t.Run("open-ended total cost", func(t *testing.T) {
+		f := newFixture(t)
+
+		// Подписка без даты окончания бессрочная, а не месячная (см. CHANGELOG.md)
+		id := f.create(t, models.Subscription{ServiceName: "Yandex Plus", Price: 400, UserID: contractOwner, StartDate: "01-2025"})
+		sub, err := f.store.GetById(ctx, id)
+		require.NoError(t, err)
+		assert.Nil(t, sub.FinishDate)
+
+		// Сводка - сумма списаний по месяцам, а не цен пересекающих период подписок
+		total := func(from, to string) int {
+			t.Helper()
+			total, err := f.store.GetSubscriptionSummary(ctx, models.SubscriptionFilter{Period: models.Period{StartDate: from, FinishDate: to}})
+			require.NoError(t, err)
+			return total
+		}
+		assert.Equal(t, 400, total("01-2025", "01-2025"))
+		assert.Equal(t, 10*400, total("01-2025", "10-2025"))
+		assert.Equal(t, 3*400, total("10-2026", "12-2026"))
+
+		// Явная дата окончания не включительна
+		f.create(t, models.Subscription{ServiceName: "Okko", Price: 200, UserID: contractOwner, StartDate: "07-2025", FinishDate: "08-2025"})
+		assert.Equal(t, 10*400+200, total("01-2025", "10-2025"))
+	})
+
+	t.Run("trials", func(t *testing.T) {
+		f := newFixture(t)
+
+		afterTrial := 200
+		ending := f.create(t, models.Subscription{
+			ServiceName: "Spotify", Price: 100, UserID: contractOwner, StartDate: "01-2025",
+			TrialEnd: "03-2025", PriceAfterTrial: &afterTrial, Status: models.StatusTrial,
+		})
+		long := f.create(t, models.Subscription{ServiceName: "Okko", Price: 100, UserID: contractOwner, StartDate: "01-2025", TrialEnd: "06-2025", Status: models.StatusTrial})
+
+		// Бесплатные месяцы не начисляются, после пробного периода действует price_after_trial,
+		// а без нее - обычная цена
+		summary := func(from, to string) int {
+			t.Helper()
+			total, err := f.store.GetSubscriptionSummary(ctx, models.SubscriptionFilter{Period: models.Period{StartDate: from, FinishDate: to}})
+			require.NoError(t, err)
+			return total
+		}
+		assert.Zero(t, summary("01-2025", "03-2025"))
+		assert.Equal(t, 3*200, summary("01-2025", "06-2025"))
+		assert.Equal(t, 200+100, summary("07-2025", "07-2025"))
+
+		charges, err := f.store.GetCharges(ctx, models.ChargeFilter{From: monthOf(2025, time.January), To: monthOf(2025, time.July)})
+		require.NoError(t, err)
+		assert.Equal(t, []chargeKey{
+			{ending, "2025-04-01", 200}, {ending, "2025-05-01", 200}, {ending, "2025-06-01", 200},
+			{ending, "2025-07-01", 200}, {long, "2025-07-01", 100},
+		}, chargeKeys(charges))
+
+		trials, err := f.store.GetEndingTrials(ctx, monthOf(2025, time.March), monthOf(2025, time.May))
+		require.NoError(t, err)
+		assert.Equal(t, []int{ending}, subscriptionIDs(trials))
+
+		converted, err := f.store.ConvertEndedTrials(ctx, monthOf(2025, time.April))
+		require.NoError(t, err)
+		require.Equal(t, []int{ending}, subscriptionIDs(converted))
+		assert.Equal(t, models.StatusActive, converted[0].Status)
+		assert.NotNil(t, converted[0].TrialConvertedAt)
+
+		// Конвертация происходит один раз
+		converted, err = f.store.ConvertEndedTrials(ctx, monthOf(2025, time.April))
+		require.NoError(t, err)
+		assert.Empty(t, converted)
+
+		// Конвертация меняет только статус, начисления остаются прежними
+		assert.Equal(t, 3*200, summary("01-2025", "06-2025"))
+
+		trials, err = f.store.GetEndingTrials(ctx, monthOf(2025, time.March), monthOf(2025, time.May))
+		require.NoError(t, err)
+		assert.Empty(t, trials)
+	})
+
+	t.Run("status changes", func(t *testing.T) {
+		f := newFixture(t)
+
+		cancelled := f.create(t, models.Subscription{ServiceName: "Okko", Price: 200, UserID: contractOwner, StartDate: "01-2025"})
+		ending := f.create(t, models.Subscription{ServiceName: "Netflix", Price: 500, UserID: contractOwner, StartDate: "01-2025", FinishDate: "03-2025"})
+		f.create(t, models.Subscription{ServiceName: "Spotify", Price: 300, UserID: contractOwner, StartDate: "01-2025"})
+
+		cancelledAt, finish, reason := time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC), monthOf(2025, time.April), "too expensive"
+		change := models.StatusChange{
+			From: models.StatusActive, To: models.StatusCancelled, CancelledAt: &cancelledAt,
+			Reason: &reason, FinishDate: &finish, EventType: models.EventSubscriptionCancelled,
+		}
+		sub, err := f.store.ChangeStatus(ctx, cancelled, change)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusCancelled, sub.Status)
+		assert.Equal(t, "2025-03-15", dateOf(sub.CancelledAt))
+		assert.Equal(t, "2025-04-01", dateOf(sub.FinishDate))
+		require.NotNil(t, sub.CancellationReason)
+		assert.Equal(t, reason, *sub.CancellationReason)
+
+		_, err = f.store.ChangeStatus(ctx, cancelled, change)
+		assert.ErrorIs(t, err, postgres.ErrStatusConflict)
+
+		// Отмененная подписка сохраняет свой статус после окончания
+		expired, err := f.store.ExpireEnded(ctx, monthOf(2025, time.April))
+		require.NoError(t, err)
+		require.Equal(t, []int{ending}, subscriptionIDs(expired))
+		assert.Equal(t, models.StatusExpired, expired[0].Status)
+
+		// Событие об истечении пишется один раз, повторный запуск ничего не меняет
+		var events []string
+		require.NoError(t, f.db.Select(&events, "SELECT aggregate_id FROM outbox WHERE event_type = $1", models.EventSubscriptionExpired))
+		assert.Equal(t, []string{strconv.Itoa(ending)}, events)
+		expired, err = f.store.ExpireEnded(ctx, monthOf(2025, time.May))
+		require.NoError(t, err)
+		assert.Empty(t, expired)
+
+		counts, err := f.store.CountByStatus(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []models.StatusCountDB{
+			{Status: models.StatusActive, Count: 1},
+			{Status: models.StatusCancelled, Count: 1},
+			{Status: models.StatusExpired, Count: 1},
+		}, counts)
+	})
+
+	t.Run("organization subscriptions", func(t *testing.T) {
+		f := newFixture(t)
+
+		// Личная и оплачиваемая организацией подписки одного пользователя на один сервис
+		personal := f.create(t, models.Subscription{
+			ServiceName: "Netflix", Price: 500, UserID: contractOwner, StartDate: "01-2025", TrialEnd: "02-2025", Status: models.StatusTrial,
+		})
+		paid := f.create(t, models.Subscription{
+			ServiceName: "Netflix", Price: 700, UserID: contractOwner, StartDate: "01-2025", TrialEnd: "02-2025", Status: models.StatusTrial,
+		})
+		f.exec(t, "INSERT INTO organizations (id, name) VALUES ($1, $2)", 1, "ACME")
+		f.exec(t, "UPDATE subscriptions SET organization_id = $1 WHERE id = $2", 1, paid)
+
+		// Запросы без организации видят только личные подписки
+		overlaps, err := f.store.FindOverlaps(ctx, models.OverlapFilter{UserID: contractOwner, ServiceName: "Netflix", StartDate: monthOf(2025, time.March)})
+		require.NoError(t, err)
+		assert.Equal(t, []int{personal}, subscriptionIDs(overlaps))
+
+		duplicates, err := f.store.GetDuplicates(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, duplicates)
+
+		trials, err := f.store.GetEndingTrials(ctx, monthOf(2025, time.February), monthOf(2025, time.February))
+		require.NoError(t, err)
+		assert.Equal(t, []int{personal}, subscriptionIDs(trials))
+
+		owner := contractOwner
+		for _, filter := range []models.ChargeFilter{{}, {UserID: &owner}} {
+			filter.From, filter.To = monthOf(2025, time.March), monthOf(2025, time.March)
+			charges, err := f.store.GetCharges(ctx, filter)
+			require.NoError(t, err)
+			assert.Equal(t, []chargeKey{{personal, "2025-03-01", 500}}, chargeKeys(charges))
+		}
+
+		summary := func(filters models.Filters) int {
+			t.Helper()
+			total, err := f.store.GetSubscriptionSummary(ctx, models.SubscriptionFilter{Period: models.Period{StartDate: "03-2025", FinishDate: "03-2025"}, Filters: filters})
+			require.NoError(t, err)
+			return total
+		}
+		assert.Equal(t, 500, summary(models.Filters{}))
+		assert.Equal(t, 500, summary(models.Filters{UserID: &owner}))
+		orgID := 1
+		assert.Equal(t, 700, summary(models.Filters{OrganizationID: &orgID}))
+	})
+}
+
+// newSQLiteFixture открывает базу SQLite по пути, применяет миграции и создает пользователей
+func newSQLiteFixture(t *testing.T, path string) storeFixture {
+	db, err := sqlite.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, sqlite.RunMigrations(db))
+
+	f := storeFixture{store: sqlite.NewSubscriptionRepository(db), db: db}
+	f.exec(t, "INSERT INTO users (id) VALUES ($1), ($2)", contractOwner, contractMember)
+	return f
+}
+
+// TestSQLiteSubscriptionStoreContract проверяет хранилище SQLite в файле
+func TestSQLiteSubscriptionStoreContract(t *testing.T) {
+	runSubscriptionStoreContract(t, func(t *testing.T) storeFixture {
+		return newSQLiteFixture(t, filepath.Join(t.TempDir(), "subscriptions.db"))
+	})
+}
+
+// TestInMemorySubscriptionStoreContract проверяет хранилище SQLite в памяти
+func TestInMemorySubscriptionStoreContract(t *testing.T) {
+	runSubscriptionStoreContract(t, func(t *testing.T) storeFixture {
+		return newSQLiteFixture(t, sqlite.MemoryPath)
+	})
+}
+
+// TestPostgresSubscriptionStoreContract проверяет хранилище PostgreSQL; контейнер общий,
+// перед каждым подтестом таблицы очищаются
+func TestPostgresSubscriptionStoreContract(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	dbConfig, cleanup, err := setupTestContainer(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	db, err := postgres.NewPostgresDB(dbConfig)
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, postgres.RunMigrations(db))
+
+	runSubscriptionStoreContract(t, func(t *testing.T) storeFixture {
+		f := storeFixture{store: postgres.NewSubscriptionRepository(db), db: db}
+		f.exec(t, "TRUNCATE subscriptions, users, organizations, outbox RESTART IDENTITY CASCADE")
+		f.exec(t, "INSERT INTO users (id) VALUES ($1), ($2)", contractOwner, contractMember)
+		return f
+	})
+}
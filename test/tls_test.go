@@ -0,0 +1,242 @@
+package test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCert - самоподписанный сертификат для тестов TLS
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+// newTestCert создает сертификат; при наличии issuer сертификат подписывается им
+func newTestCert(t *testing.T, serial int64, issuer *testCert) testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:         issuer == nil,
+
+		BasicConstraintsValid: true,
+	}
+
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return testCert{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// write сохраняет сертификат и ключ в файлы
+func (c testCert) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, c.pem, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+}
+
+// freePort возвращает свободный TCP-порт
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+}
+
+// startServer запускает сервер и останавливает его по окончании теста
+func startServer(t *testing.T, cfg server.Config) {
+	t.Helper()
+
+	srv, err := server.NewServer(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- srv.Run() }()
+
+	// Ждем, пока сервер начнет принимать соединения
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", net.JoinHostPort(cfg.Host, cfg.Port))
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		assert.NoError(t, srv.Shutdown(ctx))
+		assert.NoError(t, <-done)
+	})
+}
+
+// servedSerial возвращает серийный номер сертификата, который предъявляет сервер
+func servedSerial(t *testing.T, addr string, clientCert *tls.Certificate) (int64, error) {
+	t.Helper()
+
+	cfg := &tls.Config{InsecureSkipVerify: true}
+	if clientCert != nil {
+		cfg.Certificates = []tls.Certificate{*clientCert}
+	}
+	conn, err := tls.Dial("tcp", addr, cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	// Ошибка проверки клиентского сертификата приходит после рукопожатия
+	if err := conn.Handshake(); err != nil {
+		return 0, err
+	}
+	if _, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+		return 0, err
+	}
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		return 0, err
+	}
+
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+// testServerConfig возвращает конфигурацию сервера на свободном порту
+func testServerConfig(t *testing.T) server.Config {
+	return server.Config{
+		Host:              "127.0.0.1",
+		Port:              freePort(t),
+		ReadTimeout:       5 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      5 * time.Second,
+		IdleTimeout:       5 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+	}
+}
+
+// TestServerPlainHTTP проверяет режим без TLS
+func TestServerPlainHTTP(t *testing.T) {
+	cfg := testServerConfig(t)
+	startServer(t, cfg)
+
+	resp, err := http.Get("http://" + net.JoinHostPort(cfg.Host, cfg.Port) + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+// TestServerTLSReload проверяет подхват обновленного сертификата без перезапуска
+func TestServerTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	newTestCert(t, 1, nil).write(t, certFile, keyFile)
+
+	cfg := testServerConfig(t)
+	cfg.TLS = server.TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, ReloadInterval: 20 * time.Millisecond}
+	startServer(t, cfg)
+	addr := net.JoinHostPort(cfg.Host, cfg.Port)
+
+	serial, err := servedSerial(t, addr, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), serial)
+
+	// Ротация: новый сертификат с более поздним временем изменения
+	newTestCert(t, 2, nil).write(t, certFile, keyFile)
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(certFile, later, later))
+	require.NoError(t, os.Chtimes(keyFile, later, later))
+
+	assert.Eventually(t, func() bool {
+		serial, err := servedSerial(t, addr, nil)
+		return err == nil && serial == 2
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+// TestServerMutualTLS проверяет обязательный клиентский сертификат, подписанный заданным CA
+func TestServerMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+
+	ca := newTestCert(t, 10, nil)
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0o600))
+	newTestCert(t, 1, &ca).write(t, certFile, keyFile)
+
+	cfg := testServerConfig(t)
+	cfg.TLS = server.TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile}
+	startServer(t, cfg)
+	addr := net.JoinHostPort(cfg.Host, cfg.Port)
+
+	// Без клиентского сертификата соединение отклоняется
+	_, err := servedSerial(t, addr, nil)
+	assert.Error(t, err)
+
+	// Сертификат, подписанный другим CA, отклоняется
+	stranger := newTestCert(t, 20, nil)
+	strangerCert := tls.Certificate{Certificate: [][]byte{stranger.cert.Raw}, PrivateKey: stranger.key}
+	_, err = servedSerial(t, addr, &strangerCert)
+	assert.Error(t, err)
+
+	client := newTestCert(t, 30, &ca)
+	clientCert := tls.Certificate{Certificate: [][]byte{client.cert.Raw}, PrivateKey: client.key}
+	_, err = servedSerial(t, addr, &clientCert)
+	assert.NoError(t, err)
+}
+
+// TestServerRedirect проверяет перенаправление HTTP на HTTPS
+func TestServerRedirect(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	newTestCert(t, 1, nil).write(t, certFile, keyFile)
+
+	cfg := testServerConfig(t)
+	cfg.TLS = server.TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, RedirectPort: freePort(t)}
+	startServer(t, cfg)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		var err error
+		resp, err = client.Get("http://localhost:" + cfg.TLS.RedirectPort + "/subscriptions?status=active")
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusPermanentRedirect, resp.StatusCode)
+	assert.Equal(t, "https://localhost:"+cfg.Port+"/subscriptions?status=active", resp.Header.Get("Location"))
+}
@@ -0,0 +1,145 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spanRecorderOnce sync.Once
+	spanRecorder     *tracetest.InMemoryExporter
+)
+
+// recordSpans устанавливает глобальный провайдер с записью спанов в память
+// Глобальный провайдер можно установить только один раз, поэтому он общий для всех тестов
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	spanRecorderOnce.Do(func() {
+		spanRecorder = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spanRecorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+	spanRecorder.Reset()
+	return spanRecorder
+}
+
+// findSpan возвращает завершенный спан по имени
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	require.Failf(t, "span not found", "%s", name)
+	return tracetest.SpanStub{}
+}
+
+// fakeSubscriptionStore возвращает подписку с ID 1, для остальных - ошибку
+type fakeSubscriptionStore struct {
+	postgres.SubscriptionStore
+}
+
+func (fakeSubscriptionStore) GetById(ctx context.Context, subID int) (models.SubscriptionDB, error) {
+	if subID != 1 {
+		return models.SubscriptionDB{}, errors.New("card not found")
+	}
+	return models.SubscriptionDB{Id: 1, ServiceName: "TEST", Status: models.StatusActive}, nil
+}
+
+// TestTracingSpans проверяет вложенность спанов маршрута и сервиса и продолжение входящей трассы
+func TestTracingSpans(t *testing.T) {
+	spans := recordSpans(t)
+
+	services := &service.Service{
+		SubscriptionStore: service.NewSubscriptionService(fakeSubscriptionStore{}, nil, nil),
+		OrganizationStore: personalSubscriptions{},
+	}
+	router := handler.NewHandler(services, nil, nil, nil).InitRoutes()
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/subscriptions/1", nil)
+	req.Header.Set("traceparent", fmt.Sprintf("00-%s-00f067aa0ba902b7-01", traceID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	recorded := spans.GetSpans()
+	route := findSpan(t, recorded, "GET /subscriptions/:subscription_id")
+	method := findSpan(t, recorded, "SubscriptionService.GetById")
+
+	// Трасса продолжает входящий traceparent, спан сервиса вложен в спан маршрута
+	assert.Equal(t, traceID, route.SpanContext.TraceID().String())
+	assert.Equal(t, route.SpanContext.SpanID(), method.Parent.SpanID())
+
+	// Ошибка сервиса отмечается в спане
+	spans.Reset()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/2", nil))
+	failed := findSpan(t, spans.GetSpans(), "SubscriptionService.GetById")
+	assert.Equal(t, codes.Error, failed.Status.Code)
+}
+
+// TestTracingSQLIntegration проверяет спаны SQL-запросов: текст запроса без значений параметров
+func TestTracingSQLIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+
+	dbConfig, cleanup, err := setupTestContainer(ctx)
+	if err != nil {
+		t.Fatalf("Failed to set up test container: %v", err)
+	}
+	defer cleanup()
+
+	router, err := setupTestServer(dbConfig)
+	if err != nil {
+		t.Fatalf("Failed to set up test server: %v", err)
+	}
+
+	spans := recordSpans(t)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/subscriptions/424242", nil))
+
+	recorded := spans.GetSpans()
+	method := findSpan(t, recorded, "SubscriptionService.GetById")
+
+	var statements []string
+	for _, span := range recorded {
+		if span.Parent.SpanID() != method.SpanContext.SpanID() {
+			continue
+		}
+		for _, attr := range span.Attributes {
+			if attr.Key == "db.statement" {
+				statements = append(statements, attr.Value.AsString())
+			}
+		}
+	}
+
+	// Запрос выполняется внутри спана сервиса; значение параметра не записывается
+	require.NotEmpty(t, statements)
+	for _, statement := range statements {
+		assert.Contains(t, statement, "$1")
+		assert.NotContains(t, statement, "424242")
+	}
+}
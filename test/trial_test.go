@@ -0,0 +1,65 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/handler"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/models"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/repository/postgres"
+	"github.com/evgeney-fullstack/subscription-aggregator-app/internal/app/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTrialStore запоминает запрошенный период и возвращает одну заканчивающуюся пробную подписку
+type fakeTrialStore struct {
+	postgres.SubscriptionStore
+	from, to time.Time
+}
+
+func (s *fakeTrialStore) GetEndingTrials(ctx context.Context, from, to time.Time) ([]models.SubscriptionDB, error) {
+	s.from, s.to = from, to
+	return []models.SubscriptionDB{{Id: 1, ServiceName: "Spotify", TrialEnd: &from, Status: models.StatusTrial}}, nil
+}
+
+// TestEndingTrialsValidation проверяет ответы 400 на неверный горизонт и период запроса к хранилищу
+func TestEndingTrialsValidation(t *testing.T) {
+	store := &fakeTrialStore{}
+	services := &service.Service{TrialStore: service.NewTrialService(store)}
+	router := handler.NewHandler(services, nil, nil, nil).InitRoutes()
+
+	tests := []struct {
+		name           string
+		url            string
+		expectedStatus int
+	}{
+		{name: "Default lookahead", url: "/subscriptions/trials/ending", expectedStatus: http.StatusOK},
+		{name: "Maximum lookahead", url: "/subscriptions/trials/ending?months=24", expectedStatus: http.StatusOK},
+		{name: "Not a number", url: "/subscriptions/trials/ending?months=abc", expectedStatus: http.StatusBadRequest},
+		{name: "Zero months", url: "/subscriptions/trials/ending?months=0", expectedStatus: http.StatusBadRequest},
+		{name: "Too far ahead", url: "/subscriptions/trials/ending?months=25", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+
+	// Горизонт в три месяца начинается с текущего месяца и включает два следующих
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/subscriptions/trials/ending?months=3", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Spotify")
+
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, thisMonth, store.from)
+	assert.Equal(t, thisMonth.AddDate(0, 2, 0), store.to)
+}